@@ -113,9 +113,13 @@ type CameraActionZoom struct {
 // CameraActionPan - pan the camera by the given vector
 type CameraActionPan struct{ By rl.Vector2 }
 
+// CameraActionFit - zoom and center the camera on the given world bounds
+type CameraActionFit struct{ Bounds rl.Rectangle }
+
 func (a CameraActionReset) Target() ActionTarget { return TargetCamera }
 func (a CameraActionZoom) Target() ActionTarget  { return TargetCamera }
 func (a CameraActionPan) Target() ActionTarget   { return TargetCamera }
+func (a CameraActionFit) Target() ActionTarget   { return TargetCamera }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // [TargetSelector] actions
@@ -223,6 +227,9 @@ type SelectionActionInitSelection struct{ Selection ObjectSelection }
 // SelectionActionDelete - delete the current selection ([SelectionNormal])
 type SelectionActionDelete struct{}
 
+// SelectionActionSwapSelections - swap the current selection with the secondary one
+type SelectionActionSwapSelections struct{}
+
 // SelectionActionBeginTransformation - switch selection to either [SelectionDrag] or [SelectionDuplicate]
 type SelectionActionBeginTransformation struct {
 	// Selection mode
@@ -251,6 +258,7 @@ type SelectionActionEndTransformation struct {
 func (a SelectionActionInitSingleDrag) Target() ActionTarget      { return TargetSelection }
 func (a SelectionActionInitSelection) Target() ActionTarget       { return TargetSelection }
 func (a SelectionActionDelete) Target() ActionTarget              { return TargetSelection }
+func (a SelectionActionSwapSelections) Target() ActionTarget      { return TargetSelection }
 func (a SelectionActionBeginTransformation) Target() ActionTarget { return TargetSelection }
 func (a SelectionActionMoveTo) Target() ActionTarget              { return TargetSelection }
 func (a SelectionActionMoveBy) Target() ActionTarget              { return TargetSelection }
@@ -0,0 +1,456 @@
+// analysis - Read-only scene queries (crossings, ...)
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"slices"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Path crossings
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// PathCrossing represents two paths crossing each other
+type PathCrossing struct {
+	// I is the index of the first path in [Scene.Paths] (I < J)
+	I int
+	// J is the index of the second path in [Scene.Paths]
+	J int
+	// Pos is the intersection point (world coordinates)
+	Pos rl.Vector2
+}
+
+// FindPathCrossings returns every pair of crossing paths, sorted by (I, J)
+//
+// Paths sharing an end or touching by an end (junctions) do not count as crossing,
+// see [SegmentIntersection]. Hidden paths (see [Scene.Hide]) are skipped.
+//
+// Only the paths near each other in the spatial index are tested, see [Scene.updateSpatialIndex].
+func (s *Scene) FindPathCrossings() []PathCrossing {
+	s.updateSpatialIndex()
+	var crossings []PathCrossing
+	for i, p := range s.Paths {
+		if p.Hidden {
+			continue
+		}
+		near, ok := s.index.pathsNear(rl.NewRectangleCorners(p.Start, p.End))
+		if !ok {
+			near = Range(0, len(s.Paths))
+		}
+		// sorted and unique, each pair is tested once, from its lowest index
+		slices.Sort(near)
+		for _, j := range slices.Compact(near) {
+			q := s.Paths[j]
+			if j <= i || q.Hidden {
+				continue
+			}
+			if pos, ok := SegmentIntersection(p.Start, p.End, q.Start, q.End); ok {
+				crossings = append(crossings, PathCrossing{I: i, J: j, Pos: pos})
+			}
+		}
+	}
+	return crossings
+}
+
+// updateCrossings recomputes the cached path crossings if needed
+func (s *Scene) updateCrossings() {
+	if !s.crossingsUpToDate {
+		s.crossings = s.FindPathCrossings()
+		s.crossingsUpToDate = true
+	}
+}
+
+const crossingRadius = 1.
+
+// drawCrossings highlights the cached path crossings
+func (s Scene) drawCrossings() {
+	color := colors.WithAlpha(colors.Red500, 0.5)
+	for _, c := range s.crossings {
+		if dims.ExWorld.CheckCollisionPoint(c.Pos) {
+			rl.DrawCircleV(c.Pos, crossingRadius, color)
+		}
+	}
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Resource summary
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// ClassAttributes holds named numeric attributes of a building class (eg. "power": -4)
+type ClassAttributes map[string]float32
+
+// ClassAttributeTable associates building classes to their [ClassAttributes]
+type ClassAttributeTable map[string]ClassAttributes
+
+// classAttributes is the attribute table used by [Scene.ResourceSummary], see [SetClassAttributes]
+var classAttributes ClassAttributeTable
+
+// SetClassAttributes sets the per-class attribute table used by [Scene.ResourceSummary]
+//
+// The table is not copied, nil unregisters every attribute.
+func SetClassAttributes(table ClassAttributeTable) { classAttributes = table }
+
+// ResourceSummary holds aggregated figures over the scene buildings
+type ResourceSummary struct {
+	// Counts is the number of placed buildings by class
+	Counts map[string]int
+	// Totals is the sum of each registered attribute over the placed buildings
+	//
+	// It is empty if no attributes are registered for the placed classes.
+	Totals map[string]float32
+}
+
+// ResourceSummary returns the building counts by class and the attributes totals
+// (see [SetClassAttributes]) over all the placed buildings
+func (s Scene) ResourceSummary() ResourceSummary {
+	summary := ResourceSummary{Counts: s.BillOfMaterials(), Totals: map[string]float32{}}
+	for class, count := range summary.Counts {
+		for name, val := range classAttributes[class] {
+			summary.Totals[name] += val * float32(count)
+		}
+	}
+	return summary
+}
+
+// BillOfMaterials returns the number of placed buildings by class
+func (s Scene) BillOfMaterials() map[string]int {
+	counts := map[string]int{}
+	for _, b := range s.Buildings {
+		counts[b.Def().Class]++
+	}
+	return counts
+}
+
+// PathLengthByClass returns the total length of the paths by class
+func (s Scene) PathLengthByClass() map[string]float32 {
+	_, lengths := s.pathsByClass()
+	return lengths
+}
+
+// pathsByClass returns the number and the total length of the paths by class
+func (s Scene) pathsByClass() (map[string]int, map[string]float32) {
+	counts, lengths := map[string]int{}, map[string]float32{}
+	for _, p := range s.Paths {
+		class := p.Def().Class
+		counts[class]++
+		lengths[class] += p.Start.Distance(p.End)
+	}
+	return counts, lengths
+}
+
+// ClassSummary holds the figures of a building or path class, see [Scene.Summary]
+type ClassSummary struct {
+	// Buildings is the number of buildings of the class
+	Buildings int
+	// Paths is the number of paths of the class
+	Paths int
+	// PathsLength is the total length of the paths of the class
+	PathsLength float32
+}
+
+// Summary returns the number of buildings and paths, and the paths total length, by class: a bill of
+// materials of the scene.
+//
+// It is built from [Scene.BillOfMaterials] and [Scene.PathLengthByClass] figures, and cached until
+// the next scene change. The returned map must not be modified.
+func (s *Scene) Summary() map[string]ClassSummary {
+	if s.summaryUpToDate {
+		return s.summary
+	}
+	summary := map[string]ClassSummary{}
+	for class, n := range s.BillOfMaterials() {
+		summary[class] = ClassSummary{Buildings: n}
+	}
+	counts, lengths := s.pathsByClass()
+	for class, n := range counts {
+		cs := summary[class]
+		cs.Paths, cs.PathsLength = n, lengths[class]
+		summary[class] = cs
+	}
+	s.summary, s.summaryUpToDate = summary, true
+	return summary
+}
+
+// summaryLines returns a line per class of [Scene.Summary], sorted by class
+func (s *Scene) summaryLines() []string {
+	summary := s.Summary()
+	classes := make([]string, 0, len(summary))
+	for class := range summary {
+		classes = append(classes, class)
+	}
+	slices.Sort(classes)
+	lines := make([]string, 0, len(classes))
+	for _, class := range classes {
+		cs := summary[class]
+		line := class + ":"
+		if cs.Buildings > 0 {
+			line += fmt.Sprintf(" %d building(s)", cs.Buildings)
+		}
+		if cs.Paths > 0 {
+			line += fmt.Sprintf(" %d path(s), length %.1f", cs.Paths, cs.PathsLength)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Metrics returns the metrics of every scene object (hidden ones included), see
+// [ObjectSelection.Metrics]: among others the total length of the paths and the total footprint area
+// of the buildings (overlaps are counted twice).
+func (s Scene) Metrics() SelectionMetrics {
+	return s.ObjectCollection.SelectAll().Metrics(s)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Statistics export
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// statsVersion is the version of the [Scene.ExportStatsJSON] schema, incremented on breaking changes
+const statsVersion = 1
+
+// sceneStats is the [Scene.ExportStatsJSON] schema
+type sceneStats struct {
+	Version   int                `json:"version"`
+	Buildings map[string]int     `json:"buildings"`
+	Paths     map[string]float32 `json:"pathLengths"`
+	Bounds    struct {
+		X      float32 `json:"x"`
+		Y      float32 `json:"y"`
+		Width  float32 `json:"width"`
+		Height float32 `json:"height"`
+	} `json:"bounds"`
+	Totals struct {
+		Buildings int `json:"buildings"`
+		Paths     int `json:"paths"`
+		TextBoxes int `json:"textBoxes"`
+		Objects   int `json:"objects"`
+	} `json:"totals"`
+}
+
+// ExportStatsJSON writes the scene statistics as JSON: building counts and path lengths by class,
+// scene bounds and objects totals.
+//
+// The schema is versioned by its "version" field. All errors originate from the underlying [io.Writer].
+func (s Scene) ExportStatsJSON(w io.Writer) error {
+	stats := sceneStats{
+		Version:   statsVersion,
+		Buildings: s.BillOfMaterials(),
+		Paths:     s.PathLengthByClass(),
+	}
+	bounds := s.ObjectCollection.SelectAll().Bounds
+	stats.Bounds.X, stats.Bounds.Y = bounds.X, bounds.Y
+	stats.Bounds.Width, stats.Bounds.Height = bounds.Width, bounds.Height
+	stats.Totals.Buildings = len(s.Buildings)
+	stats.Totals.Paths = len(s.Paths)
+	stats.Totals.TextBoxes = len(s.TextBoxes)
+	stats.Totals.Objects = s.objectCount()
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(stats)
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Outliers
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Outliers returns the objects far from the scene main cluster
+//
+// An object is an outlier if its distance to the scene centroid is greater than stddevFactor times
+// the standard distance (root mean square of all the objects distances to the centroid).
+// Objects positions are their bounds center (middle for paths).
+//
+// Objects are returned buildings first, then paths, then text boxes, each by increasing index.
+func (s Scene) Outliers(stddevFactor float32) []Object {
+	var objs []Object
+	var positions []rl.Vector2
+	for i, b := range s.Buildings {
+		objs = append(objs, Object{Type: TypeBuilding, Idx: i})
+		positions = append(positions, b.Bounds().Center())
+	}
+	for i, p := range s.Paths {
+		objs = append(objs, Object{Type: TypePath, Idx: i})
+		positions = append(positions, p.Start.Add(p.End).Scale(0.5))
+	}
+	for i, tb := range s.TextBoxes {
+		objs = append(objs, Object{Type: TypeTextBox, Idx: i})
+		positions = append(positions, tb.Bounds.Center())
+	}
+	if len(positions) < 2 {
+		return nil
+	}
+
+	var centroid rl.Vector2
+	for _, pos := range positions {
+		centroid = centroid.Add(pos)
+	}
+	centroid = centroid.Scale(1 / float32(len(positions)))
+
+	var sumSqr float32
+	for _, pos := range positions {
+		sumSqr += pos.DistanceSqr(centroid)
+	}
+	threshold := stddevFactor * math32.Sqrt(sumSqr/float32(len(positions)))
+
+	var outliers []Object
+	for i, pos := range positions {
+		if pos.Distance(centroid) > threshold {
+			outliers = append(outliers, objs[i])
+		}
+	}
+	return outliers
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Gaps
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// uniformGapEpsilon is the maximum spread between gaps for [GapStats.Uniform] (world units)
+const uniformGapEpsilon = 1e-3
+
+// Gap returns the distance between the bounds of buildings a and b, 0 if touching and the negated
+// overlap depth if overlapping, see [RectGap]
+func (s Scene) Gap(a, b int) float32 {
+	return RectGap(s.Buildings[a].Bounds(), s.Buildings[b].Bounds())
+}
+
+// GapStats holds the gaps between neighbor buildings, see [Scene.SelectionGaps]
+type GapStats struct {
+	// Min is the smallest gap
+	Min float32
+	// Max is the largest gap
+	Max float32
+	// Uniform is true if all gaps are equal (up to a small epsilon)
+	Uniform bool
+}
+
+// SelectionGaps returns statistics over the gap between each selected building and its nearest
+// selected neighbor.
+//
+// It returns false if less than two buildings are selected.
+func (s Scene) SelectionGaps(sel ObjectSelection) (GapStats, bool) {
+	if len(sel.BuildingIdxs) < 2 {
+		return GapStats{}, false
+	}
+	stats := GapStats{Min: math32.MaxFloat32, Max: -math32.MaxFloat32}
+	for _, i := range sel.BuildingIdxs {
+		nearest := float32(math32.MaxFloat32)
+		for _, j := range sel.BuildingIdxs {
+			if i != j {
+				nearest = min(nearest, s.Gap(i, j))
+			}
+		}
+		stats.Min = min(stats.Min, nearest)
+		stats.Max = max(stats.Max, nearest)
+	}
+	stats.Uniform = stats.Max-stats.Min <= uniformGapEpsilon
+	return stats, true
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Dominant direction
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// DominantDirectionNear returns the mean direction of the paths within radius of pos, as a unit
+// vector, and false if there are none.
+//
+// Directions are undirected (a path and its reverse count the same) and weighted by the paths length.
+func (s Scene) DominantDirectionNear(pos rl.Vector2, radius float32) (rl.Vector2, bool) {
+	// sum unit vectors at twice the paths angle, so that opposite directions add up
+	var sum rl.Vector2
+	for _, p := range s.Paths {
+		if p.Hidden || PointSegmentDistance(pos, p.Start, p.End) > radius {
+			continue
+		}
+		d := p.End.Subtract(p.Start)
+		angle := 2 * math32.Atan2(d.Y, d.X)
+		sum = sum.Add(vec2(math32.Cos(angle), math32.Sin(angle)).Scale(d.Length()))
+	}
+	if sum.LengthSqr() == 0 {
+		return rl.Vector2{}, false
+	}
+	angle := math32.Atan2(sum.Y, sum.X) / 2
+	return vec2(math32.Cos(angle), math32.Sin(angle)), true
+}
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Fingerprint
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Fingerprint returns a hash of the scene objects, independent of the objects order
+//
+// Two scenes with the same objects in any order have the same fingerprint, any change to an object
+// saved field (see [Scene.SaveToText]) changes it (up to hash collisions).
+func (s Scene) Fingerprint() uint64 {
+	var sum uint64
+	h := fnv.New64a()
+	add := func(format string, args ...any) {
+		h.Reset()
+		fmt.Fprintf(h, format, args...)
+		// sum is commutative: order independent
+		sum += h.Sum64()
+	}
+	for _, b := range s.Buildings {
+		add("%s %v %v %d %v", b.Def().Class, b.Pos.X, b.Pos.Y, b.Rot, b.Hidden)
+	}
+	for _, p := range s.Paths {
+		add("%s %v %v %v %v %v", p.Def().Class, p.Start.X, p.Start.Y, p.End.X, p.End.Y, p.Hidden)
+	}
+	for _, tb := range s.TextBoxes {
+		add("%s %v %v %v %v %q %v %v", textboxClass, tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height,
+			tb.Content, tb.Hidden, tb.Background)
+	}
+	for tag, value := range s.metadata {
+		add("%s=%s", tag, value)
+	}
+	return sum
+}
+
+// ScenesSaveEqual returns true if a and b save to identical bytes with [Scene.SaveToText]
+//
+// Unlike [Scene.Fingerprint], it depends on the objects order. See [FirstSaveDifference].
+func ScenesSaveEqual(a, b *Scene) (bool, error) {
+	pos, err := FirstSaveDifference(a, b)
+	return err == nil && pos < 0, err
+}
+
+// FirstSaveDifference returns the byte offset of the first difference between the saves of a and b
+// with [Scene.SaveToText], or -1 if they are identical.
+//
+// If one save is a prefix of the other, the offset is the length of the shorter one. The scenes are
+// not modified (their [Scene.FormatVersion] is left as is).
+func FirstSaveDifference(a, b *Scene) (int, error) {
+	save := func(s *Scene) ([]byte, error) {
+		var buf bytes.Buffer
+		cpy := *s
+		err := cpy.SaveToText(&buf)
+		return buf.Bytes(), err
+	}
+	bytesA, err := save(a)
+	if err != nil {
+		return -1, err
+	}
+	bytesB, err := save(b)
+	if err != nil {
+		return -1, err
+	}
+	n := min(len(bytesA), len(bytesB))
+	for i := range n {
+		if bytesA[i] != bytesB[i] {
+			return i, nil
+		}
+	}
+	if len(bytesA) != len(bytesB) {
+		return n, nil
+	}
+	return -1, nil
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path"
-	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/log"
@@ -17,7 +17,7 @@ import (
 
 const (
 	// Version of the save file format
-	version          = 0
+	version          = 1
 	windowTitle      = "Satisfied"
 	extFilter        = "*.satisfied"
 	extFilterDesc    = "Satisfied project"
@@ -53,6 +53,9 @@ type DrawCounts struct {
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // save project to file and updates window title and [App.filepath] on success
+//
+// The recovery file of the project is removed, as is the one of unsaved projects when the project is
+// first saved (see [recoveryPath]).
 func (a *App) saveFile(filepath string) error {
 	log.Info("saving project", "path", filepath)
 	file, err := os.Create(filepath)
@@ -66,9 +69,15 @@ func (a *App) saveFile(filepath string) error {
 		log.Error("cannot write to file", "path", filepath, "err", err)
 		return err
 	}
+	wasUnsaved := a.filepath == ""
 	a.filepath = filepath
 	scene.ResetModified()
 	log.Info("project saved", "path", filepath)
+	removeRecovery(filepath)
+	// the panic handler saves unsaved projects to their recovery file
+	if unsavedRecovery, err := recoveryPath(""); wasUnsaved && err == nil && NormalizePath(filepath) != unsavedRecovery {
+		removeRecovery("")
+	}
 	return nil
 }
 
@@ -82,16 +91,25 @@ func (a *App) loadFile(filepath string) error {
 	}
 	defer file.Close()
 	fileScene := Scene{}
-	err = fileScene.LoadFromText(file)
+	warnings, issues, err := fileScene.LoadFromTextValidatedMode(file, DecodeLenient)
 	if err != nil {
 		log.Error("error parsing project", "path", filepath, "err", err)
 		msg := fmt.Sprintf("Cannot load project: %s\n\nError: %s", filepath, RemoveQuotes(err.Error()))
 		tfd.MessageBox(windowTitle+" -Error loading file", msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
 		return err
 	}
+	for _, warning := range warnings {
+		log.Warn("project decode warning", "path", filepath, "err", warning)
+	}
+	for _, issue := range issues {
+		log.Warn("project issue", "path", filepath, "kind", issue.Kind, "msg", issue.Msg)
+	}
 	a.filepath = filepath
 	scene = fileScene
-	log.Info("project loaded", "path", filepath)
+	log.Info("project loaded", "path", filepath, "issues", len(issues))
+	if ver := scene.FormatVersion(); ver < version {
+		log.Info("project will be upgraded on save", "path", filepath, "from", ver, "to", version)
+	}
 	return nil
 }
 
@@ -170,6 +188,9 @@ func (a *App) doNew() Action {
 	app.filepath = ""
 	scene.Buildings = scene.Buildings[:0]
 	scene.Paths = scene.Paths[:0]
+	scene.formatVersion = version
+	scene.metadata = nil
+	scene.invalidateCaches()
 	return a.doSwitchMode(ModeNormal, ResetAll().WithCamera(true))
 }
 
@@ -217,6 +238,21 @@ func (a *App) doSave(filepath string) Action {
 // GUI actions
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// doCancelPlacement cancels the placement of the new object of the current mode
+func (a *App) doCancelPlacement() Action {
+	switch a.Mode {
+	case ModeNewPath:
+		return newPath.doCancel()
+	case ModeNewBuilding:
+		return newBuilding.doCancel()
+	case ModeNewTextBox:
+		return newTextBox.doCancel()
+	default:
+		log.Warn("cannot cancel placement", "reason", "not placing", "mode", a.Mode)
+		return nil
+	}
+}
+
 func (a *App) doUndo() Action {
 	if a.isNormal() {
 		scene.Undo()
@@ -319,6 +355,8 @@ type AppOptions struct {
 	File string
 	// Target / Max FPS
 	Fps int
+	// Autosave interval to the recovery file (see [Autosave]), 0 for the default, negative to disable
+	AutosaveInterval time.Duration
 }
 
 // Init initializes the application.
@@ -375,6 +413,15 @@ func Init(assets embed.FS, opts *AppOptions) error {
 			log.Error("init app with empty scene", "err", err)
 		}
 	}
+	app.checkRecovery(opts.File)
+
+	switch {
+	case opts.AutosaveInterval > 0:
+		autosave.Interval = opts.AutosaveInterval
+	case opts.AutosaveInterval < 0:
+		autosave.save = nil
+	}
+	log.Info("autosave", "enabled", autosave.save != nil, "interval", autosave.Interval)
 	return nil
 }
 
@@ -500,6 +547,7 @@ func update() {
 	animations.Update()
 
 	keyboard.Update()
+	numInput.Update()
 
 	dims.Update()
 	camera.Update()
@@ -508,6 +556,18 @@ func update() {
 
 	app.update()
 	scene.Update()
+	autosave.Update()
+
+	// the ruler captures inputs while active
+	ruler.Update()
+	if ruler.Active() {
+		return
+	}
+	// as does the stamp
+	stamp.Update()
+	if stamp.Active() {
+		return
+	}
 
 	for action := getAction(); action != nil; action = dispatchAction(action) {
 		// empty loop body
@@ -541,6 +601,8 @@ func draw() {
 	case ModeSelection:
 		selection.Draw()
 	}
+	stamp.Draw()
+	ruler.Draw()
 	camera.EndMode2D()
 
 	raygui.SetFont(labelFont)
@@ -606,19 +668,11 @@ func panicHandler() {
 
 	os.Stderr.Write(fullStack())
 
-	savepath := app.filepath
-	if savepath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			msg := fmt.Sprintf(panicMessageNoBackupFile, panicErr)
-			tfd.MessageBox(panicTitle, msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
-			return
-		}
-		savepath = NormalizePath(filepath.Join(home, "recover.satisfied"))
-	} else {
-		savepath = NormalizePath(savepath)
-		ext := filepath.Ext(savepath)
-		savepath = savepath[:len(savepath)-len(ext)] + ".recover" + ext
+	savepath, err := recoveryPath(app.filepath)
+	if err != nil {
+		msg := fmt.Sprintf(panicMessageNoBackupFile, panicErr)
+		tfd.MessageBox(panicTitle, msg, tfd.DialogOk, tfd.IconError, tfd.ButtonOkYes)
+		return
 	}
 
 	if err := app.saveFile(savepath); err != nil {
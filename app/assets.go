@@ -3,8 +3,10 @@ package app
 import (
 	"embed"
 	"encoding/json"
+	"fmt"
 
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
 	"github.com/gen2brain/raylib-go/raygui"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -88,6 +90,12 @@ func LoadAssets(assets embed.FS) error {
 		log.Fatal("cannot parse building defs", "err", err)
 		return err
 	}
+	for _, def := range buildingDefs {
+		if def.Anchor.X != math32.Round(def.Anchor.X) || def.Anchor.Y != math32.Round(def.Anchor.Y) {
+			log.Fatal("invalid building def", "class", def.Class, "reason", "anchor not in whole world units")
+			return fmt.Errorf("building def %q: anchor not in whole world units", def.Class)
+		}
+	}
 	log.Debug("assets.buildingsDefs", "status", "parsed", "count", len(buildingDefs))
 	if log.WillTrace() {
 		for i, def := range buildingDefs {
@@ -0,0 +1,216 @@
+// autosave - Throttled periodic scene saves
+
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/bonoboris/satisfied/log"
+	tfd "github.com/bonoboris/satisfied/tinyfiledialogs"
+)
+
+const (
+	defaultAutosaveInterval    = 2 * time.Minute
+	defaultAutosaveQuietPeriod = 5 * time.Second
+)
+
+// Autosave state, saving to the recovery file
+var autosave = Autosave{Interval: defaultAutosaveInterval, QuietPeriod: defaultAutosaveQuietPeriod, save: saveRecovery}
+
+// Autosave periodically saves the scene, without hammering the disk during rapid edits
+//
+// A save is written only when all of the following hold:
+//   - the scene is modified ([Scene.IsModified])
+//   - at least Interval elapsed since the last save
+//   - no scene operation was performed during the last QuietPeriod
+//   - the scene content changed since the last save ([Scene.Fingerprint])
+//   - no undo group is in progress ([Scene.BeginUndoGroup])
+//
+// Saves do not change the scene modified state.
+type Autosave struct {
+	// Interval is the minimum duration between two saves
+	Interval time.Duration
+	// QuietPeriod is the duration without any scene operation required before saving
+	QuietPeriod time.Duration
+
+	// save writes the scene, autosave is disabled if nil
+	save func(s *Scene) error
+
+	// time of the last save (or of the autosave initialization)
+	lastSave time.Time
+	// time of the last observed scene change
+	lastChange time.Time
+	// last observed scene revision ([Scene.revision])
+	revision int
+	// fingerprint of the last saved scene (or of the scene at autosave initialization)
+	fingerprint uint64
+}
+
+func (a Autosave) traceState() {
+	log.Trace("autosave", "interval", a.Interval, "quietPeriod", a.QuietPeriod, "enabled", a.save != nil)
+	log.Trace("autosave", "lastSave", a.lastSave, "lastChange", a.lastChange, "revision", a.revision, "fingerprint", a.fingerprint)
+}
+
+// Update saves the scene if needed
+//
+// Depends on [Scene]
+func (a *Autosave) Update() {
+	a.update(time.Now())
+}
+
+// update saves the scene if needed given the current time, and returns whether it has
+func (a *Autosave) update(now time.Time) bool {
+	if a.save == nil {
+		return false
+	}
+	if a.lastSave.IsZero() {
+		a.lastSave = now
+		a.fingerprint = scene.Fingerprint()
+	}
+	if scene.revision != a.revision {
+		// scene changed since last update: restart the quiet period
+		a.revision = scene.revision
+		a.lastChange = now
+		return false
+	}
+	if !scene.IsModified() || now.Sub(a.lastSave) < a.Interval || now.Sub(a.lastChange) < a.QuietPeriod ||
+		scene.groupDepth > 0 {
+		return false
+	}
+	fingerprint := scene.Fingerprint()
+	if fingerprint == a.fingerprint {
+		// identical content, postpone next check by a whole interval
+		a.lastSave = now
+		return false
+	}
+	if err := a.save(&scene); err != nil {
+		log.Error("autosave failed", "err", err)
+		a.lastSave = now // retry after a whole interval
+		return false
+	}
+	log.Info("autosave", "fingerprint", fingerprint)
+	a.lastSave = now
+	a.fingerprint = fingerprint
+	a.traceState()
+	return true
+}
+
+// recoveryPath returns the recovery file of a project: next to it with a ".recover" extension prefix,
+// or "recover.satisfied" in the user home directory for unsaved projects.
+func recoveryPath(project string) (string, error) {
+	if project == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return NormalizePath(filepath.Join(home, "recover.satisfied")), nil
+	}
+	project = NormalizePath(project)
+	ext := filepath.Ext(project)
+	return project[:len(project)-len(ext)] + ".recover" + ext, nil
+}
+
+// saveRecovery writes the scene to the recovery file of the current project, see [recoveryPath]
+//
+// The file is written through a temporary file, so that a failed save never leaves a truncated
+// recovery file. The scene modified state is unchanged.
+func saveRecovery(s *Scene) error {
+	path, err := recoveryPath(app.filepath)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := s.SaveToText(file); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	log.Debug("autosave.saveRecovery", "path", path)
+	return os.Rename(tmp, path)
+}
+
+// removeRecovery removes the recovery file of a project, after it has been saved
+func removeRecovery(project string) {
+	path, err := recoveryPath(project)
+	if err != nil {
+		return
+	}
+	if err := os.Remove(path); err == nil {
+		log.Debug("removed recovery file", "path", path)
+	} else if !os.IsNotExist(err) {
+		log.Warn("cannot remove recovery file", "path", path, "err", err)
+	}
+}
+
+// retireRecovery renames the recovery file of unsaved projects (see [recoveryPath]) with a ".bak"
+// suffix once it has been loaded or declined, so that it is not offered again on every startup. Only
+// the last retired file is kept.
+func retireRecovery() {
+	path, err := recoveryPath("")
+	if err != nil {
+		return
+	}
+	if err := os.Rename(path, path+".bak"); err == nil {
+		log.Debug("retired recovery file", "path", path)
+	} else if !os.IsNotExist(err) {
+		log.Warn("cannot retire recovery file", "path", path, "err", err)
+	}
+}
+
+// newerRecovery returns the recovery file of a project if it exists and is newer than the project
+func newerRecovery(project string) (string, bool) {
+	path, err := recoveryPath(project)
+	if err != nil {
+		return "", false
+	}
+	recInfo, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	if project != "" {
+		if info, err := os.Stat(project); err == nil && !recInfo.ModTime().After(info.ModTime()) {
+			return "", false
+		}
+	}
+	return path, true
+}
+
+// checkRecovery offers to load the recovery file of a project if it is newer than the project, the
+// recovered scene is then marked as modified and still saved to the project.
+//
+// The recovery file of unsaved projects is retired once loaded or declined, see [retireRecovery].
+func (a *App) checkRecovery(project string) {
+	path, ok := newerRecovery(project)
+	if !ok {
+		return
+	}
+	msg := fmt.Sprintf("A recovery file more recent than the project was found:\n%s\n\nDo you want to load it ?", path)
+	if tfd.MessageBox(windowTitle+" - Recovery file", msg, tfd.DialogYesNo, tfd.IconQuestion, tfd.ButtonOkYes) != tfd.ButtonOkYes {
+		log.Info("recovery file ignored", "path", path)
+		if project == "" {
+			retireRecovery()
+		}
+		return
+	}
+	err := a.loadFile(path)
+	if project == "" {
+		retireRecovery()
+	}
+	if err != nil {
+		return
+	}
+	a.filepath = project
+	scene.savedHistoryPos = -1 // recovered changes are unsaved
+	log.Info("recovery file loaded", "path", path, "project", project)
+}
@@ -0,0 +1,102 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAutosaveRecovery checks that autosaves go to the recovery file without changing the modified
+// state, and that the recovery file is only offered when newer than the project
+func TestAutosaveRecovery(t *testing.T) {
+	project := filepath.Join(t.TempDir(), "factory.satisfied")
+	filepath0 := app.filepath
+	t.Cleanup(func() { app.filepath = filepath0; scene = Scene{} })
+	app.filepath = project
+	scene = Scene{}
+	if err := os.WriteFile(project, []byte("#VERSION=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	saved := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(project, saved, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	a := Autosave{Interval: time.Minute, save: saveRecovery}
+	now := time.Now()
+	a.update(now) // initialization
+	scene.AddBuilding(Building{DefIdx: 0, Pos: vec2(10, 20)})
+	a.update(now.Add(time.Second))     // change observed
+	if !a.update(now.Add(time.Hour)) { // quiet and interval elapsed
+		t.Fatal("no autosave")
+	}
+	if !scene.IsModified() {
+		t.Error("autosave reset the modified state")
+	}
+
+	path, ok := newerRecovery(project)
+	if want := filepath.Join(filepath.Dir(project), "factory.recover.satisfied"); !ok || path != NormalizePath(want) {
+		t.Fatalf("recovery file %q %v, want %q", path, ok, want)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "Assembler 10 20 0") {
+		t.Errorf("recovery file content %q (%v)", data, err)
+	}
+
+	// older recovery file
+	old := saved.Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := newerRecovery(project); ok {
+		t.Error("older recovery file offered")
+	}
+
+	removeRecovery(project)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("recovery file not removed: %v", err)
+	}
+}
+
+// TestUnsavedRecovery checks that the recovery file of unsaved projects is retired once handled, and
+// removed when the project is first saved
+func TestUnsavedRecovery(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	filepath0 := app.filepath
+	t.Cleanup(func() { app.filepath = filepath0; scene = Scene{} })
+	app.filepath = ""
+	scene = Scene{}
+	path, err := recoveryPath("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func() {
+		t.Helper()
+		if err := os.WriteFile(path, []byte("#VERSION=1\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write()
+	if _, ok := newerRecovery(""); !ok {
+		t.Fatal("unsaved project recovery file not offered")
+	}
+	retireRecovery()
+	if _, ok := newerRecovery(""); ok {
+		t.Error("retired recovery file offered")
+	}
+	if _, err := os.Stat(path + ".bak"); err != nil {
+		t.Errorf("retired recovery file not kept: %v", err)
+	}
+
+	write()
+	if err := app.saveFile(filepath.Join(home, "factory.satisfied")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("unsaved project recovery file not removed on first save: %v", err)
+	}
+}
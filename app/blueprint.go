@@ -0,0 +1,241 @@
+// blueprint - Reusable groups of objects
+
+package app
+
+import (
+	"errors"
+	"io"
+	"os"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	tfd "github.com/bonoboris/satisfied/tinyfiledialogs"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// thumbnailSize is the width and height of blueprints thumbnails (pixels)
+const thumbnailSize = 128
+
+// Blueprint is a named group of objects, see [Scene.SaveSelectionAsBlueprint]
+type Blueprint struct {
+	Name string
+	// Objects are normalized: their bounding box top-left is at the origin
+	Objects ObjectCollection
+	// Thumbnail is a PNG encoded preview of the objects (nil if it could not be rendered)
+	Thumbnail []byte
+}
+
+// SaveSelectionAsBlueprint returns a blueprint named name made of the selected objects, with a
+// preview thumbnail.
+//
+// Only fully selected paths are kept. The thumbnail is only rendered when the window is ready.
+func (s Scene) SaveSelectionAsBlueprint(sel ObjectSelection, name string) (Blueprint, error) {
+	if name == "" {
+		return Blueprint{}, errors.New("empty blueprint name")
+	}
+	var col ObjectCollection
+	for _, idx := range sel.BuildingIdxs {
+		col.Buildings = append(col.Buildings, s.Buildings[idx])
+	}
+	for _, idx := range sel.FullPathIdxs() {
+		col.Paths = append(col.Paths, s.Paths[idx])
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		col.TextBoxes = append(col.TextBoxes, s.TextBoxes[idx])
+	}
+	if col.IsEmpty() {
+		return Blueprint{}, errors.New("empty selection")
+	}
+
+	bounds := col.normalize()
+
+	bp := Blueprint{Name: name, Objects: col}
+	if rl.IsWindowReady() {
+		bp.Thumbnail = renderOffscreenPNG(GrowRect(bounds, 8), thumbnailSize, func() { col.draw(DrawNormal) })
+	}
+	log.Info("save blueprint", "name", name, "buildings", len(col.Buildings), "paths", len(col.Paths),
+		"textBoxes", len(col.TextBoxes), "thumbnail", len(bp.Thumbnail))
+	return bp, nil
+}
+
+// normalize translates the objects so that their bounding box top-left is at the origin, and returns
+// the translated bounding box
+func (oc *ObjectCollection) normalize() rl.Rectangle {
+	bounds := oc.SelectAll().Bounds
+	sceneOpDelta{Translate: bounds.Position().Negate()}.applyCollection(oc)
+	bounds.X, bounds.Y = 0, 0
+	return bounds
+}
+
+// SaveSelectionToText saves the selected objects into text format (see [Scene.SaveToText]),
+// normalized so that their bounding box top-left is at the origin, as a blueprint file to be
+// inserted with [Scene.InsertBlueprintText].
+//
+// Paths with a single selected end are saved as full paths. Errors originate from the underlying
+// [io.Writer], or from an empty selection.
+func (s Scene) SaveSelectionToText(w io.Writer, sel ObjectSelection) error {
+	var col ObjectCollection
+	col.Buildings = CopyIdxs(col.Buildings, s.Buildings, sel.BuildingIdxs)
+	col.Paths = CopyIdxs(col.Paths, s.Paths, sel.AnyPathIdxs())
+	col.TextBoxes = CopyIdxs(col.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	if col.IsEmpty() {
+		return errors.New("empty selection")
+	}
+	col.normalize()
+	log.Info("save selection", "format", "text", "buildings", len(col.Buildings), "paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+	blueprint := Scene{ObjectCollection: col}
+	return blueprint.SaveToText(w)
+}
+
+// readBlueprint reads the objects of a blueprint file (see [Scene.SaveSelectionToText]), normalized
+// so that their bounding box top-left is at the origin, malformed lines are skipped (see
+// [DecodeLenient]).
+func readBlueprint(r io.Reader) (ObjectCollection, error) {
+	var blueprint Scene
+	if _, err := blueprint.LoadFromTextMode(r, DecodeLenient); err != nil {
+		return ObjectCollection{}, err
+	}
+	col := blueprint.ObjectCollection
+	if col.IsEmpty() {
+		return ObjectCollection{}, errors.New("empty blueprint")
+	}
+	col.normalize()
+	return col, nil
+}
+
+// openBlueprint prompts for a blueprint file and reads it, see [readBlueprint]
+func openBlueprint(title string) (ObjectCollection, bool) {
+	filepath, ok := tfd.OpenFileDialog(title, "", []string{extFilter}, extFilterDesc)
+	if !ok {
+		log.Debug("open blueprint", "action", "cancel")
+		return ObjectCollection{}, false
+	}
+	file, err := os.Open(filepath)
+	if err != nil {
+		log.Error("cannot open file", "path", filepath, "err", err)
+		return ObjectCollection{}, false
+	}
+	defer file.Close()
+	col, err := readBlueprint(file)
+	if err != nil {
+		log.Error("cannot read blueprint", "path", filepath, "err", err)
+		return ObjectCollection{}, false
+	}
+	return col, true
+}
+
+// InsertBlueprintText adds the objects of a blueprint file (see [Scene.SaveSelectionToText]) with
+// their bounding box top-left at pos, and returns the selection of the added objects.
+//
+// Malformed lines are skipped, see [DecodeLenient].
+func (s *Scene) InsertBlueprintText(r io.Reader, pos rl.Vector2) (ObjectSelection, error) {
+	col, err := readBlueprint(r)
+	if err != nil {
+		return ObjectSelection{}, err
+	}
+	return s.insertBlueprint(col, pos)
+}
+
+// insertBlueprint adds the normalized objects of col translated by pos as a single operation, and
+// returns the selection of the added objects
+func (s *Scene) insertBlueprint(col ObjectCollection, pos rl.Vector2) (ObjectSelection, error) {
+	col = col.clone()
+	sceneOpDelta{Translate: pos}.applyCollection(&col)
+	if !s.AddObjects(col) {
+		return ObjectSelection{}, errors.New("cannot add blueprint objects")
+	}
+	log.Info("insert blueprint", "pos", pos, "buildings", len(col.Buildings), "paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+	return s.tailSelection(col), nil
+}
+
+// doExportBlueprint prompts for a file and saves the selection to it as a blueprint
+func (a *App) doExportBlueprint() Action {
+	if a.Mode != ModeSelection {
+		return nil
+	}
+	log.Info("export blueprint")
+	filepath, ok := tfd.SaveFileDialog("Export selection as blueprint", "", []string{extFilter}, extFilterDesc)
+	if !ok {
+		log.Debug("export blueprint", "action", "cancel")
+		return nil
+	}
+	file, err := os.Create(filepath)
+	if err != nil {
+		log.Error("cannot create file", "path", filepath, "err", err)
+		return nil
+	}
+	defer file.Close()
+	if err := scene.SaveSelectionToText(file, selection.ObjectSelection); err != nil {
+		log.Error("cannot export blueprint", "path", filepath, "err", err)
+	}
+	return nil
+}
+
+// doInsertBlueprint prompts for a blueprint file, inserts it at the cursor and selects it
+func (a *App) doInsertBlueprint() Action {
+	if !a.isNormal() {
+		return nil
+	}
+	log.Info("insert blueprint")
+	col, ok := openBlueprint("Insert blueprint")
+	if !ok {
+		return nil
+	}
+	sel, err := scene.insertBlueprint(col, mouse.SnappedPos)
+	if err != nil {
+		log.Error("cannot insert blueprint", "err", err)
+		return nil
+	}
+	return selection.doInitSelection(sel)
+}
+
+// draw draws the objects of the collection in the given state
+func (oc ObjectCollection) draw(state DrawState) {
+	for i := range oc.TextBoxes {
+		if oc.TextBoxes[i].Background {
+			oc.TextBoxes[i].Draw(state, false)
+		}
+	}
+	for _, p := range oc.Paths {
+		p.Draw(state)
+	}
+	for _, b := range oc.Buildings {
+		b.Draw(state)
+	}
+	for i := range oc.TextBoxes {
+		if !oc.TextBoxes[i].Background {
+			oc.TextBoxes[i].Draw(state, false)
+		}
+	}
+}
+
+// renderOffscreenPNG renders draw into an offscreen texture of size pixels wide and high, with the
+// world rectangle bounds fitted and centered, and returns it PNG encoded (nil on failure).
+//
+// It requires a ready window, and must not be called between BeginTextureMode / EndTextureMode.
+func renderOffscreenPNG(bounds rl.Rectangle, size int32, draw func()) []byte {
+	if bounds.Width <= 0 || bounds.Height <= 0 {
+		return nil
+	}
+	target := rl.LoadRenderTexture(size, size)
+	defer rl.UnloadRenderTexture(target)
+
+	zoom := float32(size) / max(bounds.Width, bounds.Height)
+	cam := rl.Camera2D{
+		Offset: vec2(float32(size)/2, float32(size)/2),
+		Target: bounds.Center(),
+		Zoom:   zoom,
+	}
+	rl.BeginTextureMode(target)
+	rl.ClearBackground(colors.White)
+	rl.BeginMode2D(cam)
+	draw()
+	rl.EndMode2D()
+	rl.EndTextureMode()
+
+	img := rl.LoadImageFromTexture(target.Texture)
+	defer rl.UnloadImage(img)
+	// render textures are stored upside down
+	rl.ImageFlipVertical(img)
+	return rl.ExportImageToMemory(*img, ".png")
+}
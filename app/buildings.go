@@ -9,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/math32"
 	"github.com/bonoboris/satisfied/matrix"
 	"github.com/bonoboris/satisfied/text"
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -22,6 +23,8 @@ type Building struct {
 	DefIdx int
 	Pos    rl.Vector2
 	Rot    int32
+	// Hidden buildings are not drawn and cannot be hovered or selected (see [Scene.Hide])
+	Hidden bool
 }
 
 func (b Building) String() string {
@@ -34,8 +37,7 @@ func (b Building) String() string {
 func (b Building) Def() BuildingDef { return buildingDefs[b.DefIdx] }
 
 func (b Building) matrix() matrix.Matrix {
-	mid := grid.Snap(b.Def().Dims.Scale(0.5))
-	return matrix.NewTranslateV(b.Pos).Rotate(b.Rot).TranslateV(mid.Negate())
+	return matrix.NewTranslateV(b.Pos).Rotate(b.Rot).TranslateV(b.Def().origin().Negate())
 }
 
 func (b Building) Bounds() rl.Rectangle {
@@ -67,7 +69,7 @@ func (b Building) DrawLabel(bounds rl.Rectangle) {
 }
 
 func (b Building) Draw(state DrawState) {
-	if state == DrawSkip {
+	if state == DrawSkip || b.Hidden {
 		return
 	}
 	mat := b.matrix()
@@ -202,14 +204,29 @@ type BuildingDef struct {
 	Class    string
 	Category string
 	Dims     rl.Vector2
-	BeltIn   inputOutputs
-	BeltOut  inputOutputs
-	PipeIn   inputOutputs
-	PipeOut  inputOutputs
+	// Anchor is the offset of the building position from its footprint center (unrotated, whole
+	// world units), for buildings whose logical origin is off-center
+	Anchor  rl.Vector2
+	BeltIn  inputOutputs
+	BeltOut inputOutputs
+	PipeIn  inputOutputs
+	PipeOut inputOutputs
+}
+
+// origin returns the building position in its unrotated footprint (from the top left corner)
+//
+// It is the footprint center rounded to world units, independently of the grid snapping settings,
+// offset by the anchor: footprints of buildings at whole positions stay aligned at cardinal rotations.
+func (b BuildingDef) origin() rl.Vector2 {
+	half := b.Dims.Scale(0.5)
+	return vec2(math32.Round(half.X), math32.Round(half.Y)).Add(b.Anchor)
 }
 
 func (b BuildingDef) String() string {
 	s := fmt.Sprintf("{%s(%s) W=%v H=%v", b.Class, b.Category, b.Dims.X, b.Dims.Y)
+	if b.Anchor != (rl.Vector2{}) {
+		s += fmt.Sprintf(" Anchor=(%v,%v)", b.Anchor.X, b.Anchor.Y)
+	}
 	if b.BeltIn.len > 0 {
 		s += fmt.Sprintf(" BeltIn=%s", b.BeltIn)
 	}
@@ -23,6 +23,8 @@ const (
 	moveDelta = 100.
 	// Ammount to zoom by on middle mouse button drag
 	zoomPerPx = 1.0 / 100.
+	// Margin around the bounds framed by [Camera.doFit] (px)
+	fitMargin = 32.
 )
 
 var camera = Camera{}
@@ -35,6 +37,10 @@ type Camera struct {
 	Zooming bool
 	// zoom at position
 	ZoomAt rl.Vector2
+	// returns the world position to keep centered, nil when not following
+	follow func() rl.Vector2
+	// true if following has been interrupted by user pan / zoom
+	followPaused bool
 }
 
 func (c Camera) traceState(key, val string) {
@@ -48,6 +54,46 @@ func (c Camera) traceState(key, val string) {
 // Zoom returns the current zoom level
 func (c *Camera) Zoom() float32 { return c.camera.Zoom }
 
+// CameraState is the persistable part of the camera state
+type CameraState struct {
+	// Target is the world position at the center of the scene area
+	Target rl.Vector2
+	// Zoom is the zoom level (px/wu)
+	Zoom float32
+}
+
+// State returns the current camera state, see [Camera.SetState]
+func (c *Camera) State() CameraState {
+	// world position of the scene area center (the camera is never rotated)
+	target := c.camera.Target.Add(dims.Scene.Center().Subtract(c.camera.Offset).Scale(1 / c.camera.Zoom))
+	return CameraState{Target: target, Zoom: c.camera.Zoom}
+}
+
+// SetState restores a camera state, centering the target in the scene area.
+//
+// Zoom is clamped to [zoomMin, zoomMax], and reset to the default if not finite; a non-finite target is
+// reset to the origin.
+func (c *Camera) SetState(st CameraState) {
+	c.traceState("before", "SetState")
+	log.Debug("camera.SetState", "target", st.Target, "zoom", st.Zoom)
+	if !AllFinite(st.Target.X, st.Target.Y) {
+		log.Warn("invalid camera target", "target", st.Target)
+		st.Target = vec2(0, 0)
+	}
+	c.camera.Zoom = clampZoom(st.Zoom)
+	c.center(st.Target)
+	c.traceState("after", "SetState")
+}
+
+// clampZoom clamps a zoom level to [zoomMin, zoomMax], non-finite levels become [zoomDefault]
+func clampZoom(zoom float32) float32 {
+	if !AllFinite(zoom) {
+		log.Warn("invalid camera zoom", "zoom", zoom)
+		return zoomDefault
+	}
+	return min(max(zoom, zoomMin), zoomMax)
+}
+
 // WorldPos converts screen position to world position
 func (c *Camera) WorldPos(screenPos rl.Vector2) rl.Vector2 {
 	return rl.GetScreenToWorld2D(screenPos, c.camera)
@@ -58,6 +104,46 @@ func (c *Camera) ScreenPos(worldPos rl.Vector2) rl.Vector2 {
 	return rl.GetWorldToScreen2D(worldPos, c.camera)
 }
 
+// Follow makes the camera keep the position returned by get centered in the scene area.
+//
+// get is called once per frame in [Camera.Update] until [Camera.Unfollow] is called.
+// User pan / zoom pauses following, see [Camera.ResumeFollow].
+func (c *Camera) Follow(get func() rl.Vector2) {
+	log.Debug("camera.follow")
+	c.follow = get
+	c.followPaused = false
+}
+
+// Unfollow stops following the target set with [Camera.Follow]
+func (c *Camera) Unfollow() {
+	log.Debug("camera.unfollow")
+	c.follow = nil
+	c.followPaused = false
+}
+
+// ResumeFollow resumes following the target after it has been paused by user input
+func (c *Camera) ResumeFollow() {
+	log.Debug("camera.resumeFollow", "following", c.follow != nil)
+	c.followPaused = false
+}
+
+// IsFollowing returns true if the camera is actively following a target
+func (c *Camera) IsFollowing() bool { return c.follow != nil && !c.followPaused }
+
+// pauseFollow pauses following on user pan / zoom
+func (c *Camera) pauseFollow() {
+	if c.IsFollowing() {
+		log.Debug("camera.pauseFollow")
+		c.followPaused = true
+	}
+}
+
+// center sets the camera target so that the given world position is at the center of the scene area
+func (c *Camera) center(pos rl.Vector2) {
+	c.camera.Target = pos
+	c.camera.Offset = dims.Scene.Center()
+}
+
 // BeginMode2D enters raylib 2D mode
 func (c *Camera) BeginMode2D() { rl.BeginMode2D(c.camera) }
 
@@ -90,6 +176,8 @@ func (c *Camera) Update() {
 		c.doZoom(+1, dims.Scene.Center())
 	case BindingZoomReset:
 		c.doReset()
+	case BindingZoomFit:
+		c.doFitView()
 	}
 
 	// mouse inputs
@@ -114,6 +202,11 @@ func (c *Camera) Update() {
 			c.doZoom(mouse.Wheel, mouse.ScreenPos)
 		}
 	}
+
+	// follow target
+	if c.IsFollowing() {
+		c.center(c.follow())
+	}
 }
 
 // doReset resets camera state (default zoom, target (0,0) and offset middle of the scene)
@@ -127,6 +220,42 @@ func (c *Camera) doReset() Action {
 	return nil
 }
 
+// doFit sets the zoom and target so that the given world bounds are entirely visible and centered in
+// the scene area, with a [fitMargin] margin.
+//
+// Zoom is clamped to [zoomMin, zoomMax], so bounds may not fit at the minimum zoom.
+func (c *Camera) doFit(bounds rl.Rectangle) Action {
+	c.traceState("before", "doFit")
+	log.Debug("camera.doFit", "bounds", bounds)
+	c.pauseFollow()
+	w := max(dims.Scene.Width-2*fitMargin, 1)
+	h := max(dims.Scene.Height-2*fitMargin, 1)
+	zoom := float32(zoomMax)
+	if bounds.Width > 0 {
+		zoom = min(zoom, w/bounds.Width)
+	}
+	if bounds.Height > 0 {
+		zoom = min(zoom, h/bounds.Height)
+	}
+	c.camera.Zoom = clampZoom(zoom)
+	c.center(bounds.Center())
+	c.traceState("after", "doFit")
+	return nil
+}
+
+// doFitView frames the selection in selection mode, the whole scene otherwise, and resets the camera
+// if there is nothing to frame (see [Camera.doFit]).
+func (c *Camera) doFitView() Action {
+	if app.Mode == ModeSelection && !selection.IsEmpty() {
+		selection.recomputeBounds(scene.ObjectCollection)
+		return c.doFit(selection.Bounds)
+	}
+	if scene.IsEmpty() {
+		return c.doReset()
+	}
+	return c.doFit(scene.ObjectCollection.SelectAll().Bounds)
+}
+
 // doZoom zooms the camera by a given amount at a given position
 func (c *Camera) doZoom(by float32, at rl.Vector2) Action {
 	c.traceState("before", "doZoom")
@@ -135,13 +264,14 @@ func (c *Camera) doZoom(by float32, at rl.Vector2) Action {
 	} else {
 		log.Debug("camera.doZoom", "by", by, "at", at) // zooming by keyboard -> tracing
 	}
+	c.pauseFollow()
 	// Set target at world position
 	c.camera.Target = c.WorldPos(at)
 	// Set offset at screen position
 	c.camera.Offset = at
 	// Change zoom
-	newZoom := c.camera.Zoom * math32.Pow(zoomFactor, by)
-	c.camera.Zoom = min(max(newZoom, zoomMin), zoomMax)
+	newZoom := clampZoom(c.camera.Zoom) * math32.Pow(zoomFactor, by)
+	c.camera.Zoom = clampZoom(newZoom)
 	c.traceState("after", "doZoom")
 	return nil
 }
@@ -154,6 +284,7 @@ func (c *Camera) doPan(by rl.Vector2) Action {
 	} else {
 		log.Debug("camera.doPan", "by", by) // panning by keyboard -> tracing
 	}
+	c.pauseFollow()
 	// Set target at world position
 	c.camera.Offset = c.camera.Offset.Add(by)
 	c.traceState("after", "doPan")
@@ -173,6 +304,8 @@ func (c *Camera) Dispatch(action Action) Action {
 		return c.doZoom(action.By, action.At)
 	case CameraActionPan:
 		return c.doPan(action.By)
+	case CameraActionFit:
+		return c.doFit(action.Bounds)
 
 	default:
 		panic(fmt.Sprintf("Camera.Dispatch: cannot handle: %T", action))
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCameraSetState checks that restoring invalid camera states never produces invalid zoom levels
+func TestCameraSetState(t *testing.T) {
+	t.Cleanup(func() { camera = Camera{} })
+	nan := float32(math.NaN())
+	inf := float32(math.Inf(1))
+	for _, tc := range []struct {
+		st   CameraState
+		want CameraState
+	}{
+		{CameraState{Target: vec2(10, 20), Zoom: 2}, CameraState{Target: vec2(10, 20), Zoom: 2}},
+		{CameraState{Target: vec2(10, 20), Zoom: 0}, CameraState{Target: vec2(10, 20), Zoom: zoomMin}},
+		{CameraState{Target: vec2(10, 20), Zoom: -3}, CameraState{Target: vec2(10, 20), Zoom: zoomMin}},
+		{CameraState{Target: vec2(10, 20), Zoom: 1000}, CameraState{Target: vec2(10, 20), Zoom: zoomMax}},
+		{CameraState{Target: vec2(10, 20), Zoom: nan}, CameraState{Target: vec2(10, 20), Zoom: zoomDefault}},
+		{CameraState{Target: vec2(nan, 20), Zoom: inf}, CameraState{Target: vec2(0, 0), Zoom: zoomDefault}},
+	} {
+		camera.SetState(tc.st)
+		if got := camera.State(); got != tc.want {
+			t.Errorf("SetState(%v): state %v, want %v", tc.st, got, tc.want)
+		}
+	}
+
+}
@@ -0,0 +1,97 @@
+// clipboard - Copy / paste of objects through the system clipboard (text format)
+
+package app
+
+import (
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// offset of pasted objects from the copied ones, so they do not overlap exactly (world units)
+const pasteOffset = 8
+
+// selectionText returns the selected objects in text format (see [Scene.SaveToText]), only fully
+// selected paths are kept.
+func (s Scene) selectionText(sel ObjectSelection) string {
+	var sb strings.Builder
+	sw := NewSceneTextWriter(&sb)
+	sw.WriteHeader(version)
+	for _, idx := range sel.BuildingIdxs {
+		sw.WriteBuilding(s.Buildings[idx])
+	}
+	for _, idx := range sel.FullPathIdxs() {
+		sw.WritePath(s.Paths[idx])
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		sw.WriteTextBox(s.TextBoxes[idx])
+	}
+	sw.Close() // cannot fail writing to a strings.Builder
+	return sb.String()
+}
+
+// CopyToClipboard copies the selected objects to the system clipboard, in text format
+func (s Scene) CopyToClipboard(sel ObjectSelection) {
+	if sel.IsEmpty() {
+		log.Debug("scene.CopyToClipboard", "action", "skipped", "reason", "empty selection")
+		return
+	}
+	text := s.selectionText(sel)
+	rl.SetClipboardText(text)
+	log.Info("copied to clipboard", "bytes", len(text))
+}
+
+// PasteFromClipboard adds the objects of the system clipboard, offset by [pasteOffset], and returns
+// the selection of the added objects, and false if nothing was pasted.
+//
+// Clipboard text not in the scene text format is ignored, malformed content is logged.
+func (s *Scene) PasteFromClipboard() (ObjectSelection, bool) {
+	return s.pasteText(rl.GetClipboardText())
+}
+
+// pasteText is [Scene.PasteFromClipboard] with the given clipboard text
+func (s *Scene) pasteText(text string) (ObjectSelection, bool) {
+	if !strings.HasPrefix(text, tagVersion) {
+		log.Debug("scene.paste", "action", "skipped", "reason", "not a scene")
+		return ObjectSelection{}, false
+	}
+	var pasted Scene
+	warnings, err := pasted.LoadFromTextMode(strings.NewReader(text), DecodeLenient)
+	if err != nil {
+		log.Warn("cannot paste", "reason", "invalid clipboard content", "err", err)
+		return ObjectSelection{}, false
+	}
+	for _, warning := range warnings {
+		log.Warn("clipboard decode warning", "err", warning)
+	}
+	col := pasted.ObjectCollection
+	if col.IsEmpty() {
+		return ObjectSelection{}, false
+	}
+	sceneOpDelta{Translate: vec2(pasteOffset, pasteOffset)}.applyCollection(&col)
+	if !s.AddObjects(col) {
+		return ObjectSelection{}, false
+	}
+	log.Info("pasted from clipboard", "buildings", len(col.Buildings), "paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+	return s.tailSelection(col), true
+}
+
+// doCopy copies the selection to the system clipboard
+func (a *App) doCopy() Action {
+	if a.Mode == ModeSelection {
+		scene.CopyToClipboard(selection.ObjectSelection)
+	}
+	return nil
+}
+
+// doPaste pastes the system clipboard objects and selects them
+func (a *App) doPaste() Action {
+	if !a.isNormal() {
+		return nil
+	}
+	if sel, ok := scene.PasteFromClipboard(); ok {
+		return selection.doInitSelection(sel)
+	}
+	return nil
+}
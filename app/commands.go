@@ -0,0 +1,277 @@
+// commands - Named commands registry (command palette)
+
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+)
+
+// padding of the text box created by the "wrap selection in text box" command (world units)
+const wrapTextBoxPadding = 2
+
+// radius of the "grow selection" command (world units)
+const growSelectionRadius = 8
+
+// factor of the "toggle exploded view" command, see [Scene.Explode]
+const explodeFactor = 2
+
+// commands associates command names to their handler, see [RegisterCommand]
+var commands = map[string]func() Action{}
+
+// RegisterCommand registers a named command, replacing any command with the same name.
+//
+// fn performs the command and returns a follow up [Action] to be performed (or nil).
+// Names are case insensitive.
+func RegisterCommand(name string, fn func() Action) {
+	commands[strings.ToLower(name)] = fn
+}
+
+// RunCommand runs the named command and returns its follow up [Action].
+//
+// Unknown commands return an error.
+func RunCommand(name string) (Action, error) {
+	fn, ok := commands[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown command: %q", name)
+	}
+	log.Info("runCommand", "name", name)
+	return fn(), nil
+}
+
+// CommandNames returns the registered command names, sorted alphabetically
+func CommandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// modifySelection returns a command handler modifying the selected objects in place with modify,
+// then updating the selection bounds. It does nothing outside of selection mode.
+func modifySelection(modify func(sel ObjectSelection)) func() Action {
+	return func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		modify(selection.ObjectSelection)
+		selection.recomputeBounds(scene.ObjectCollection)
+		return nil
+	}
+}
+
+// default commands
+func init() {
+	RegisterCommand("new project", func() Action { return app.doNew() })
+	RegisterCommand("open project", func() Action { return app.doOpen() })
+	RegisterCommand("save project as", func() Action { return app.doSaveAs() })
+	RegisterCommand("export selection as blueprint", func() Action { return app.doExportBlueprint() })
+	RegisterCommand("insert blueprint", func() Action { return app.doInsertBlueprint() })
+	RegisterCommand("stamp blueprint", func() Action { return app.doStampBlueprint() })
+	RegisterCommand("open reference project", func() Action { return app.doOpenReference() })
+	RegisterCommand("clear reference project", func() Action {
+		ClearReferenceScene()
+		return nil
+	})
+	RegisterCommand("undo", func() Action { return app.doUndo() })
+	RegisterCommand("redo", func() Action { return app.doRedo() })
+	RegisterCommand("delete", func() Action { return app.doDelete() })
+	RegisterCommand("copy", func() Action { return app.doCopy() })
+	RegisterCommand("paste", func() Action { return app.doPaste() })
+	RegisterCommand("rotate", func() Action { return app.doRotate() })
+	RegisterCommand("duplicate", func() Action { return app.doDuplicate() })
+	RegisterCommand("drag", func() Action { return app.doDrag() })
+	RegisterCommand("reset camera", func() Action { return camera.doReset() })
+	RegisterCommand("toggle hud", func() Action {
+		gui.HUD.Visible = !gui.HUD.Visible
+		return nil
+	})
+	RegisterCommand("toggle class summary", func() Action {
+		gui.Summary.Visible = !gui.Summary.Visible
+		return nil
+	})
+	RegisterCommand("measure distance", func() Action {
+		ruler.doStart()
+		return nil
+	})
+	RegisterCommand("fit camera to scene", func() Action {
+		if scene.IsEmpty() {
+			return camera.doReset()
+		}
+		return camera.doFit(scene.ObjectCollection.SelectAll().Bounds)
+	})
+	RegisterCommand("fit camera to selection", func() Action {
+		if app.Mode != ModeSelection || selection.IsEmpty() {
+			return nil
+		}
+		selection.recomputeBounds(scene.ObjectCollection)
+		return camera.doFit(selection.Bounds)
+	})
+	RegisterCommand("select all", func() Action { return selection.doSelectAll() })
+	RegisterCommand("select invalid objects", func() Action {
+		sel := scene.SelectIssues(scene.Validate(), IssueOverlap, IssueShortPath)
+		if sel.IsEmpty() {
+			log.Info("no invalid objects")
+		}
+		return selection.doInitSelection(sel)
+	})
+	RegisterCommand("invert selection", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return selection.doInitSelection(scene.InvertSelection(selection.ObjectSelection))
+	})
+	for name, t := range map[string]ObjectType{"buildings": TypeBuilding, "paths": TypePath, "text boxes": TypeTextBox} {
+		RegisterCommand("select all "+name, func() Action { return selection.doInitSelection(scene.SelectAllOfType(t)) })
+	}
+	RegisterCommand("hide selection", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		scene.Hide(selection.ObjectSelection)
+		return app.doSwitchMode(ModeNormal, ResetAll())
+	})
+	RegisterCommand("optimize memory", func() Action {
+		scene.Optimize()
+		return nil
+	})
+	RegisterCommand("show all", func() Action {
+		scene.ShowAll()
+		return nil
+	})
+	RegisterCommand("wrap selection in text box", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return scene.WrapSelectionInTextBox(selection.ObjectSelection, textBoxDefaultText, wrapTextBoxPadding)
+	})
+	RegisterCommand("set reference origin to selection", func() Action {
+		if app.Mode == ModeSelection {
+			scene.SetReferenceOrigin(selection.Bounds.Center())
+		}
+		return nil
+	})
+	RegisterCommand("clear reference origin", func() Action {
+		scene.ClearReferenceOrigin()
+		return nil
+	})
+	RegisterCommand("grow selection", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return selection.doInitSelection(scene.GrowSelection(selection.ObjectSelection, growSelectionRadius))
+	})
+	for name, axis := range map[string]Axis{"horizontal": AxisX, "vertical": AxisY} {
+		RegisterCommand("equalize "+name+" gaps", modifySelection(func(sel ObjectSelection) {
+			scene.EqualizeGaps(sel, axis, scene.MeanGap(sel, axis))
+		}))
+	}
+	for name, turns := range map[string]int{"clockwise": 1, "counterclockwise": -1} {
+		RegisterCommand("rotate selection "+name, modifySelection(func(sel ObjectSelection) {
+			scene.RotateSelection(sel, turns)
+		}))
+	}
+	RegisterCommand("merge collinear paths", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		if sel, ok := scene.MergeCollinearPaths(selection.ObjectSelection); ok {
+			return selection.doInitSelection(sel)
+		}
+		return nil
+	})
+	RegisterCommand("reverse selected paths", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return selection.doInitSelection(scene.ReversePaths(selection.ObjectSelection))
+	})
+	RegisterCommand("rotate selected buildings in place", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return selection.doRotateInPlace()
+	})
+	for name, flip := range map[string]Axis{"vertical": AxisX, "horizontal": AxisY} {
+		RegisterCommand("mirror selection across "+name+" axis", modifySelection(func(sel ObjectSelection) {
+			scene.Mirror(sel, flip)
+		}))
+	}
+	for name, align := range map[string]Alignment{
+		"left": AlignLeft, "right": AlignRight, "top": AlignTop, "bottom": AlignBottom,
+		"horizontal centers": AlignCenterX, "vertical centers": AlignCenterY,
+	} {
+		RegisterCommand("align buildings "+name, modifySelection(func(sel ObjectSelection) {
+			scene.AlignBuildings(sel, align)
+		}))
+	}
+	for name, layer := range map[string]Layer{"paths": LayerPaths, "buildings": LayerBuildings, "text boxes": LayerTextBoxes} {
+		RegisterCommand("toggle "+name+" layer", func() Action {
+			scene.SetLayerVisible(layer, !scene.IsLayerVisible(layer))
+			return nil
+		})
+	}
+	RegisterCommand("snap selected paths to grid", func() Action {
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return scene.SnapPathsToGrid(selection.ObjectSelection)
+	})
+	RegisterCommand("toggle exploded view", func() Action {
+		if scene.IsExploded() {
+			scene.Unexplode()
+		} else {
+			scene.Explode(dims.World.Center(), explodeFactor)
+		}
+		return nil
+	})
+	RegisterCommand("toggle path gradient", func() Action {
+		pathGradient.Enabled = !pathGradient.Enabled
+		return nil
+	})
+	RegisterCommand("toggle overlap highlighting", func() Action {
+		highlightOverlaps = !highlightOverlaps
+		return nil
+	})
+	RegisterCommand("toggle sticky connections", func() Action {
+		stickyConnections = !stickyConnections
+		log.Info("sticky connections", "enabled", stickyConnections)
+		return nil
+	})
+	RegisterCommand("select duplicate objects", func() Action {
+		sel := scene.FindDuplicates()
+		if sel.IsEmpty() {
+			log.Info("no duplicate objects")
+		}
+		return selection.doInitSelection(sel)
+	})
+	RegisterCommand("remove duplicate objects", func() Action {
+		if scene.Compact() > 0 {
+			return app.doSwitchMode(ModeNormal, ResetAll())
+		}
+		return nil
+	})
+	RegisterCommand("canonicalize objects order", func() Action {
+		sel := scene.Canonicalize(selection.ObjectSelection)
+		if app.Mode != ModeSelection {
+			return nil
+		}
+		return selection.doInitSelection(sel)
+	})
+	RegisterCommand("snap selection to grid", modifySelection(scene.SnapSelectionToGrid))
+	RegisterCommand("toggle grid snapping", func() Action {
+		grid.ToggleSnap()
+		return nil
+	})
+	for _, step := range []float32{0.5, 1, 2, 4, 8} {
+		RegisterCommand(fmt.Sprintf("set snap step to %v", step), func() Action {
+			grid.SetSnapStep(step)
+			return nil
+		})
+	}
+}
@@ -17,6 +17,7 @@ const (
 	DrawInvalid  DrawState = 3
 	DrawShadow   DrawState = 4
 	DrawSkip     DrawState = 5
+	DrawFaded    DrawState = 6
 
 	// Modifiers
 
@@ -31,6 +32,9 @@ const (
 
 var shadowColor = colors.WithAlpha(colors.Gray700, 0.25)
 
+// alpha of [DrawFaded] objects colors
+const fadedAlpha = 0.3
+
 // transformColor returns a color modified according to the draw state
 func (state DrawState) transformColor(color rl.Color) rl.Color {
 	// State
@@ -44,6 +48,8 @@ func (state DrawState) transformColor(color rl.Color) rl.Color {
 		color = colors.Lerp(color, colors.Red500, 0.5)
 	case DrawShadow:
 		color = shadowColor
+	case DrawFaded:
+		color = colors.WithAlpha(color, fadedAlpha)
 	case DrawSkip:
 		return colors.Blank // FIXME: should panic ?
 	default:
@@ -58,3 +64,125 @@ func (state DrawState) transformColor(color rl.Color) rl.Color {
 	}
 	return color
 }
+
+////////////////////////////////////////////////////////////////////////////////////////////////////
+// Per object draw states
+////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// drawStates caches the scene objects draw states used by [Scene.drawWithSel]
+var drawStates drawStateCache
+
+// drawStateCache holds the draw state of every scene object (indexed as the scene objects), they are
+// rebuilt only when the app mode, the selection or the number of objects change.
+type drawStateCache struct {
+	// key of the inputs the states were computed from
+	key drawStateKey
+
+	buildings  []DrawState
+	pathStarts []DrawState
+	pathEnds   []DrawState
+	pathBodies []DrawState
+	textBoxes  []DrawState
+	// whether paths are drawn as a whole with [drawStateCache.pathBodies] state (in drag mode)
+	wholePaths bool
+}
+
+// drawStateKey represents the inputs the draw states depend on
+type drawStateKey struct {
+	mode                    AppMode
+	selectionMode           SelectionMode
+	buildings, paths, texts int
+	selHash                 uint64
+}
+
+// hashSelection returns a FNV-1a hash of the selection indices
+func hashSelection(sel ObjectSelection) uint64 {
+	const prime = 1099511628211
+	h := uint64(14695981039346656037)
+	mix := func(v int) { h = (h ^ uint64(v)) * prime }
+	for _, idx := range sel.BuildingIdxs {
+		mix(idx)
+	}
+	mix(-1)
+	for _, elt := range sel.PathIdxs {
+		v := elt.Idx << 2
+		if elt.Start {
+			v |= 1
+		}
+		if elt.End {
+			v |= 2
+		}
+		mix(v)
+	}
+	mix(-1)
+	for _, idx := range sel.TextBoxIdxs {
+		mix(idx)
+	}
+	return h
+}
+
+// update recomputes the draw states of the scene s objects if needed
+func (c *drawStateCache) update(s Scene) {
+	var sel ObjectSelection
+	var state DrawState
+	if app.Mode == ModeSelection {
+		sel = selection.ObjectSelection
+		switch selection.mode {
+		case SelectionNormal, SelectionSingleTextBox:
+			state = DrawSkip
+		case SelectionDrag, SelectionTextBoxResize:
+			state = DrawShadow
+		case SelectionDuplicate:
+			state = DrawClicked
+		}
+	} else {
+		sel = selector.ObjectSelection
+		state = DrawSkip
+	}
+
+	key := drawStateKey{
+		mode:      app.Mode,
+		buildings: len(s.Buildings),
+		paths:     len(s.Paths),
+		texts:     len(s.TextBoxes),
+		selHash:   hashSelection(sel),
+	}
+	if app.Mode == ModeSelection {
+		key.selectionMode = selection.mode
+	}
+	if key == c.key {
+		return
+	}
+	c.key = key
+
+	c.buildings = Repeat(c.buildings, DrawNormal, len(s.Buildings))
+	for _, idx := range sel.BuildingIdxs {
+		c.buildings[idx] = state
+	}
+
+	c.wholePaths = app.Mode == ModeSelection && selection.mode == SelectionDrag
+	c.pathStarts = Repeat(c.pathStarts, DrawNormal, len(s.Paths))
+	c.pathEnds = Repeat(c.pathEnds, DrawNormal, len(s.Paths))
+	c.pathBodies = Repeat(c.pathBodies, DrawNormal, len(s.Paths))
+	for _, elt := range sel.PathIdxs {
+		if c.wholePaths {
+			// in drag mode, the whole path is drawn as shadow
+			c.pathBodies[elt.Idx] = state
+			continue
+		}
+		if elt.Start {
+			c.pathStarts[elt.Idx] = state
+		}
+		if elt.End {
+			c.pathEnds[elt.Idx] = state
+		}
+		if elt.Start && elt.End {
+			c.pathBodies[elt.Idx] = state
+		}
+	}
+
+	c.textBoxes = Repeat(c.textBoxes, DrawNormal, len(s.TextBoxes))
+	for _, idx := range sel.TextBoxIdxs {
+		c.textBoxes[idx] = state
+	}
+}
@@ -0,0 +1,109 @@
+// duplicates - Detection and removal of identical objects (file cleanup)
+
+package app
+
+import (
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// duplicateGroups returns the groups (of 2 or more) of indices of the objects with the same key, in
+// increasing order, groups sorted by their first index
+func duplicateGroups[T any, K comparable](objs []T, key func(T) K) [][]int {
+	groupOf := map[K]int{}
+	var groups [][]int
+	for i, obj := range objs {
+		k := key(obj)
+		if g, ok := groupOf[k]; ok {
+			groups[g] = append(groups[g], i)
+		} else {
+			groupOf[k] = len(groups)
+			groups = append(groups, []int{i})
+		}
+	}
+	dups := groups[:0]
+	for _, g := range groups {
+		if len(g) > 1 {
+			dups = append(dups, g)
+		}
+	}
+	return dups
+}
+
+// buildingKey is the identity of a building for [Scene.FindDuplicateBuildings]
+type buildingKey struct {
+	defIdx int
+	pos    rl.Vector2
+	rot    int32
+}
+
+// FindDuplicateBuildings returns the groups of indices of the buildings with the same definition,
+// position and rotation, see [Scene.FindDuplicates]
+func (s Scene) FindDuplicateBuildings() [][]int {
+	return duplicateGroups(s.Buildings, func(b Building) buildingKey { return buildingKey{b.DefIdx, b.Pos, b.Rot} })
+}
+
+// pathKey is the identity of a path for [Scene.FindDuplicatePaths]
+type pathKey struct {
+	defIdx     int
+	start, end rl.Vector2
+}
+
+// FindDuplicatePaths returns the groups of indices of the paths with the same definition and ends,
+// see [Scene.FindDuplicates]
+func (s Scene) FindDuplicatePaths() [][]int {
+	return duplicateGroups(s.Paths, func(p Path) pathKey { return pathKey{p.DefIdx, p.Start, p.End} })
+}
+
+// textBoxKey is the identity of a text box for [Scene.FindDuplicateTextBoxes]
+type textBoxKey struct {
+	bounds  rl.Rectangle
+	content string
+}
+
+// FindDuplicateTextBoxes returns the groups of indices of the text boxes with the same bounds and
+// content (compared exactly), see [Scene.FindDuplicates]
+func (s Scene) FindDuplicateTextBoxes() [][]int {
+	return duplicateGroups(s.TextBoxes, func(tb TextBox) textBoxKey { return textBoxKey{tb.Bounds, tb.Content} })
+}
+
+// FindDuplicates returns the selection of the redundant copies of the identical objects: all the
+// objects of each group but the first one, hidden objects included.
+//
+// See [Scene.FindDuplicateBuildings], [Scene.FindDuplicatePaths] and [Scene.FindDuplicateTextBoxes].
+func (s Scene) FindDuplicates() ObjectSelection {
+	var sel ObjectSelection
+	for _, g := range s.FindDuplicateBuildings() {
+		sel.BuildingIdxs = append(sel.BuildingIdxs, g[1:]...)
+	}
+	for _, g := range s.FindDuplicatePaths() {
+		for _, idx := range g[1:] {
+			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+		}
+	}
+	for _, g := range s.FindDuplicateTextBoxes() {
+		sel.TextBoxIdxs = append(sel.TextBoxIdxs, g[1:]...)
+	}
+	slices.Sort(sel.BuildingIdxs)
+	slices.SortFunc(sel.PathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	slices.Sort(sel.TextBoxIdxs)
+	sel.recomputeBounds(s.ObjectCollection)
+	log.Debug("scene.FindDuplicates", "buildings", len(sel.BuildingIdxs), "paths", len(sel.PathIdxs), "textBoxes", len(sel.TextBoxIdxs))
+	return sel
+}
+
+// Compact deletes the duplicate objects (see [Scene.FindDuplicates]) as a single operation, and
+// returns the number of deleted objects.
+func (s *Scene) Compact() int {
+	sel := s.FindDuplicates()
+	if sel.IsEmpty() {
+		log.Debug("scene.Compact", "action", "skipped", "reason", "no duplicate")
+		return 0
+	}
+	n := len(sel.BuildingIdxs) + len(sel.PathIdxs) + len(sel.TextBoxIdxs)
+	log.Info("compact scene", "buildings", len(sel.BuildingIdxs), "paths", len(sel.PathIdxs), "textBoxes", len(sel.TextBoxIdxs))
+	s.DeleteObjects(sel)
+	return n
+}
@@ -0,0 +1,113 @@
+// dxf - DXF (R12 ASCII) export of the scene, for CAD tools
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// DXF layers of the exported objects
+const (
+	dxfLayerBuildings = "BUILDINGS"
+	dxfLayerPaths     = "PATHS"
+	dxfLayerTextBoxes = "TEXTBOXES"
+)
+
+// height of the exported text boxes text lines (world units)
+const dxfTextHeight = 2
+
+// dxfWriter writes DXF group code / value pairs, keeping the first error
+type dxfWriter struct {
+	w   *bufio.Writer
+	err error
+}
+
+// pair writes a group code and its value
+func (dw *dxfWriter) pair(code int, value string) {
+	if dw.err == nil {
+		_, dw.err = fmt.Fprintf(dw.w, "%d\n%s\n", code, value)
+	}
+}
+
+// point writes the coordinates of v with the group codes of its X coordinate (x), Y (x+10) and Z (x+20)
+//
+// The Y axis is flipped: world Y points down, DXF Y points up.
+func (dw *dxfWriter) point(x int, v rl.Vector2) {
+	dw.pair(x, formatDXFFloat(v.X))
+	dw.pair(x+10, formatDXFFloat(-v.Y))
+	dw.pair(x+20, "0")
+}
+
+// formatDXFFloat formats a coordinate in the shortest exact decimal representation
+func formatDXFFloat(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) }
+
+// ExportDXF writes the scene as a minimal DXF R12 ASCII drawing, one world unit per drawing unit
+// (with the Y axis pointing up).
+//
+// Buildings are closed polylines of their rotated footprint (BUILDINGS layer), paths are lines
+// between their ends (PATHS layer), text boxes are one text entity per content line from their top
+// left corner (TEXTBOXES layer). Hidden objects are exported.
+//
+// Errors originate from the underlying [io.Writer].
+func (s Scene) ExportDXF(w io.Writer) error {
+	dw := &dxfWriter{w: bufio.NewWriter(w)}
+	dw.pair(0, "SECTION")
+	dw.pair(2, "HEADER")
+	dw.pair(9, "$ACADVER")
+	dw.pair(1, "AC1009")
+	dw.pair(0, "ENDSEC")
+	dw.pair(0, "SECTION")
+	dw.pair(2, "ENTITIES")
+
+	for _, b := range s.Buildings {
+		dims := b.Def().Dims
+		mat := b.matrix()
+		dw.pair(0, "POLYLINE")
+		dw.pair(8, dxfLayerBuildings)
+		dw.pair(66, "1") // vertices follow
+		dw.pair(70, "1") // closed
+		dw.point(10, rl.Vector2{})
+		for _, corner := range [4]rl.Vector2{mat.Apply(0, 0), mat.Apply(dims.X, 0), mat.Apply(dims.X, dims.Y), mat.Apply(0, dims.Y)} {
+			dw.pair(0, "VERTEX")
+			dw.pair(8, dxfLayerBuildings)
+			dw.point(10, corner)
+		}
+		dw.pair(0, "SEQEND")
+		dw.pair(8, dxfLayerBuildings)
+	}
+	for _, p := range s.Paths {
+		dw.pair(0, "LINE")
+		dw.pair(8, dxfLayerPaths)
+		dw.point(10, p.Start)
+		dw.point(11, p.End)
+	}
+	for _, tb := range s.TextBoxes {
+		for i, line := range strings.Split(tb.Content, "\n") {
+			if line == "" {
+				continue
+			}
+			// text entities are positioned by their baseline start
+			pos := tb.Bounds.TopLeft().Add(vec2(0, float32(i+1)*dxfTextHeight))
+			dw.pair(0, "TEXT")
+			dw.pair(8, dxfLayerTextBoxes)
+			dw.point(10, pos)
+			dw.pair(40, formatDXFFloat(dxfTextHeight))
+			dw.pair(1, line)
+		}
+	}
+
+	dw.pair(0, "ENDSEC")
+	dw.pair(0, "EOF")
+	if dw.err == nil {
+		dw.err = dw.w.Flush()
+	}
+	log.Info("scene.ExportDXF", "buildings", len(s.Buildings), "paths", len(s.Paths), "textBoxes", len(s.TextBoxes), "err", dw.err)
+	return dw.err
+}
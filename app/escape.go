@@ -0,0 +1,65 @@
+// escape - Escape key handling
+
+package app
+
+import "github.com/bonoboris/satisfied/log"
+
+// EscapeContext identifies what the Escape key applies to, see [SetEscapeHandler]
+type EscapeContext int
+
+const (
+	// Objects are selected, no transformation is occurring
+	EscapeSelection EscapeContext = iota
+	// The selection is being transformed (drag, duplicate or text box resize)
+	EscapeTransformation
+	// A new object is being placed (path, building or text box)
+	EscapePlacement
+)
+
+func (c EscapeContext) String() string {
+	switch c {
+	case EscapeSelection:
+		return "EscapeSelection"
+	case EscapeTransformation:
+		return "EscapeTransformation"
+	case EscapePlacement:
+		return "EscapePlacement"
+	default:
+		return "Invalid"
+	}
+}
+
+// escapeHandlers holds the handlers overriding the default ones, see [SetEscapeHandler]
+var escapeHandlers = map[EscapeContext]func() Action{}
+
+// SetEscapeHandler overrides what the Escape key does in the given context, nil restores the default.
+//
+// fn returns a follow up [Action] to be performed (or nil). Defaults are:
+//   - [EscapeSelection]: deselect and switch to [ModeNormal], see [Selection.doDeselect]
+//   - [EscapeTransformation]: cancel the transformation, see [Selection.doCancelTransformation]
+//   - [EscapePlacement]: cancel the placement, see [App.doCancelPlacement]
+func SetEscapeHandler(ctx EscapeContext, fn func() Action) {
+	if fn == nil {
+		delete(escapeHandlers, ctx)
+	} else {
+		escapeHandlers[ctx] = fn
+	}
+}
+
+// doEscape performs the Escape key handler of the given context
+func doEscape(ctx EscapeContext) Action {
+	log.Debug("escape", "context", ctx)
+	if fn, ok := escapeHandlers[ctx]; ok {
+		return fn()
+	}
+	switch ctx {
+	case EscapeSelection:
+		return selection.doDeselect()
+	case EscapeTransformation:
+		return selection.doCancelTransformation()
+	case EscapePlacement:
+		return app.doCancelPlacement()
+	default:
+		panic("invalid escape context")
+	}
+}
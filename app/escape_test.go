@@ -0,0 +1,66 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+// TestEscapeDefaults checks the default Escape transitions of each context
+func TestEscapeDefaults(t *testing.T) {
+	setupDragScene(t)
+	before := slices.Clone(scene.Buildings)
+	historyPos := scene.historyPos
+
+	// transformation: restores the pre-drag state, the selection is kept
+	start := selection.Bounds.Center()
+	selection.doBeginTransformation(SelectionDrag, start, false)
+	selection.doMoveTo(start.Add(vec2(30, 0)))
+	doEscape(EscapeTransformation)
+	if !slices.Equal(scene.Buildings, before) || scene.historyPos != historyPos {
+		t.Errorf("cancelled drag: got %v at history %d, want %v at history %d", scene.Buildings, scene.historyPos, before, historyPos)
+	}
+	if app.Mode != ModeSelection || selection.mode != SelectionNormal || !slices.Equal(selection.BuildingIdxs, []int{0}) {
+		t.Errorf("cancelled drag: got mode %v / %v with %v selected, want the building still selected", app.Mode, selection.mode, selection.ObjectSelection)
+	}
+
+	// selection: deselects
+	doEscape(EscapeSelection)
+	if app.Mode != ModeNormal || !selection.IsEmpty() {
+		t.Errorf("escape selection: got mode %v with %v selected, want normal mode and nothing selected", app.Mode, selection.ObjectSelection)
+	}
+
+	// placement: cancels the placed first end, then the placement
+	t.Cleanup(newPath.Reset)
+	newPath.doInit(pathDefs.Index("Belt"))
+	newPath.doMoveTo(vec2(100, 100))
+	newPath.doPlaceStart()
+	doEscape(EscapePlacement)
+	if app.Mode != ModeNewPath || newPath.firstEndPlaced {
+		t.Errorf("escape placed start: got mode %v with start placed %v, want path placement without start", app.Mode, newPath.firstEndPlaced)
+	}
+	doEscape(EscapePlacement)
+	if app.Mode != ModeNormal || !slices.Equal(scene.Buildings, before) || len(scene.Paths) != 0 {
+		t.Errorf("escape placement: got mode %v, want normal mode and the scene untouched", app.Mode)
+	}
+}
+
+// TestSetEscapeHandler checks that handlers override the defaults until reset
+func TestSetEscapeHandler(t *testing.T) {
+	setupDragScene(t)
+	t.Cleanup(func() { SetEscapeHandler(EscapeSelection, nil) })
+	calls := 0
+	SetEscapeHandler(EscapeSelection, func() Action {
+		calls++
+		return nil
+	})
+	doEscape(EscapeSelection)
+	if calls != 1 || app.Mode != ModeSelection || selection.IsEmpty() {
+		t.Errorf("overridden escape: got %d calls and mode %v, want 1 call and the selection kept", calls, app.Mode)
+	}
+
+	SetEscapeHandler(EscapeSelection, nil)
+	doEscape(EscapeSelection)
+	if calls != 1 || app.Mode != ModeNormal {
+		t.Errorf("restored escape: got %d calls and mode %v, want the default deselection", calls, app.Mode)
+	}
+}
@@ -0,0 +1,60 @@
+// explode - Display only radial separation of overlapping objects (inspection aid)
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// explodeView is the view transform set by [Scene.Explode]
+type explodeView struct {
+	center rl.Vector2
+	factor float32
+}
+
+// Explode pushes the objects radially away from center when drawn: every object is translated so
+// that its center distance to center is multiplied by factor (greater than 1 to separate them).
+//
+// It is a display only transform until [Scene.Unexplode]: objects, history and saves are unchanged,
+// hit-testing and selection use the actual positions.
+func (s *Scene) Explode(center rl.Vector2, factor float32) {
+	if factor <= 0 || !AllFinite(center.X, center.Y, factor) {
+		log.Warn("cannot explode view", "reason", "invalid parameters", "center", center, "factor", factor)
+		return
+	}
+	s.explode = &explodeView{center: center, factor: factor}
+	log.Debug("scene.Explode", "center", center, "factor", factor)
+}
+
+// Unexplode restores the objects drawing to their actual positions, see [Scene.Explode]
+func (s *Scene) Unexplode() {
+	s.explode = nil
+	log.Debug("scene.Unexplode")
+}
+
+// IsExploded returns true if the objects are drawn exploded, see [Scene.Explode]
+func (s Scene) IsExploded() bool { return s.explode != nil }
+
+// explodedObjects returns a copy of the scene objects translated by the [Scene.Explode] transform
+func (s Scene) explodedObjects() ObjectCollection {
+	col := s.ObjectCollection.clone()
+	offset := func(objCenter rl.Vector2) rl.Vector2 {
+		return objCenter.Subtract(s.explode.center).Scale(s.explode.factor - 1)
+	}
+	for i := range col.Buildings {
+		b := &col.Buildings[i]
+		b.Pos = b.Pos.Add(offset(b.Bounds().Center()))
+	}
+	for i := range col.Paths {
+		p := &col.Paths[i]
+		d := offset(p.Start.Lerp(p.End, 0.5))
+		p.Start, p.End = p.Start.Add(d), p.End.Add(d)
+	}
+	for i := range col.TextBoxes {
+		tb := &col.TextBoxes[i]
+		d := offset(tb.Bounds.Center())
+		tb.Bounds.X, tb.Bounds.Y = tb.Bounds.X+d.X, tb.Bounds.Y+d.Y
+	}
+	return col
+}
@@ -0,0 +1,68 @@
+// export - Export of some object categories (layers) only, eg. a layout without annotations
+
+package app
+
+import (
+	"io"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// withLayers returns the objects of oc in the given layers, objects of other layers are skipped
+//
+// The returned collection shares its slices with oc.
+func (oc ObjectCollection) withLayers(layers Layer) ObjectCollection {
+	var col ObjectCollection
+	if layers&LayerBuildings != 0 {
+		col.Buildings = oc.Buildings
+	}
+	if layers&LayerPaths != 0 {
+		col.Paths = oc.Paths
+	}
+	if layers&LayerTextBoxes != 0 {
+		col.TextBoxes = oc.TextBoxes
+	}
+	return col
+}
+
+// ExportLayersText saves the objects of the given layers into text format (see [Scene.SaveToText]),
+// objects of other layers are skipped.
+//
+// It does not depend on the layers visibility (see [Scene.SetLayerVisible]), hidden objects are kept.
+func (s Scene) ExportLayersText(w io.Writer, layers Layer) error {
+	export := Scene{ObjectCollection: s.withLayers(layers), metadata: s.metadata}
+	log.Info("export layers", "format", "text", "layers", int(layers))
+	return export.SaveToText(w)
+}
+
+// ExportLayersPNG renders the objects of the given layers into a PNG encoded image of size pixels
+// wide and high, and returns nil if there is nothing to render or the window is not ready.
+//
+// Objects of other layers and hidden objects are skipped, regardless of the layers visibility
+// (see [Scene.SetLayerVisible]).
+func (s Scene) ExportLayersPNG(layers Layer, size int32) []byte {
+	var col ObjectCollection
+	all := s.withLayers(layers)
+	for _, b := range all.Buildings {
+		if !b.Hidden {
+			col.Buildings = append(col.Buildings, b)
+		}
+	}
+	for _, p := range all.Paths {
+		if !p.Hidden {
+			col.Paths = append(col.Paths, p)
+		}
+	}
+	for _, tb := range all.TextBoxes {
+		if !tb.Hidden {
+			col.TextBoxes = append(col.TextBoxes, tb)
+		}
+	}
+	if col.IsEmpty() || !rl.IsWindowReady() {
+		log.Warn("cannot export layers", "reason", "nothing to render", "layers", int(layers))
+		return nil
+	}
+	log.Info("export layers", "format", "png", "layers", int(layers), "size", size)
+	return renderOffscreenPNG(GrowRect(col.SelectAll().Bounds, 8), size, func() { col.draw(DrawNormal) })
+}
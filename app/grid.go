@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
 	"github.com/bonoboris/satisfied/math32"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -13,14 +14,37 @@ var grid = Grid{SnapStep: 1}
 type Grid struct {
 	// SnapStep is the snap step in world units, 0 to disable
 	SnapStep float32
+	// SnapOff disables snapping while keeping SnapStep (see [Grid.ToggleSnap])
+	SnapOff bool
+	// SnapCenters snaps selections by their bounds center rather than their top-left corner (see
+	// [Scene.SnapSelectionToGrid])
+	SnapCenters bool
 }
 
 // Snap returns the vector snapped to the grid
 func (g Grid) Snap(v rl.Vector2) rl.Vector2 {
-	if g.SnapStep == 0 {
+	if g.SnapStep == 0 || g.SnapOff {
 		return v
 	}
-	return vec2(g.SnapStep*math32.Round(v.X)/g.SnapStep, g.SnapStep*math32.Round(v.Y)/g.SnapStep)
+	return vec2(g.SnapStep*math32.Round(v.X/g.SnapStep), g.SnapStep*math32.Round(v.Y/g.SnapStep))
+}
+
+// SetSnapStep sets the snap step in world units, 0 to disable, and returns false if step is
+// negative or not finite.
+func (g *Grid) SetSnapStep(step float32) bool {
+	if step < 0 || !AllFinite(step) {
+		log.Warn("cannot set snap step", "reason", "invalid step", "step", step)
+		return false
+	}
+	g.SnapStep = step
+	log.Debug("grid.SetSnapStep", "step", step)
+	return true
+}
+
+// ToggleSnap enables or disables snapping, keeping the snap step
+func (g *Grid) ToggleSnap() {
+	g.SnapOff = !g.SnapOff
+	log.Info("grid snapping", "enabled", !g.SnapOff, "step", g.SnapStep)
 }
 
 // Draw grid
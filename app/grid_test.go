@@ -0,0 +1,33 @@
+package app
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// TestGridSnap checks snapping to the grid step, and that disabled snapping leaves positions unchanged
+func TestGridSnap(t *testing.T) {
+	tests := []struct {
+		grid Grid
+		in   rl.Vector2
+		want rl.Vector2
+	}{
+		{Grid{SnapStep: 1}, vec2(1.4, -2.6), vec2(1, -3)},
+		{Grid{SnapStep: 4}, vec2(5.9, 6.1), vec2(4, 8)},
+		{Grid{SnapStep: 0.5}, vec2(1.3, 1.2), vec2(1.5, 1)},
+		{Grid{SnapStep: 4, SnapOff: true}, vec2(5.9, 6.1), vec2(5.9, 6.1)},
+		{Grid{}, vec2(5.9, 6.1), vec2(5.9, 6.1)},
+	}
+	for _, tt := range tests {
+		if got := tt.grid.Snap(tt.in); got != tt.want {
+			t.Errorf("%+v.Snap(%v): got %v, want %v", tt.grid, tt.in, got, tt.want)
+		}
+	}
+
+	// both snapped ends of a short path may coincide
+	g := Grid{SnapStep: 4}
+	if p := (Path{Start: g.Snap(vec2(1, 1)), End: g.Snap(vec2(1.5, 0.5))}); scene.IsPathValid(p) {
+		t.Errorf("IsPathValid(%v): got true for a zero-length snapped path", p)
+	}
+}
@@ -31,6 +31,8 @@ type Gui struct {
 	Sidebar    guiSidebar
 	Detailsbar guiDetailsbar
 	Statusbar  guiStatusbar
+	HUD        guiHUD
+	Summary    guiSummary
 }
 
 // Precompute and store some static data
@@ -44,6 +46,8 @@ func (gui *Gui) Init() {
 func (g *Gui) UpdateAndDraw() (action Action) {
 	// At most a single non nil action by frame should be returned from updateAndDraw calls
 	// we cannot press 2 buttons at the same time
+	g.HUD.draw()
+	g.Summary.draw()
 	action = orAction(action, g.Statusbar.updateAndDraw())
 	action = orAction(action, g.Detailsbar.updateAndDraw())
 	action = orAction(action, g.Sidebar.updateAndDraw())
@@ -57,7 +61,9 @@ func (g *Gui) Reset() {
 }
 
 // Whether the gui should captures key presses
-func (g *Gui) CapturesKeyPress() bool { return g.Detailsbar.textarea.Focused() }
+func (g *Gui) CapturesKeyPress() bool {
+	return g.Detailsbar.textarea.Focused() || g.Detailsbar.position.focused()
+}
 
 func (g *Gui) traceState() {
 	log.Trace("gui.sidebar", "activePath", g.Sidebar.activePath, "activeCategory", g.Sidebar.activeCategory, "activeBuilding", g.Sidebar.activeBuilding)
@@ -125,7 +131,7 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 		raygui.Disable()
 	}
 	bounds.X += 20
-	raygui.SetTooltip("Undo (Ctrl+Z)")
+	raygui.SetTooltip(historyTooltip("Undo", "Ctrl+Z", scene.PeekUndo))
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_UNDO, "")) {
 		log.Debug("topbar undo clicked")
 		action = app.doUndo()
@@ -136,7 +142,7 @@ func (tb *guiTopbar) updateAndDraw() (action Action) {
 		raygui.Disable()
 	}
 	bounds.X += 50
-	raygui.SetTooltip("Redo (Ctrl+Y / Ctrl+Shift+Z)")
+	raygui.SetTooltip(historyTooltip("Redo", "Ctrl+Y / Ctrl+Shift+Z", scene.PeekRedo))
 	if raygui.Button(bounds, raygui.IconText(raygui.ICON_REDO, "")) {
 		log.Debug("topbar redo clicked")
 		action = app.doRedo()
@@ -386,6 +392,7 @@ func (sb *guiSidebar) updateAndDraw() (action Action) {
 type guiDetailsbar struct {
 	areaInit bool
 	textarea text.Area
+	position guiPositionEditor
 }
 
 func textAreaOpts() text.AreaOptions {
@@ -456,13 +463,36 @@ func (db *guiDetailsbar) updateAndDraw() Action {
 			action = db.doUpdateTextBoxContent()
 		}
 		raygui.Enable()
+	} else if obj, ok := editedObject(); ok {
+		db.reset()
+		action = db.position.updateAndDraw(bar, obj)
 	} else {
 		db.reset()
+		db.position.reset()
 	}
 	return action
 }
 
-type guiStatusbar struct{}
+type guiStatusbar struct {
+	// cached selection metrics, see [guiStatusbar.selectionMetrics]
+	metrics SelectionMetrics
+	// whether metrics has been computed
+	metricsComputed bool
+	// scene revision and selection metrics was computed for
+	metricsRevision  int
+	metricsSelection ObjectSelection
+}
+
+// selectionMetrics returns the metrics of the current selection, cached until the scene or the
+// selection changes
+func (sb *guiStatusbar) selectionMetrics() SelectionMetrics {
+	if !sb.metricsComputed || sb.metricsRevision != scene.revision || !selection.sameIdxs(sb.metricsSelection) {
+		sb.metrics = selection.Metrics(scene)
+		sb.metricsComputed, sb.metricsRevision = true, scene.revision
+		selection.ObjectSelection.copy(&sb.metricsSelection)
+	}
+	return sb.metrics
+}
 
 func (sb *guiStatusbar) updateAndDraw() Action {
 	bar := rl.NewRectangle(0, dims.Screen.Y-StatusBarHeight, dims.Screen.X, StatusBarHeight)
@@ -472,10 +502,19 @@ func (sb *guiStatusbar) updateAndDraw() Action {
 	// left aligned text
 	lpos := bar.TopLeft().Add(vec2(5, 5))
 	ltext := fmt.Sprintf("FPS=% 3d | %12v | Building Draws=%d | Path Draws=%d", int(rl.GetFPS()), app.Mode, app.drawCounts.Buildings, app.drawCounts.Paths)
+	if app.Mode == ModeSelection {
+		m := sb.selectionMetrics()
+		ltext += fmt.Sprintf(" | Selection %vx%v | Buildings=%d (area %v) | Paths=%d (length %.1f) | Text Boxes=%d",
+			m.Width, m.Height, m.Buildings, m.BuildingsArea, m.Paths, m.PathsLength, m.TextBoxes)
+	}
 	rl.DrawTextEx(font, ltext, lpos, 24, 1, colors.Gray700)
 
 	// right aligned text
-	rtext := fmt.Sprintf("X:%5d  Y:%5d", int(mouse.SnappedPos.X), int(mouse.SnappedPos.Y))
+	pos := scene.DisplayPos(mouse.SnappedPos)
+	rtext := fmt.Sprintf("X:%5d  Y:%5d", int(pos.X), int(pos.Y))
+	if numInput.Active() {
+		rtext = fmt.Sprintf("Typed: %s | %s", numInput.Text(), rtext)
+	}
 	width := rl.MeasureTextEx(font, rtext, 24, 1).X
 	rpos := bar.TopRight().Add(vec2(-5-width, 5))
 	rl.DrawTextEx(font, rtext, rpos, 24, 1, colors.Gray700)
@@ -483,6 +522,67 @@ func (sb *guiStatusbar) updateAndDraw() Action {
 	return nil
 }
 
+// guiHUD is an overlay in the scene area top left corner showing the mouse world position, the zoom
+// level and the scene objects counts
+//
+// It is only drawn (no controls), so it does not capture mouse inputs.
+type guiHUD struct {
+	// whether the HUD is drawn
+	Visible bool
+}
+
+// HUD text size and padding (px)
+const (
+	hudFontSize = 20.
+	hudPadding  = 5.
+)
+
+func (h *guiHUD) draw() {
+	if !h.Visible {
+		return
+	}
+	pos := camera.WorldPos(mouse.ScreenPos)
+	lines := fmt.Sprintf("X: %.2f  Y: %.2f\nZoom: %.3f\nBuildings=%d | Paths=%d | Text Boxes=%d",
+		pos.X, pos.Y, camera.Zoom(), len(scene.Buildings), len(scene.Paths), len(scene.TextBoxes))
+	size := rl.MeasureTextEx(font, lines, hudFontSize, 1)
+	bg := rl.NewRectangle(dims.Scene.X, dims.Scene.Y, size.X+2*hudPadding, size.Y+2*hudPadding)
+	rl.DrawRectangleRec(bg, colors.WithAlpha(colors.Gray100, 0.8))
+	rl.DrawTextEx(font, lines, bg.TopLeft().Add(vec2(hudPadding, hudPadding)), hudFontSize, 1, colors.Gray700)
+}
+
+// guiSummary is an overlay in the scene area top right corner showing the classes summary of the
+// scene (see [Scene.Summary])
+//
+// It is only drawn (no controls), so it does not capture mouse inputs.
+type guiSummary struct {
+	// whether the summary is drawn
+	Visible bool
+}
+
+func (sp *guiSummary) draw() {
+	if !sp.Visible {
+		return
+	}
+	lines := scene.summaryLines()
+	if len(lines) == 0 {
+		lines = []string{"Empty scene"}
+	}
+	text := strings.Join(lines, "\n")
+	size := rl.MeasureTextEx(font, text, hudFontSize, 1)
+	width := size.X + 2*hudPadding
+	bg := rl.NewRectangle(dims.Scene.X+dims.Scene.Width-width, dims.Scene.Y, width, size.Y+2*hudPadding)
+	rl.DrawRectangleRec(bg, colors.WithAlpha(colors.Gray100, 0.8))
+	rl.DrawTextEx(font, text, bg.TopLeft().Add(vec2(hudPadding, hudPadding)), hudFontSize, 1, colors.Gray700)
+}
+
+// historyTooltip returns the undo / redo button tooltip, describing the operation peek would return
+func historyTooltip(name, shortcut string, peek func() (sceneOpType, int, bool)) string {
+	if opType, count, ok := peek(); ok {
+		return fmt.Sprintf("%s: %s %d object(s) (%s)", name, opType, count, shortcut)
+	}
+	return fmt.Sprintf("%s (%s)", name, shortcut)
+}
+
 // orAction returns the first non nil action, or nil if both are nil
 func orAction(a, b Action) Action {
 	if a != nil {
@@ -0,0 +1,139 @@
+// jsonformat - JSON save file format
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/bonoboris/satisfied/log"
+)
+
+// jsonVersion is the current version of the JSON format, see [Scene.SaveToJSON]
+const jsonVersion = 1
+
+// sceneJSON is the [Scene.SaveToJSON] schema
+//
+// Classes are the same as in the text format (see [Scene.SaveToText]).
+type sceneJSON struct {
+	Version   int            `json:"version"`
+	Buildings []buildingJSON `json:"buildings"`
+	Paths     []pathJSON     `json:"paths"`
+	TextBoxes []textBoxJSON  `json:"textBoxes"`
+	// Unknown metadata entries, preserved as is
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+type buildingJSON struct {
+	Class  string  `json:"class"`
+	X      float32 `json:"x"`
+	Y      float32 `json:"y"`
+	Rot    int32   `json:"rot"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+type pathJSON struct {
+	Class  string  `json:"class"`
+	StartX float32 `json:"startX"`
+	StartY float32 `json:"startY"`
+	EndX   float32 `json:"endX"`
+	EndY   float32 `json:"endY"`
+	Hidden bool    `json:"hidden,omitempty"`
+}
+
+type textBoxJSON struct {
+	X          float32 `json:"x"`
+	Y          float32 `json:"y"`
+	Width      float32 `json:"width"`
+	Height     float32 `json:"height"`
+	Content    string  `json:"content"`
+	Hidden     bool    `json:"hidden,omitempty"`
+	Background bool    `json:"background,omitempty"`
+}
+
+// SaveToJSON saves the scene into JSON format, always using the current JSON format version.
+//
+// Errors originate from the underlying [io.Writer], or from non-finite coordinates.
+func (s *Scene) SaveToJSON(w io.Writer) error {
+	data := sceneJSON{
+		Version:   jsonVersion,
+		Buildings: make([]buildingJSON, 0, len(s.Buildings)),
+		Paths:     make([]pathJSON, 0, len(s.Paths)),
+		TextBoxes: make([]textBoxJSON, 0, len(s.TextBoxes)),
+		Metadata:  s.metadata,
+	}
+	for _, b := range s.Buildings {
+		data.Buildings = append(data.Buildings, buildingJSON{
+			Class: b.Def().Class, X: b.Pos.X, Y: b.Pos.Y, Rot: b.Rot, Hidden: b.Hidden,
+		})
+	}
+	for _, p := range s.Paths {
+		data.Paths = append(data.Paths, pathJSON{
+			Class: p.Def().Class, StartX: p.Start.X, StartY: p.Start.Y, EndX: p.End.X, EndY: p.End.Y, Hidden: p.Hidden,
+		})
+	}
+	for _, tb := range s.TextBoxes {
+		data.TextBoxes = append(data.TextBoxes, textBoxJSON{
+			X: tb.Bounds.X, Y: tb.Bounds.Y, Width: tb.Bounds.Width, Height: tb.Bounds.Height,
+			Content: tb.Content, Hidden: tb.Hidden, Background: tb.Background,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+// LoadFromJSON loads the scene from JSON format, see [Scene.SaveToJSON]
+//
+// The scene is only modified if loading succeeds, its objects are then replaced and its history is
+// cleared. Unknown classes, too many objects (see [SetMaxObjects]) and versions higher than the
+// current one are errors.
+func (s *Scene) LoadFromJSON(r io.Reader) error {
+	var data sceneJSON
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if data.Version < 0 {
+		return fmt.Errorf("%s: %d", msgInvalidVersionNumber, data.Version)
+	}
+	if data.Version > jsonVersion {
+		return fmt.Errorf("%s: %d", msgVersionTooHigh, data.Version)
+	}
+	if n := len(data.Buildings) + len(data.Paths) + len(data.TextBoxes); maxObjects > 0 && n > maxObjects {
+		return fmt.Errorf(msgTooManyObjects, maxObjects)
+	}
+
+	var col ObjectCollection
+	for i, bj := range data.Buildings {
+		defIdx := buildingDefs.Index(bj.Class)
+		if defIdx < 0 {
+			return fmt.Errorf("building %d: %s: %q", i, msgInvalidClass, bj.Class)
+		}
+		col.Buildings = append(col.Buildings, Building{DefIdx: defIdx, Pos: vec2(bj.X, bj.Y), Rot: bj.Rot, Hidden: bj.Hidden})
+	}
+	for i, pj := range data.Paths {
+		defIdx := pathDefs.Index(pj.Class)
+		if defIdx < 0 {
+			return fmt.Errorf("path %d: %s: %q", i, msgInvalidClass, pj.Class)
+		}
+		col.Paths = append(col.Paths, Path{
+			DefIdx: defIdx, Start: vec2(pj.StartX, pj.StartY), End: vec2(pj.EndX, pj.EndY), Hidden: pj.Hidden,
+		})
+	}
+	for _, tj := range data.TextBoxes {
+		tb := TextBox{Content: tj.Content, Hidden: tj.Hidden, Background: tj.Background}
+		tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height = tj.X, tj.Y, tj.Width, tj.Height
+		col.TextBoxes = append(col.TextBoxes, tb)
+	}
+
+	s.ObjectCollection = col
+	s.metadata = data.Metadata
+	s.formatVersion = version
+	s.resetHistory()
+	s.invalidateCaches()
+	log.Info("scene.LoadFromJSON", "version", data.Version, "buildings", len(col.Buildings),
+		"paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+	return nil
+}
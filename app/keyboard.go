@@ -81,9 +81,9 @@ func (kb *Keyboard) Update() {
 
 // Binding returns the key binding that matches the pressed key and modifiers
 //
-// If gui is capturing key presses, always returns [BindingNull]
+// If gui is capturing key presses, or the key is consumed by [NumericInput], always returns [BindingNull]
 func (kb Keyboard) Binding() KeyBinding {
-	if gui.CapturesKeyPress() {
+	if gui.CapturesKeyPress() || numInput.captured {
 		return BindingNull
 	}
 	for i, pair := range keyBindings {
@@ -127,6 +127,7 @@ const (
 	BindingRedo
 	BindingDelete
 	BindingDuplicate
+	BindingRotateInPlace // before BindingRotate, which matches any modifiers
 	BindingRotate
 	BindingDrag
 	BindingUp
@@ -136,6 +137,12 @@ const (
 	BindingZoomIn
 	BindingZoomOut
 	BindingZoomReset
+	BindingZoomFit
+	BindingMeasure
+	BindingSwapSelections
+	BindingRepeatTransform
+	BindingCopy
+	BindingPaste
 )
 
 // default key bindings
@@ -148,9 +155,9 @@ var keyBindings = [...][2]keyBindingDef{
 	BindingSaveAs:    {{code: rl.KeyS, ctrl: Yes, shift: Yes}},
 	BindingUndo:      {{code: rl.KeyZ, ctrl: Yes, shift: No}},
 	BindingRedo:      {{code: rl.KeyY, ctrl: Yes}, {code: rl.KeyZ, ctrl: Yes, shift: Yes}},
-	BindingDuplicate: {{code: rl.KeyD}},
+	BindingDuplicate: {{code: rl.KeyD, ctrl: No}},
 	BindingRotate:    {{code: rl.KeyR}},
-	BindingDrag:      {{code: rl.KeyV}},
+	BindingDrag:      {{code: rl.KeyV, ctrl: No}},
 	BindingUp:        {{code: rl.KeyUp}},
 	BindingDown:      {{code: rl.KeyDown}},
 	BindingLeft:      {{code: rl.KeyLeft}},
@@ -158,6 +165,19 @@ var keyBindings = [...][2]keyBindingDef{
 	BindingZoomIn:    {{code: rl.KeyEqual, shift: Yes}, {code: rl.KeyKpAdd}},
 	BindingZoomOut:   {{code: rl.KeyMinus}, {code: rl.KeyKpSubtract}},
 	BindingZoomReset: {{code: rl.KeyEqual, shift: No}, {code: rl.KeyKp0}},
+	// frame the selection, or the whole scene
+	BindingZoomFit: {{code: rl.KeyHome}, {code: rl.KeyKpDecimal}},
+	// swap current and secondary selections
+	BindingSwapSelections: {{code: rl.KeyTab, ctrl: No}},
+	// repeat last duplicate / move
+	BindingRepeatTransform: {{code: rl.KeyD, ctrl: Yes}},
+	// system clipboard
+	BindingCopy:  {{code: rl.KeyC, ctrl: Yes}},
+	BindingPaste: {{code: rl.KeyV, ctrl: Yes}},
+	// toggle the ruler
+	BindingMeasure: {{code: rl.KeyM, ctrl: No}},
+	// rotate each selected building around its own center (rotates like BindingRotate in other modes)
+	BindingRotateInPlace: {{code: rl.KeyR, ctrl: No, shift: Yes}},
 }
 
 func GetKeyName(key int32) string {
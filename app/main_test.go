@@ -0,0 +1,24 @@
+package app
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestMain loads the building and path definitions from the assets directory
+func TestMain(m *testing.M) {
+	for path, defs := range map[string]any{
+		"../assets/building_defs.json": &buildingDefs,
+		"../assets/path_defs.json":     &pathDefs,
+	} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			panic(err)
+		}
+		if err := json.Unmarshal(data, defs); err != nil {
+			panic(err)
+		}
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,229 @@
+// network - Connectivity graph of buildings and paths (routing)
+
+package app
+
+import (
+	"container/heap"
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// pathNetwork is the connectivity graph of a scene buildings and paths
+//
+// Nodes are the buildings (node i is building i) followed by the paths ends (nodes nb+2*i and
+// nb+2*i+1 are the start and end of path i, with nb the number of buildings).
+//
+// Each path links its ends with its length, path ends within threshold of each other or of a
+// building bounds are linked with a zero length edge.
+type pathNetwork struct {
+	// adjacency lists, by node
+	adj [][]networkEdge
+}
+
+// networkEdge is an edge of a [pathNetwork]
+type networkEdge struct {
+	to     int
+	length float32
+	// index of the path the edge runs along, -1 for connections
+	path int
+}
+
+// newPathNetwork builds the connectivity graph of s, see [pathNetwork]
+//
+// Candidate connections of each path end are looked up in the spatial index.
+func newPathNetwork(s *Scene, threshold float32) pathNetwork {
+	nb := len(s.Buildings)
+	n := pathNetwork{adj: make([][]networkEdge, nb+2*len(s.Paths))}
+	link := func(a, b int, length float32, path int) {
+		n.adj[a] = append(n.adj[a], networkEdge{to: b, length: length, path: path})
+		n.adj[b] = append(n.adj[b], networkEdge{to: a, length: length, path: path})
+	}
+	near := func(r rl.Rectangle, lookup func(r rl.Rectangle) ([]int, bool), count int) []int {
+		idxs, ok := lookup(r)
+		if !ok {
+			return Range(0, count)
+		}
+		slices.Sort(idxs)
+		return slices.Compact(idxs)
+	}
+
+	s.updateSpatialIndex()
+	for i, p := range s.Paths {
+		link(nb+2*i, nb+2*i+1, p.Start.Distance(p.End), i)
+		for k, pos := range [2]rl.Vector2{p.Start, p.End} {
+			node := nb + 2*i + k
+			r := GrowRect(rl.NewRectangleV(pos, rl.Vector2{}), threshold)
+			for _, bIdx := range near(r, s.index.buildingsNear, nb) {
+				if GrowRect(s.Buildings[bIdx].Bounds(), threshold).CheckCollisionPoint(pos) {
+					link(bIdx, node, 0, -1)
+				}
+			}
+			// each pair of paths is linked once, from the path with the highest index
+			for _, j := range near(r, s.index.pathsNear, len(s.Paths)) {
+				if j >= i {
+					continue
+				}
+				q := s.Paths[j]
+				if pos.Distance(q.Start) <= threshold {
+					link(node, nb+2*j, 0, -1)
+				}
+				if pos.Distance(q.End) <= threshold {
+					link(node, nb+2*j+1, 0, -1)
+				}
+			}
+		}
+	}
+	return n
+}
+
+// shortestRoute returns the length of the shortest route between nodes from and to, the indices of
+// the paths along the route (in route order), and false if they are not connected.
+func (n pathNetwork) shortestRoute(from, to int) (float32, []int, bool) {
+	dist := make([]float32, len(n.adj))
+	prev := make([]networkEdge, len(n.adj)) // edge reaching each node, to is the previous node
+	for i := range dist {
+		dist[i] = math32.MaxFloat32
+		prev[i] = networkEdge{to: -1, path: -1}
+	}
+	dist[from] = 0
+	queue := &nodeQueue{{node: from}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(nodeQueueItem)
+		if item.dist > dist[item.node] {
+			continue // outdated entry
+		}
+		if item.node == to {
+			break
+		}
+		for _, e := range n.adj[item.node] {
+			if d := item.dist + e.length; d < dist[e.to] {
+				dist[e.to] = d
+				prev[e.to] = networkEdge{to: item.node, length: e.length, path: e.path}
+				heap.Push(queue, nodeQueueItem{node: e.to, dist: d})
+			}
+		}
+	}
+	if dist[to] == math32.MaxFloat32 {
+		return 0, nil, false
+	}
+
+	var paths []int
+	for node := to; node != from; node = prev[node].to {
+		if p := prev[node].path; p >= 0 {
+			paths = append(paths, p)
+		}
+	}
+	slices.Reverse(paths)
+	return dist[to], paths, true
+}
+
+// nodeQueueItem is an entry of [nodeQueue]
+type nodeQueueItem struct {
+	node int
+	dist float32
+}
+
+// nodeQueue is a min-heap of nodes by distance, see [container/heap]
+type nodeQueue []nodeQueueItem
+
+func (q nodeQueue) Len() int           { return len(q) }
+func (q nodeQueue) Less(i, j int) bool { return q[i].dist < q[j].dist }
+func (q nodeQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *nodeQueue) Push(x any)        { *q = append(*q, x.(nodeQueueItem)) }
+func (q *nodeQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// PathsConnectedTo returns the sorted indices of the paths with an end within threshold of the bounds
+// of building buildingIdx (the [Scene.RouteDistance] connection rule), empty if none is.
+func (s *Scene) PathsConnectedTo(buildingIdx int, threshold float32) []int {
+	connected := []int{}
+	if buildingIdx < 0 || buildingIdx >= len(s.Buildings) {
+		log.Warn("cannot list connected paths", "reason", "invalid building index", "idx", buildingIdx)
+		return connected
+	}
+	bounds := GrowRect(s.Buildings[buildingIdx].Bounds(), threshold)
+	s.updateSpatialIndex()
+	near, ok := s.index.pathsNear(bounds)
+	if !ok {
+		near = Range(0, len(s.Paths))
+	}
+	for _, i := range near {
+		p := s.Paths[i]
+		if bounds.CheckCollisionPoint(p.Start) || bounds.CheckCollisionPoint(p.End) {
+			connected = append(connected, i)
+		}
+	}
+	slices.Sort(connected)
+	connected = slices.Compact(connected)
+	log.Debug("scene.PathsConnectedTo", "building", buildingIdx, "paths", connected)
+	return connected
+}
+
+// distance from a building bounds within which path ends are considered connected to it, when
+// transforming buildings with [stickyConnections] (world units)
+const stickyThreshold = 1
+
+// stickyConnections makes the connected path ends follow the moved / rotated buildings, see
+// [Scene.WithConnectedEnds]
+var stickyConnections bool
+
+// WithConnectedEnds returns sel with the ends of the paths within threshold of the bounds of its
+// selected buildings added (see [Scene.PathsConnectedTo]), so that they follow the buildings when
+// transformed. Only the connected end of a path is added, its other end is kept as selected in sel.
+func (s *Scene) WithConnectedEnds(sel ObjectSelection, threshold float32) ObjectSelection {
+	ends := map[int]PathSel{}
+	for _, ps := range sel.PathIdxs {
+		ends[ps.Idx] = ps
+	}
+	added := 0
+	for _, bIdx := range sel.BuildingIdxs {
+		bounds := GrowRect(s.Buildings[bIdx].Bounds(), threshold)
+		for _, i := range s.PathsConnectedTo(bIdx, threshold) {
+			ps := ends[i]
+			ps.Idx = i
+			if start := bounds.CheckCollisionPoint(s.Paths[i].Start); start && !ps.Start {
+				ps.Start = true
+				added++
+			}
+			if end := bounds.CheckCollisionPoint(s.Paths[i].End); end && !ps.End {
+				ps.End = true
+				added++
+			}
+			ends[i] = ps
+		}
+	}
+	if added == 0 {
+		return sel
+	}
+	out := sel.clone()
+	out.PathIdxs = out.PathIdxs[:0]
+	for _, ps := range ends {
+		out.PathIdxs = append(out.PathIdxs, ps)
+	}
+	slices.SortFunc(out.PathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	out.recomputeBounds(s.ObjectCollection)
+	log.Debug("scene.WithConnectedEnds", "buildings", len(sel.BuildingIdxs), "addedEnds", added)
+	return out
+}
+
+// RouteDistance returns the travel distance from building fromBuilding to building toBuilding along
+// the paths, the indices of the paths on the route, and false if they are not connected.
+//
+// Path ends within threshold of each other or of a building bounds are considered connected, routes
+// may go through other buildings.
+func (s *Scene) RouteDistance(fromBuilding, toBuilding int, threshold float32) (float32, []int, bool) {
+	if fromBuilding < 0 || fromBuilding >= len(s.Buildings) || toBuilding < 0 || toBuilding >= len(s.Buildings) {
+		log.Warn("cannot compute route", "reason", "invalid building index", "from", fromBuilding, "to", toBuilding)
+		return 0, nil, false
+	}
+	dist, paths, ok := newPathNetwork(s, threshold).shortestRoute(fromBuilding, toBuilding)
+	log.Debug("scene.RouteDistance", "from", fromBuilding, "to", toBuilding, "dist", dist, "paths", paths, "ok", ok)
+	return dist, paths, ok
+}
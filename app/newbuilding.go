@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/matrix"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -12,8 +13,12 @@ var newBuilding NewBuilding
 
 // NewBuilding represents a new building creation state (corresponding to [ModeNewBuilding])
 type NewBuilding struct {
+	// building being placed, at the placement position (see [PreviewBuildingAt])
 	building Building
 	isValid  bool
+	// whether a validity check result was reported since placement started, and its value
+	// (see [OnPlacementValidityChanged])
+	validityReported, reportedValid bool
 }
 
 func (nb NewBuilding) traceState(key, val string) {
@@ -24,12 +29,74 @@ func (nb NewBuilding) traceState(key, val string) {
 	}
 }
 
+// placementDefault is the initial rotation and position offset of new buildings of a class
+type placementDefault struct {
+	rot    int32
+	offset rl.Vector2
+}
+
+// placementDefaults holds the placement defaults by building class, see [RegisterPlacementDefault]
+var placementDefaults = map[string]placementDefault{}
+
+// RegisterPlacementDefault registers the initial rotation (degrees, multiple of 90) and the position
+// offset of new buildings of the given class.
+//
+// The offset is in world units at rotation 0, it is rotated with the building and added to the
+// placement position, see [PreviewBuildingAt]. Unregistered classes are placed with no rotation and
+// no offset.
+func RegisterPlacementDefault(class string, rot int, offset rl.Vector2) {
+	if rot%90 != 0 {
+		log.Warn("cannot register placement default", "reason", "rotation is not a multiple of 90", "class", class, "rot", rot)
+		return
+	}
+	placementDefaults[class] = placementDefault{rot: int32((rot%360 + 360) % 360), offset: offset}
+}
+
+// PreviewBuildingAt returns b as placed at pos: positioned at pos plus the placement default offset
+// of its class rotated with b, see [RegisterPlacementDefault].
+func PreviewBuildingAt(b Building, pos rl.Vector2) Building {
+	b.Pos = pos
+	if b.DefIdx >= 0 {
+		offset := placementDefaults[b.Def().Class].offset
+		b.Pos = pos.Add(matrix.NewRotate(b.Rot).ApplyV(offset))
+	}
+	return b
+}
+
+// preview returns the building being placed, see [PreviewBuildingAt]
+func (nb NewBuilding) preview() Building { return PreviewBuildingAt(nb.building, nb.building.Pos) }
+
+// placementValidityHooks are called on placement validity changes, see [OnPlacementValidityChanged]
+var placementValidityHooks []func(valid bool)
+
+// OnPlacementValidityChanged registers fn to be called with the validity of the building being
+// placed (see [Scene.IsBuildingValid]) when it changes, and on the first check of each placement.
+//
+// Unlike the per-frame checks, it is only called on transitions (eg. to play a sound).
+func OnPlacementValidityChanged(fn func(valid bool)) {
+	placementValidityHooks = append(placementValidityHooks, fn)
+}
+
+// checkValidity checks the validity of the building being placed, calling the validity hooks on changes
+func (nb *NewBuilding) checkValidity() {
+	nb.isValid = scene.IsBuildingValid(nb.preview(), -1)
+	if nb.validityReported && nb.reportedValid == nb.isValid {
+		return
+	}
+	nb.validityReported, nb.reportedValid = true, nb.isValid
+	log.Debug("newBuilding.validityChanged", "isValid", nb.isValid)
+	for _, fn := range placementValidityHooks {
+		fn(nb.isValid)
+	}
+}
+
 // Reset resets the [NewBuilding] state
 func (nb *NewBuilding) Reset() {
 	nb.traceState("before", "Reset")
 	log.Debug("newBuilding.reset")
 	nb.building = Building{DefIdx: -1}
 	nb.isValid = false
+	nb.validityReported = false
 	nb.traceState("after", "Reset")
 }
 
@@ -41,11 +108,23 @@ func (nb *NewBuilding) GetAction() (action Action) {
 
 	switch keyboard.Binding() {
 	case BindingEscape:
-		return app.doSwitchMode(ModeNormal, ResetAll())
-	case BindingRotate:
+		return doEscape(EscapePlacement)
+	case BindingRotate, BindingRotateInPlace:
 		return nb.doRotate()
 	}
 
+	if numInput.Active() {
+		// typed position (relative to the reference origin) overrides the mouse
+		if pos, ok := numInput.Value(); ok {
+			nb.doMoveTo(scene.WorldPosOf(pos))
+			if numInput.committed() {
+				numInput.Reset()
+				return nb.doPlace()
+			}
+		}
+		return nil
+	}
+
 	if !mouse.InScene {
 		return nil
 	}
@@ -53,16 +132,26 @@ func (nb *NewBuilding) GetAction() (action Action) {
 		return nb.doPlace()
 	}
 	if !mouse.Left.Down {
-		return nb.doMoveTo(mouse.SnappedPos)
+		return nb.doMoveTo(scene.PlacementSnap(mouse.Pos))
 	}
 	return nil
 }
 
+// doCancel cancels the building placement and switches to [ModeNormal]
+func (nb *NewBuilding) doCancel() Action {
+	log.Debug("newBuilding.doCancel")
+	return app.doSwitchMode(ModeNormal, ResetAll())
+}
+
 func (nb *NewBuilding) doInit(defIdx int) Action {
 	nb.traceState("before", "doInit")
 	log.Debug("newBuilding.doInit", "defIdx", defIdx)
 	nb.building = Building{DefIdx: defIdx}
+	if defIdx >= 0 {
+		nb.building.Rot = placementDefaults[nb.building.Def().Class].rot
+	}
 	nb.isValid = true
+	nb.validityReported = false
 	resets := ResetAll().WithNewBuilding(false).WithGui(false)
 	nb.traceState("after", "doInit")
 	return app.doSwitchMode(ModeNewBuilding, resets)
@@ -73,7 +162,7 @@ func (nb *NewBuilding) doMoveTo(pos rl.Vector2) Action {
 	log.Trace("newBuilding.doMoveTo", "pos", pos) // moving by mouse -> tracing
 	app.Mode.Assert(ModeNewBuilding)
 	nb.building.Pos = pos
-	nb.isValid = scene.IsBuildingValid(nb.building, -1)
+	nb.checkValidity()
 	nb.traceState("after", "doMoveTo")
 	return nil
 }
@@ -82,8 +171,8 @@ func (nb *NewBuilding) doRotate() Action {
 	nb.traceState("before", "doRotate")
 	log.Debug("newBuilding.doRotate")
 	app.Mode.Assert(ModeNewBuilding)
-	nb.isValid = scene.IsBuildingValid(nb.building, -1)
 	nb.building.Rot += 90
+	nb.checkValidity()
 	nb.traceState("after", "doRotate")
 	return nil
 }
@@ -92,7 +181,7 @@ func (nb *NewBuilding) doPlace() Action {
 	nb.traceState("before", "doPlace")
 	log.Debug("newBuilding.doPlace")
 	app.Mode.Assert(ModeNewBuilding)
-	nb.isValid = scene.IsBuildingValid(nb.building, -1)
+	nb.checkValidity()
 	if nb.isValid {
 		scene.AddBuilding(nb.building)
 	}
@@ -123,8 +212,8 @@ func (np *NewBuilding) Dispatch(action Action) Action {
 
 func (np NewBuilding) Draw() {
 	if np.isValid {
-		np.building.Draw(DrawNew)
+		np.preview().Draw(DrawNew)
 	} else {
-		np.building.Draw(DrawInvalid)
+		np.preview().Draw(DrawInvalid)
 	}
 }
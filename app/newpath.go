@@ -4,12 +4,20 @@ import (
 	"fmt"
 
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
 // NewPath represents a new path creation state (corresponding to [ModeNewPath])
 var newPath NewPath
 
+const (
+	// radius around the placed end in which paths direction is looked at (world units)
+	alignRadius = 20
+	// maximum angle (degrees) between the new path and nearby paths to align it
+	alignAngle = 5
+)
+
 // NewPath represents a new path creation state (corresponding to [ModeNewPath])
 type NewPath struct {
 	path           Path
@@ -46,13 +54,8 @@ func (np *NewPath) GetAction() Action {
 
 	switch keyboard.Binding() {
 	case BindingEscape:
-		// escape cancels first end placement then switches to normal mode
-		if np.firstEndPlaced {
-			return np.doInit(np.path.DefIdx)
-		} else {
-			return app.doSwitchMode(ModeNormal, ResetAll())
-		}
-	case BindingRotate:
+		return doEscape(EscapePlacement)
+	case BindingRotate, BindingRotateInPlace:
 		return np.doReverse()
 	}
 
@@ -72,6 +75,15 @@ func (np *NewPath) GetAction() Action {
 	return nil
 }
 
+// doCancel cancels the first end placement if placed, otherwise switches to [ModeNormal]
+func (np *NewPath) doCancel() Action {
+	log.Debug("newPath.doCancel", "firstEndPlaced", np.firstEndPlaced)
+	if np.firstEndPlaced {
+		return np.doInit(np.path.DefIdx)
+	}
+	return app.doSwitchMode(ModeNormal, ResetAll())
+}
+
 func (np *NewPath) doInit(defIdx int) Action {
 	np.traceState("before", "doInit")
 	log.Debug("newPath.doInit", "defIdx", defIdx)
@@ -101,10 +113,15 @@ func (np *NewPath) doMoveTo(pos rl.Vector2) Action {
 		np.path.Start = pos
 		np.path.End = pos
 	} else {
+		// holding Shift constrains the path angle, instead of aligning it to nearby paths
+		align := np.alignToNearby
+		if keyboard.Shift {
+			align = constrainAngle
+		}
 		if np.reverse {
-			np.path.Start = pos
+			np.path.Start = align(np.path.End, pos)
 		} else {
-			np.path.End = pos
+			np.path.End = align(np.path.Start, pos)
 		}
 		np.isValid = scene.IsPathValid(np.path)
 	}
@@ -112,6 +129,42 @@ func (np *NewPath) doMoveTo(pos rl.Vector2) Action {
 	return nil
 }
 
+// alignToNearby returns pos moved onto the dominant direction of the paths near the fixed path end,
+// if the path direction is close enough to it (see [Scene.DominantDirectionNear])
+func (np *NewPath) alignToNearby(fixed, pos rl.Vector2) rl.Vector2 {
+	dir, ok := scene.DominantDirectionNear(fixed, alignRadius)
+	delta := pos.Subtract(fixed)
+	if !ok || delta.LengthSqr() == 0 {
+		return pos
+	}
+	// angle between undirected lines, in [0, 90]
+	cos := math32.Abs(delta.Normalize().DotProduct(dir))
+	if cos < math32.Cos(alignAngle*rl.Deg2rad) {
+		return pos
+	}
+	return grid.Snap(fixed.Add(dir.Scale(delta.DotProduct(dir))))
+}
+
+// constrainAngle returns pos moved so that the direction from fixed is horizontal, vertical or
+// diagonal (45°), whichever is the closest.
+//
+// When snapping is enabled (see [Grid.Snap]), diagonal offsets are snapped so that pos stays on the
+// grid if fixed and pos are: axis aligned offsets already are.
+func constrainAngle(fixed, pos rl.Vector2) rl.Vector2 {
+	delta := pos.Subtract(fixed)
+	dx, dy := math32.Abs(delta.X), math32.Abs(delta.Y)
+	switch tan := math32.Tan(22.5 * rl.Deg2rad); {
+	case dy <= dx*tan:
+		delta.Y = 0
+	case dx <= dy*tan:
+		delta.X = 0
+	default:
+		m := grid.Snap(vec2((dx+dy)/2, 0)).X
+		delta = vec2(math32.Copysign(m, delta.X), math32.Copysign(m, delta.Y))
+	}
+	return fixed.Add(delta)
+}
+
 func (np *NewPath) doPlaceStart() Action {
 	np.traceState("before", "doPlaceStart")
 	log.Debug("newPath.doPlaceStart")
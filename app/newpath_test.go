@@ -0,0 +1,29 @@
+package app
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// TestConstrainAngle checks that constrained path ends are axis aligned or diagonal, and stay on
+// the grid
+func TestConstrainAngle(t *testing.T) {
+	grid0 := grid
+	t.Cleanup(func() { grid = grid0 })
+	grid.SetSnapStep(1)
+	grid.SnapOff = false
+	fixed := vec2(10, 20)
+	for _, tc := range []struct{ pos, want rl.Vector2 }{
+		{vec2(30, 22), vec2(30, 20)}, // horizontal
+		{vec2(9, 5), vec2(10, 5)},    // vertical
+		{vec2(20, 29), vec2(20, 30)}, // diagonal, 9.5 rounded
+		{vec2(0, 30), vec2(0, 30)},   // exact diagonal
+		{vec2(-1, 8), vec2(-2, 8)},   // diagonal, 11.5 rounded
+		{fixed, fixed},               // no move
+	} {
+		if got := constrainAngle(fixed, tc.pos); got != tc.want {
+			t.Errorf("constrainAngle(%v, %v) = %v, want %v", fixed, tc.pos, got, tc.want)
+		}
+	}
+}
@@ -39,11 +39,7 @@ func (ntb *NewTextBox) GetAction() (action Action) {
 
 	switch keyboard.Binding() {
 	case BindingEscape:
-		if ntb.firstCornerPlaced {
-			return ntb.doInit()
-		} else {
-			return app.doSwitchMode(ModeNormal, ResetAll())
-		}
+		return doEscape(EscapePlacement)
 	}
 	if !mouse.InScene {
 		return nil
@@ -57,11 +53,20 @@ func (ntb *NewTextBox) GetAction() (action Action) {
 		}
 	}
 	if !mouse.Left.Down {
-		return ntb.doMoveTo(mouse.SnappedPos)
+		return ntb.doMoveTo(scene.PlacementSnap(mouse.Pos))
 	}
 	return nil
 }
 
+// doCancel cancels the first corner placement if placed, otherwise switches to [ModeNormal]
+func (ntb *NewTextBox) doCancel() Action {
+	log.Debug("newTextBox.doCancel", "firstCornerPlaced", ntb.firstCornerPlaced)
+	if ntb.firstCornerPlaced {
+		return ntb.doInit()
+	}
+	return app.doSwitchMode(ModeNormal, ResetAll())
+}
+
 func (ntb *NewTextBox) doInit() Action {
 	ntb.traceState("before", "doInit")
 	log.Debug("newTextBox.doInit")
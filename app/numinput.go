@@ -0,0 +1,125 @@
+// numinput - Typed coordinates entry during placement / transformation
+
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+var numInput NumericInput
+
+// NumericInput holds coordinates typed as "x,y" while placing a building or transforming the selection
+//
+// While typing, the typed coordinates override the mouse: the building position when placing, the
+// offset from the start position when dragging or duplicating. Enter commits, Escape clears the text
+// and cancels the placement / transformation. Once typing started, Minus toggles the sign of the
+// typed coordinate (otherwise it zooms out).
+type NumericInput struct {
+	// typed text
+	text string
+	// whether the key pressed this frame was consumed, see [Keyboard.Binding]
+	captured bool
+}
+
+// Reset clears the typed text
+func (ni *NumericInput) Reset() {
+	ni.text = ""
+	ni.captured = false
+}
+
+// Active returns true if some text is typed
+func (ni NumericInput) Active() bool { return ni.text != "" }
+
+// Text returns the typed text
+func (ni NumericInput) Text() string { return ni.text }
+
+// Value parses the typed text as "x,y" coordinates
+func (ni NumericInput) Value() (rl.Vector2, bool) {
+	xs, ys, ok := strings.Cut(ni.text, ",")
+	if !ok {
+		return rl.Vector2{}, false
+	}
+	x, errX := strconv.ParseFloat(strings.TrimSpace(xs), 32)
+	y, errY := strconv.ParseFloat(strings.TrimSpace(ys), 32)
+	if errX != nil || errY != nil || !AllFinite(float32(x), float32(y)) {
+		return rl.Vector2{}, false
+	}
+	return vec2(float32(x), float32(y)), true
+}
+
+// isEnabled returns true if the current mode accepts typed coordinates
+func (ni NumericInput) isEnabled() bool {
+	switch app.Mode {
+	case ModeNewBuilding:
+		return true
+	case ModeSelection:
+		return selection.mode == SelectionDrag || selection.mode == SelectionDuplicate
+	default:
+		return false
+	}
+}
+
+// Update appends the pressed key to the typed text
+//
+// It must be called right after [Keyboard.Update], consumed keys do not trigger key bindings.
+func (ni *NumericInput) Update() {
+	ni.captured = false
+	if !ni.isEnabled() || gui.CapturesKeyPress() {
+		ni.Reset()
+		return
+	}
+
+	var char byte
+	switch key := keyboard.Pressed; {
+	case key >= rl.KeyZero && key <= rl.KeyNine:
+		char = byte('0' + key - rl.KeyZero)
+	case key >= rl.KeyKp0 && key <= rl.KeyKp9:
+		char = byte('0' + key - rl.KeyKp0)
+	case key == rl.KeyPeriod || key == rl.KeyKpDecimal:
+		char = '.'
+	case key == rl.KeyComma:
+		char = ','
+	case (key == rl.KeyMinus || key == rl.KeyKpSubtract) && ni.Active() && !keyboard.Ctrl && !keyboard.Alt:
+		ni.toggleSign()
+		ni.captured = true
+		log.Debug("numInput", "text", ni.text)
+		return
+	case key == rl.KeyBackspace && ni.Active():
+		ni.text = ni.text[:len(ni.text)-1]
+		ni.captured = true
+		return
+	case key == rl.KeyEscape && ni.Active():
+		// not captured: Escape also cancels the placement / transformation
+		log.Debug("numInput", "action", "clear", "text", ni.text)
+		ni.text = ""
+		return
+	default:
+		return
+	}
+	if keyboard.Ctrl || keyboard.Alt {
+		return
+	}
+	ni.text += string(char)
+	ni.captured = true
+	log.Debug("numInput", "text", ni.text)
+}
+
+// toggleSign toggles the sign of the coordinate being typed (the last one)
+func (ni *NumericInput) toggleSign() {
+	start := strings.LastIndexByte(ni.text, ',') + 1
+	if strings.HasPrefix(ni.text[start:], "-") {
+		ni.text = ni.text[:start] + ni.text[start+1:]
+	} else {
+		ni.text = ni.text[:start] + "-" + ni.text[start:]
+	}
+}
+
+// committed returns true if the typed text is valid and Enter is pressed
+func (ni NumericInput) committed() bool {
+	_, ok := ni.Value()
+	return ok && (keyboard.Pressed == rl.KeyEnter || keyboard.Pressed == rl.KeyKpEnter)
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// TestNumericInput checks the typed coordinates, and that Minus and Escape are left to their key
+// bindings when nothing is typed
+func TestNumericInput(t *testing.T) {
+	mode := app.Mode
+	t.Cleanup(func() {
+		app.Mode = mode
+		keyboard = Keyboard{}
+		numInput = NumericInput{}
+	})
+	app.Mode = ModeNewBuilding
+	press := func(key int32) {
+		keyboard.Pressed = key
+		numInput.Update()
+	}
+
+	press(rl.KeyMinus)
+	if numInput.Active() || keyboard.Binding() != BindingZoomOut {
+		t.Fatalf("minus with nothing typed: text %q, binding %v, want zoom out", numInput.Text(), keyboard.Binding())
+	}
+	for _, key := range []int32{rl.KeyOne, rl.KeyTwo, rl.KeyMinus, rl.KeyComma, rl.KeyFive, rl.KeyKpSubtract, rl.KeyKpSubtract, rl.KeyKpSubtract} {
+		press(key)
+		if keyboard.Binding() != BindingNull {
+			t.Errorf("key %d not captured while typing", key)
+		}
+	}
+	if v, ok := numInput.Value(); numInput.Text() != "-12,-5" || !ok || v != vec2(-12, -5) {
+		t.Errorf("typed %q (%v, %v), want -12,-5", numInput.Text(), v, ok)
+	}
+	press(rl.KeyEscape)
+	if numInput.Active() || keyboard.Binding() != BindingEscape {
+		t.Errorf("escape: text %q, binding %v, want cleared and escape", numInput.Text(), keyboard.Binding())
+	}
+}
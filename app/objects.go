@@ -5,6 +5,8 @@ package app
 import (
 	"fmt"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/bonoboris/satisfied/math32"
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -88,6 +90,26 @@ func (oc ObjectCollection) IsEmpty() bool {
 	return len(oc.Buildings) == 0 && len(oc.Paths) == 0 && len(oc.TextBoxes) == 0
 }
 
+// isFinite returns true if none of the objects coordinates is NaN or infinite
+func (oc ObjectCollection) isFinite() bool {
+	for _, b := range oc.Buildings {
+		if !AllFinite(b.Pos.X, b.Pos.Y) {
+			return false
+		}
+	}
+	for _, p := range oc.Paths {
+		if !AllFinite(p.Start.X, p.Start.Y, p.End.X, p.End.Y) {
+			return false
+		}
+	}
+	for _, tb := range oc.TextBoxes {
+		if !AllFinite(tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height) {
+			return false
+		}
+	}
+	return true
+}
+
 func (oc ObjectCollection) clone() ObjectCollection {
 	return ObjectCollection{
 		Buildings: slices.Clone(oc.Buildings),
@@ -96,53 +118,82 @@ func (oc ObjectCollection) clone() ObjectCollection {
 	}
 }
 
-// SelectFromRect fills sel with the objects in the given rectangle and recomputes its bounding box
+// Equal returns true if oc and other hold the same objects, in any order (see [ObjectCollection.ContainsAll])
+func (oc ObjectCollection) Equal(other ObjectCollection) bool {
+	return len(oc.Buildings) == len(other.Buildings) && len(oc.Paths) == len(other.Paths) &&
+		len(oc.TextBoxes) == len(other.TextBoxes) && oc.ContainsAll(other)
+}
+
+// ContainsAll returns true if every object of subset is in oc, in any order
 //
-// sel must be empty, it is passed to avoid reallocating it
-func (oc ObjectCollection) SelectFromRect(sel *ObjectSelection, rect rl.Rectangle) {
-	xmin, ymin := math32.MaxFloat32, math32.MaxFloat32
-	xmax, ymax := -math32.MaxFloat32, -math32.MaxFloat32
-	for i, b := range scene.Buildings {
-		bounds := b.Bounds()
-		tl := bounds.TopLeft()
-		br := bounds.BottomRight()
-		if rect.CheckCollisionPoint(tl) && rect.CheckCollisionPoint(br) {
-			sel.BuildingIdxs = append(sel.BuildingIdxs, i)
-			xmin, ymin = min(xmin, tl.X), min(ymin, tl.Y)
-			xmax, ymax = max(xmax, br.X), max(ymax, br.Y)
-		}
+// Objects are compared by value with exact float comparison, a path and its reverse are different.
+// Duplicates count: subset objects are each matched with a distinct object of oc.
+func (oc ObjectCollection) ContainsAll(subset ObjectCollection) bool {
+	return containsAllExact(oc.Buildings, subset.Buildings) && containsAllExact(oc.Paths, subset.Paths) &&
+		containsAllExact(oc.TextBoxes, subset.TextBoxes)
+}
+
+// EqualWithin is [ObjectCollection.Equal] with coordinates compared up to tol (see [ObjectCollection.ContainsAllWithin])
+func (oc ObjectCollection) EqualWithin(other ObjectCollection, tol float32) bool {
+	return len(oc.Buildings) == len(other.Buildings) && len(oc.Paths) == len(other.Paths) &&
+		len(oc.TextBoxes) == len(other.TextBoxes) && oc.ContainsAllWithin(other, tol)
+}
+
+// ContainsAllWithin is [ObjectCollection.ContainsAll] with coordinates (positions, path ends, text
+// boxes bounds) compared up to tol, other fields are compared exactly.
+//
+// Matching is greedy: each subset object is matched with the first unmatched close enough object of
+// oc, which may fail to find a matching when objects are closer than tol to each other.
+func (oc ObjectCollection) ContainsAllWithin(subset ObjectCollection, tol float32) bool {
+	near := func(a, b rl.Vector2) bool { return math32.Abs(a.X-b.X) <= tol && math32.Abs(a.Y-b.Y) <= tol }
+	return containsAllFunc(oc.Buildings, subset.Buildings, func(a, b Building) bool {
+		return a.DefIdx == b.DefIdx && a.Rot == b.Rot && a.Hidden == b.Hidden && near(a.Pos, b.Pos)
+	}) && containsAllFunc(oc.Paths, subset.Paths, func(a, b Path) bool {
+		return a.DefIdx == b.DefIdx && a.Hidden == b.Hidden && near(a.Start, b.Start) && near(a.End, b.End)
+	}) && containsAllFunc(oc.TextBoxes, subset.TextBoxes, func(a, b TextBox) bool {
+		return a.Content == b.Content && a.Background == b.Background && a.Hidden == b.Hidden &&
+			near(a.Bounds.TopLeft(), b.Bounds.TopLeft()) && near(a.Bounds.BottomRight(), b.Bounds.BottomRight())
+	})
+}
+
+// containsAllExact returns true if every element of subset is in set, duplicates included, in O(n)
+func containsAllExact[T comparable](set, subset []T) bool {
+	if len(subset) > len(set) {
+		return false
 	}
-	for i, p := range scene.Paths {
-		start := rect.CheckCollisionPoint(p.Start)
-		end := rect.CheckCollisionPoint(p.End)
-		if start && end {
-			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
-			xmin, ymin = min(xmin, min(p.Start.X, p.End.X)), min(ymin, min(p.Start.Y, p.End.Y))
-			xmax, ymax = max(xmax, max(p.Start.X, p.End.X)), max(ymax, max(p.Start.Y, p.End.Y))
-		} else if start {
-			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true})
-			xmin, ymin = min(xmin, p.Start.X), min(ymin, p.Start.Y)
-			xmax, ymax = max(xmax, p.Start.X), max(ymax, p.Start.Y)
-		} else if end {
-			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, End: true})
-			xmin, ymin = min(xmin, p.End.X), min(ymin, p.End.Y)
-			xmax, ymax = max(xmax, p.End.X), max(ymax, p.End.Y)
-		}
+	counts := make(map[T]int, len(set))
+	for _, elt := range set {
+		counts[elt]++
 	}
-
-	for i, tb := range oc.TextBoxes {
-		tl := tb.Bounds.TopLeft()
-		br := tb.Bounds.BottomRight()
-		if rect.CheckCollisionPoint(tl) && rect.CheckCollisionPoint(br) {
-			sel.TextBoxIdxs = append(sel.TextBoxIdxs, i)
-			xmin, ymin = min(xmin, tl.X), min(ymin, tl.Y)
-			xmax, ymax = max(xmax, br.X), max(ymax, br.Y)
+	for _, elt := range subset {
+		if counts[elt] == 0 {
+			return false
 		}
+		counts[elt]--
 	}
+	return true
+}
 
-	if !sel.IsEmpty() {
-		sel.Bounds = rl.NewRectangle(xmin, ymin, xmax-xmin, ymax-ymin)
+// containsAllFunc returns true if every element of subset is equal to a distinct element of set,
+// matched greedily, in O(n*m)
+func containsAllFunc[T any](set, subset []T, eq func(a, b T) bool) bool {
+	if len(subset) > len(set) {
+		return false
+	}
+	used := make([]bool, len(set))
+	for _, elt := range subset {
+		found := false
+		for i := range set {
+			if !used[i] && eq(set[i], elt) {
+				used[i], found = true, true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
 	}
+	return true
 }
 
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -191,6 +242,12 @@ func (os ObjectSelection) copy(into *ObjectSelection) {
 	into.Bounds = os.Bounds
 }
 
+// sameIdxs returns true if both selections select the same objects (bounds are ignored)
+func (os ObjectSelection) sameIdxs(other ObjectSelection) bool {
+	return slices.Equal(os.BuildingIdxs, other.BuildingIdxs) && slices.Equal(os.PathIdxs, other.PathIdxs) &&
+		slices.Equal(os.TextBoxIdxs, other.TextBoxIdxs)
+}
+
 // reset the selection
 func (os *ObjectSelection) reset() {
 	os.BuildingIdxs = os.BuildingIdxs[:0]
@@ -199,6 +256,162 @@ func (os *ObjectSelection) reset() {
 	os.Bounds = rl.NewRectangle(0, 0, 0, 0)
 }
 
+// remap updates the indices after the objects were reordered: buildings, paths and textBoxes give
+// the old index of the object at each new index (-1 for new objects). Indices of removed objects are
+// dropped. Bounds are left unchanged.
+func (os *ObjectSelection) remap(buildings, paths, textBoxes []int) {
+	newIdx := func(perm []int) map[int]int {
+		m := make(map[int]int, len(perm))
+		for i, old := range perm {
+			if old >= 0 {
+				m[old] = i
+			}
+		}
+		return m
+	}
+	remapIdxs := func(idxs []int, perm []int) []int {
+		m := newIdx(perm)
+		out := idxs[:0]
+		for _, idx := range idxs {
+			if i, ok := m[idx]; ok {
+				out = append(out, i)
+			}
+		}
+		slices.Sort(out)
+		return out
+	}
+	os.BuildingIdxs = remapIdxs(os.BuildingIdxs, buildings)
+	os.TextBoxIdxs = remapIdxs(os.TextBoxIdxs, textBoxes)
+	m := newIdx(paths)
+	pathIdxs := os.PathIdxs[:0]
+	for _, ps := range os.PathIdxs {
+		if i, ok := m[ps.Idx]; ok {
+			ps.Idx = i
+			pathIdxs = append(pathIdxs, ps)
+		}
+	}
+	slices.SortFunc(pathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	os.PathIdxs = pathIdxs
+}
+
+// merge adds the objects of other to the selection, selected path ends are combined. Bounds are left
+// unchanged.
+func (os *ObjectSelection) merge(other ObjectSelection) {
+	union := func(a, b []int) []int {
+		a = append(a, b...)
+		slices.Sort(a)
+		return slices.Compact(a)
+	}
+	os.BuildingIdxs = union(os.BuildingIdxs, other.BuildingIdxs)
+	os.TextBoxIdxs = union(os.TextBoxIdxs, other.TextBoxIdxs)
+	pathIdxs := append(os.PathIdxs, other.PathIdxs...)
+	slices.SortStableFunc(pathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	os.PathIdxs = pathIdxs[:0]
+	for _, ps := range pathIdxs {
+		if n := len(os.PathIdxs); n > 0 && os.PathIdxs[n-1].Idx == ps.Idx {
+			os.PathIdxs[n-1].Start = os.PathIdxs[n-1].Start || ps.Start
+			os.PathIdxs[n-1].End = os.PathIdxs[n-1].End || ps.End
+		} else {
+			os.PathIdxs = append(os.PathIdxs, ps)
+		}
+	}
+}
+
+// keepValidIdxs removes the indices out of the given collection bounds and recomputes the bounds
+func (os *ObjectSelection) keepValidIdxs(oc ObjectCollection) {
+	// indices are sorted in ascending order
+	for len(os.BuildingIdxs) > 0 && os.BuildingIdxs[len(os.BuildingIdxs)-1] >= len(oc.Buildings) {
+		os.BuildingIdxs = os.BuildingIdxs[:len(os.BuildingIdxs)-1]
+	}
+	for len(os.PathIdxs) > 0 && os.PathIdxs[len(os.PathIdxs)-1].Idx >= len(oc.Paths) {
+		os.PathIdxs = os.PathIdxs[:len(os.PathIdxs)-1]
+	}
+	for len(os.TextBoxIdxs) > 0 && os.TextBoxIdxs[len(os.TextBoxIdxs)-1] >= len(oc.TextBoxes) {
+		os.TextBoxIdxs = os.TextBoxIdxs[:len(os.TextBoxIdxs)-1]
+	}
+	if os.IsEmpty() {
+		os.Bounds = rl.NewRectangle(0, 0, 0, 0)
+	} else {
+		os.recomputeBounds(oc)
+	}
+}
+
+// SelectAll returns the selection of every object of the collection, with its bounds
+func (oc ObjectCollection) SelectAll() ObjectSelection { return oc.SelectAllOfType(TypeInvalid) }
+
+// SelectAllOfType returns the selection of every object of the collection of the given type (all
+// types for [TypeInvalid]), with its bounds.
+//
+// Paths are fully selected, [TypePathStart] and [TypePathEnd] are equivalent to [TypePath].
+// The selection is empty with zero-valued bounds if there is no such object.
+func (oc ObjectCollection) SelectAllOfType(t ObjectType) ObjectSelection {
+	all := t == TypeInvalid
+	var sel ObjectSelection
+	if all || t == TypeBuilding {
+		sel.BuildingIdxs = Range(0, len(oc.Buildings))
+	}
+	if all || t == TypeTextBox {
+		sel.TextBoxIdxs = Range(0, len(oc.TextBoxes))
+	}
+	if all || t == TypePath || t == TypePathStart || t == TypePathEnd {
+		for i := range oc.Paths {
+			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+		}
+	}
+	if !sel.IsEmpty() {
+		sel.recomputeBounds(oc)
+	}
+	return sel
+}
+
+// BoundsWithMargin returns the selection bounds grown by pad on every side
+func (os ObjectSelection) BoundsWithMargin(pad float32) rl.Rectangle {
+	return GrowRect(os.Bounds, pad)
+}
+
+// SelectionMetrics holds figures about a selection, see [ObjectSelection.Metrics]
+type SelectionMetrics struct {
+	// Width of the selection bounds
+	Width float32
+	// Height of the selection bounds
+	Height float32
+	// Number of selected buildings
+	Buildings int
+	// Total footprint area of the selected buildings
+	BuildingsArea float32
+	// Number of selected paths (either end selected)
+	Paths int
+	// Total length of the selected paths
+	PathsLength float32
+	// Number of selected text boxes
+	TextBoxes int
+}
+
+// Metrics returns the metrics of the selected objects of s, zero-valued for an empty selection
+//
+// Bounds are computed from the objects, not from [ObjectSelection.Bounds].
+func (os ObjectSelection) Metrics(s Scene) SelectionMetrics {
+	var m SelectionMetrics
+	if os.IsEmpty() {
+		return m
+	}
+	sel := os.clone()
+	sel.recomputeBounds(s.ObjectCollection)
+	m.Width, m.Height = sel.Bounds.Width, sel.Bounds.Height
+	m.Buildings = len(os.BuildingIdxs)
+	for _, idx := range os.BuildingIdxs {
+		dims := s.Buildings[idx].Def().Dims
+		m.BuildingsArea += dims.X * dims.Y
+	}
+	m.Paths = len(os.PathIdxs)
+	for _, elt := range os.PathIdxs {
+		p := s.Paths[elt.Idx]
+		m.PathsLength += p.Start.Distance(p.End)
+	}
+	m.TextBoxes = len(os.TextBoxIdxs)
+	return m
+}
+
 // FullPathIdxs returns the indices of the path with both start and end selected
 func (os ObjectSelection) FullPathIdxs() []int {
 	idxs := make([]int, 0, len(os.PathIdxs))
@@ -325,6 +538,108 @@ func (p PathSel) String() string {
 	}
 }
 
+// Encode returns a compact text representation of the selected indices, see [DecodeSelection]
+//
+// The format is a ";" separated list of "b:", "p:" and "t:" sections (buildings, paths and text
+// boxes) of "," separated indices, empty sections are omitted. Path indices are suffixed with "s"
+// when only the start is selected, "e" when only the end is selected and "n" when neither is,
+// e.g. "b:0,3;p:1,2s;t:4". Bounds are not encoded.
+func (os ObjectSelection) Encode() string {
+	var sections []string
+	encodeIdxs := func(prefix string, idxs []int) {
+		if len(idxs) > 0 {
+			strs := make([]string, len(idxs))
+			for i, idx := range idxs {
+				strs[i] = strconv.Itoa(idx)
+			}
+			sections = append(sections, prefix+strings.Join(strs, ","))
+		}
+	}
+	encodeIdxs("b:", os.BuildingIdxs)
+	if len(os.PathIdxs) > 0 {
+		strs := make([]string, len(os.PathIdxs))
+		for i, ps := range os.PathIdxs {
+			strs[i] = strconv.Itoa(ps.Idx)
+			switch {
+			case ps.Start && ps.End:
+			case ps.Start:
+				strs[i] += "s"
+			case ps.End:
+				strs[i] += "e"
+			default:
+				strs[i] += "n"
+			}
+		}
+		sections = append(sections, "p:"+strings.Join(strs, ","))
+	}
+	encodeIdxs("t:", os.TextBoxIdxs)
+	return strings.Join(sections, ";")
+}
+
+// DecodeSelection parses a selection encoded with [ObjectSelection.Encode]
+//
+// Indices are kept in order and are not checked against any collection, the bounds are left empty.
+func DecodeSelection(str string) (ObjectSelection, error) {
+	var sel ObjectSelection
+	if str == "" {
+		return sel, nil
+	}
+	parseIdx := func(s string) (int, error) {
+		idx, err := strconv.Atoi(s)
+		if err != nil || idx < 0 {
+			return 0, fmt.Errorf("invalid index: %q", s)
+		}
+		return idx, nil
+	}
+	seen := map[string]bool{}
+	for _, section := range strings.Split(str, ";") {
+		prefix, list, ok := strings.Cut(section, ":")
+		if !ok || list == "" {
+			return ObjectSelection{}, fmt.Errorf("invalid section: %q", section)
+		}
+		if seen[prefix] {
+			return ObjectSelection{}, fmt.Errorf("duplicate section: %q", prefix)
+		}
+		seen[prefix] = true
+		for _, item := range strings.Split(list, ",") {
+			switch prefix {
+			case "b", "t":
+				idx, err := parseIdx(item)
+				if err != nil {
+					return ObjectSelection{}, err
+				}
+				if prefix == "b" {
+					sel.BuildingIdxs = append(sel.BuildingIdxs, idx)
+				} else {
+					sel.TextBoxIdxs = append(sel.TextBoxIdxs, idx)
+				}
+			case "p":
+				ps := PathSel{Start: true, End: true}
+				switch {
+				case strings.HasSuffix(item, "s"):
+					ps.End = false
+				case strings.HasSuffix(item, "e"):
+					ps.Start = false
+				case strings.HasSuffix(item, "n"):
+					ps.Start, ps.End = false, false
+				}
+				if !ps.Start || !ps.End {
+					item = item[:len(item)-1]
+				}
+				idx, err := parseIdx(item)
+				if err != nil {
+					return ObjectSelection{}, err
+				}
+				ps.Idx = idx
+				sel.PathIdxs = append(sel.PathIdxs, ps)
+			default:
+				return ObjectSelection{}, fmt.Errorf("invalid section: %q", section)
+			}
+		}
+	}
+	return sel, nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // Iterators
 ////////////////////////////////////////////////////////////////////////////////////////////////////
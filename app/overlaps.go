@@ -0,0 +1,138 @@
+// overlaps - Incrementally maintained set of overlapping buildings (highlighting)
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// highlightOverlaps draws the overlapping buildings as invalid, see [Scene.OverlappingSet]
+var highlightOverlaps bool
+
+// OverlappingSet returns the set of the indices of the buildings overlapping another building
+//
+// It is cached, and updated incrementally after scene operations: only the buildings changed by the
+// operations and those near them are checked again. The returned map must not be modified.
+func (s *Scene) OverlappingSet() map[int]bool {
+	s.updateOverlaps()
+	return s.overlapping
+}
+
+// overlapChange is the state of a building changed since [Scene.overlapping] was computed
+type overlapChange struct {
+	// bounds of the building when [Scene.overlapping] was computed
+	old rl.Rectangle
+	// whether the building existed when [Scene.overlapping] was computed
+	existed bool
+}
+
+// trackOverlaps records the buildings the operation is about to change in [Scene.overlapChanges]
+//
+// Group operations are tracked by their sub-operations.
+func (op sceneOp) trackOverlaps(s *Scene, undo bool) {
+	if !s.overlapsIncremental {
+		return
+	}
+	n := len(s.Buildings)
+	var changed []int
+	switch {
+	case op.Type == SceneOpAdd && !undo:
+		changed = Range(n, n+len(op.New.Buildings))
+	case op.Type == SceneOpAdd && undo:
+		changed = Range(n-len(op.New.Buildings), n)
+	case op.Type == SceneOpDelete && !undo:
+		// deleted buildings are replaced by the last ones
+		changed = append(Range(n-len(op.Sel.BuildingIdxs), n), op.Sel.BuildingIdxs...)
+	case op.Type == SceneOpDelete && undo:
+		// reinserted buildings move the current ones to the end
+		changed = append(Range(n, n+len(op.Sel.BuildingIdxs)), op.Sel.BuildingIdxs...)
+	case op.Type == SceneOpModify:
+		changed = op.Sel.BuildingIdxs
+	}
+	for _, idx := range changed {
+		if _, ok := s.overlapChanges[idx]; ok {
+			continue
+		}
+		if idx < n {
+			s.overlapChanges[idx] = overlapChange{old: s.Buildings[idx].Bounds(), existed: true}
+		} else {
+			s.overlapChanges[idx] = overlapChange{}
+		}
+	}
+	// the next update is a full one anyway, stop tracking
+	if 8*len(s.overlapChanges) > max(n, len(s.Buildings)) {
+		s.overlapsIncremental = false
+	}
+}
+
+// updateOverlaps updates the overlapping buildings set if needed, see [Scene.OverlappingSet]
+//
+// After scene operations only, the overlap state of the changed buildings (see [sceneOp.trackOverlaps])
+// and of those near their old and new bounds (found with the spatial index) is recomputed, the set is
+// fully recomputed after any other change (see [Scene.invalidateCaches]).
+func (s *Scene) updateOverlaps() {
+	if s.overlapsUpToDate {
+		return
+	}
+	if !s.overlapsIncremental {
+		s.overlapping = map[int]bool{}
+		for _, pair := range overlappingPairs(s.buildingsBounds()) {
+			s.overlapping[pair[0]] = true
+			s.overlapping[pair[1]] = true
+		}
+		log.Trace("scene.updateOverlaps", "mode", "full", "overlapping", len(s.overlapping))
+	} else {
+		s.updateSpatialIndex()
+		// calls fn for the buildings colliding with r other than self, until it returns false
+		colliding := func(r rl.Rectangle, self int, fn func(j int) bool) {
+			near, ok := s.index.buildingsNear(r)
+			if !ok {
+				near = Range(0, len(s.Buildings))
+			}
+			for _, j := range near {
+				if j != self && s.Buildings[j].Bounds().CheckCollisionRec(r) && !fn(j) {
+					return
+				}
+			}
+		}
+		affected := map[int]bool{}
+		for c, change := range s.overlapChanges {
+			if c < len(s.Buildings) {
+				affected[c] = true
+				colliding(s.Buildings[c].Bounds(), c, func(j int) bool { affected[j] = true; return true })
+			} else {
+				delete(s.overlapping, c)
+			}
+			if change.existed {
+				colliding(change.old, c, func(j int) bool { affected[j] = true; return true })
+			}
+		}
+		for a := range affected {
+			overlaps := false
+			colliding(s.Buildings[a].Bounds(), a, func(int) bool { overlaps = true; return false })
+			if overlaps {
+				s.overlapping[a] = true
+			} else {
+				delete(s.overlapping, a)
+			}
+		}
+		log.Trace("scene.updateOverlaps", "mode", "incremental", "changed", len(s.overlapChanges),
+			"affected", len(affected), "overlapping", len(s.overlapping))
+	}
+	s.overlapChanges = map[int]overlapChange{}
+	s.overlapsIncremental = true
+	s.overlapsUpToDate = true
+}
+
+// drawOverlaps draws the cached overlapping buildings as invalid
+func (s Scene) drawOverlaps() {
+	if !s.IsLayerVisible(LayerBuildings) {
+		return
+	}
+	for idx := range s.overlapping {
+		if idx < len(s.Buildings) && !s.Buildings[idx].Hidden {
+			s.Buildings[idx].Draw(DrawInvalid)
+		}
+	}
+}
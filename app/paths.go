@@ -16,6 +16,8 @@ import (
 type Path struct {
 	DefIdx     int
 	Start, End rl.Vector2
+	// Hidden paths are not drawn and cannot be hovered or selected (see [Scene.Hide])
+	Hidden bool
 }
 
 func (p Path) String() string {
@@ -27,8 +29,44 @@ func (p Path) String() string {
 
 func (p Path) Def() PathDef { return pathDefs[p.DefIdx] }
 
+// PathGradient is the option to draw paths with a color gradient from start to end, showing their
+// direction (directional arrows are still drawn on top)
+type PathGradient struct {
+	Enabled bool
+	// Colors at the path start and end
+	StartColor, EndColor rl.Color
+}
+
+var pathGradient = PathGradient{StartColor: colors.Blue500, EndColor: colors.Orange500}
+
+// maximum number of segments a gradient path body is drawn with
+const pathGradientMaxSegments = 16
+
+// colorAt returns the path color at t (0 at start, 1 at end), accounting for [pathGradient]
+func (p Path) colorAt(state DrawState, t float32) rl.Color {
+	if !pathGradient.Enabled || state == DrawShadow {
+		return state.transformColor(p.Def().Color)
+	}
+	return state.transformColor(colors.Lerp(pathGradient.StartColor, pathGradient.EndColor, t))
+}
+
+// drawLine draws the path body line, in [pathGradient] segments if enabled
+func (p Path) drawLine(state DrawState) {
+	width := p.Def().Width
+	if !pathGradient.Enabled || state == DrawShadow {
+		rl.DrawLineEx(p.Start, p.End, width, p.colorAt(state, 0))
+		return
+	}
+	n := min(max(int(p.Start.Distance(p.End)), 1), pathGradientMaxSegments)
+	for i := range n {
+		a := p.Start.Lerp(p.End, float32(i)/float32(n))
+		b := p.Start.Lerp(p.End, float32(i+1)/float32(n))
+		rl.DrawLineEx(a, b, width, p.colorAt(state, (float32(i)+0.5)/float32(n)))
+	}
+}
+
 func (p Path) DrawStart(state DrawState) {
-	if state == DrawSkip {
+	if state == DrawSkip || p.Hidden {
 		return
 	}
 	def := p.Def()
@@ -36,13 +74,12 @@ func (p Path) DrawStart(state DrawState) {
 		// skip drawing if path start is outside of the scene
 		return
 	}
-	color := state.transformColor(def.Color)
 	// Path start
-	rl.DrawCircleV(p.Start, def.Width/2, color)
+	rl.DrawCircleV(p.Start, def.Width/2, p.colorAt(state, 0))
 }
 
 func (p Path) DrawEnd(state DrawState) {
-	if state == DrawSkip {
+	if state == DrawSkip || p.Hidden {
 		return
 	}
 	def := p.Def()
@@ -50,17 +87,15 @@ func (p Path) DrawEnd(state DrawState) {
 		// skip drawing if path end is outside of the scene
 		return
 	}
-	color := state.transformColor(def.Color)
 	// Path end
-	rl.DrawCircleV(p.End, def.Width/2, color)
+	rl.DrawCircleV(p.End, def.Width/2, p.colorAt(state, 1))
 }
 
 func (p Path) DrawBody(state DrawState) {
-	if state == DrawSkip {
+	if state == DrawSkip || p.Hidden {
 		return
 	}
 	def := p.Def()
-	color := state.transformColor(def.Color)
 
 	if !CheckCollisionRecLine(dims.ExWorld, p.Start, p.End) {
 		// skip drawing if building is outside of the scene
@@ -70,13 +105,13 @@ func (p Path) DrawBody(state DrawState) {
 	app.drawCounts.Paths++
 
 	// Path body
-	rl.DrawLineEx(p.Start, p.End, def.Width, color)
+	p.drawLine(state)
 
 	if !def.IsDirectional || state == DrawShadow {
 		return
 	}
 	// Draw directional arrows
-	color = state.transformColor(colors.Gray300)
+	color := state.transformColor(colors.Gray300)
 	length := p.Start.Distance(p.End)
 	angle := -p.Start.LineAngle(p.End)
 	mat := matrix.NewTranslateV(p.Start).RotateRad(angle)
@@ -91,11 +126,10 @@ func (p Path) DrawBody(state DrawState) {
 }
 
 func (p Path) Draw(state DrawState) {
-	if state == DrawSkip {
+	if state == DrawSkip || p.Hidden {
 		return
 	}
 	def := p.Def()
-	color := state.transformColor(def.Color)
 
 	if !CheckCollisionRecLine(dims.ExWorld, p.Start, p.End) {
 		// skip drawing if building is outside of the scene
@@ -105,20 +139,20 @@ func (p Path) Draw(state DrawState) {
 
 	// Path start
 	// FIXME: DrawCircle is very expensive, use shader instead
-	rl.DrawCircleV(p.Start, def.Width/2, color)
+	rl.DrawCircleV(p.Start, def.Width/2, p.colorAt(state, 0))
 	if p.Start.Equals(p.End) {
 		return
 	}
 	// Path body
-	rl.DrawLineEx(p.Start, p.End, def.Width, color)
+	p.drawLine(state)
 	// Path end
-	rl.DrawCircleV(p.End, def.Width/2, color)
+	rl.DrawCircleV(p.End, def.Width/2, p.colorAt(state, 1))
 
 	if !def.IsDirectional || state == DrawShadow {
 		return
 	}
 	// Draw directional arrows
-	color = state.transformColor(colors.Gray300)
+	color := state.transformColor(colors.Gray300)
 	length := p.Start.Distance(p.End)
 	angle := -p.Start.LineAngle(p.End)
 	mat := matrix.NewTranslateV(p.Start).RotateRad(angle)
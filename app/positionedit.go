@@ -0,0 +1,194 @@
+// positionedit - Numeric position entry of the selected building or path (details bar)
+
+package app
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/text"
+	"github.com/gen2brain/raylib-go/raygui"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// PositionFields returns the names and values of the position fields of a building (X, Y and
+// rotation) or a path (start and end X, Y), and false for other objects.
+func (s Scene) PositionFields(obj Object) ([]string, []float32, bool) {
+	switch {
+	case obj.Type == TypeBuilding && obj.Idx >= 0 && obj.Idx < len(s.Buildings):
+		b := s.Buildings[obj.Idx]
+		return []string{"X", "Y", "Rotation"}, []float32{b.Pos.X, b.Pos.Y, float32(b.Rot)}, true
+	case obj.Type == TypePath && obj.Idx >= 0 && obj.Idx < len(s.Paths):
+		p := s.Paths[obj.Idx]
+		return []string{"Start X", "Start Y", "End X", "End Y"}, []float32{p.Start.X, p.Start.Y, p.End.X, p.End.Y}, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// SetPosition sets the position fields of a building or a path (see [Scene.PositionFields]) as a
+// single modify operation, and returns false if values are invalid: non-finite, wrong count or a
+// rotation which is not a multiple of 90.
+//
+// Rotations are normalized in [0, 360). No validity checks is performed, as with dragging.
+func (s *Scene) SetPosition(obj Object, values []float32) bool {
+	names, _, ok := s.PositionFields(obj)
+	if !ok || len(values) != len(names) || !AllFinite(values...) {
+		log.Warn("cannot set position", "reason", "invalid values", "obj", obj, "values", values)
+		return false
+	}
+	var sel ObjectSelection
+	var col ObjectCollection
+	switch obj.Type {
+	case TypeBuilding:
+		rot := int32(values[2])
+		if float32(rot) != values[2] || rot%90 != 0 {
+			log.Warn("cannot set position", "reason", "rotation not a multiple of 90", "rot", values[2])
+			return false
+		}
+		b := s.Buildings[obj.Idx]
+		b.Pos, b.Rot = vec2(values[0], values[1]), (rot%360+360)%360
+		sel.BuildingIdxs, col.Buildings = []int{obj.Idx}, []Building{b}
+	case TypePath:
+		p := s.Paths[obj.Idx]
+		p.Start, p.End = vec2(values[0], values[1]), vec2(values[2], values[3])
+		sel.PathIdxs, col.Paths = []PathSel{{Idx: obj.Idx, Start: true, End: true}}, []Path{p}
+	}
+	log.Debug("scene.SetPosition", "obj", obj, "values", values)
+	s.ModifyObjects(sel, col)
+	return true
+}
+
+// formatField formats a position field value in the shortest exact decimal representation
+func formatField(v float32) string { return strconv.FormatFloat(float64(v), 'f', -1, 32) }
+
+// guiPositionEditor holds the position fields of the single selected building or path, see
+// [Scene.PositionFields]
+//
+// Fields are prefilled with the current values, Enter commits the typed values as a single modify
+// operation, Escape restores the current values, Tab focuses the next field.
+type guiPositionEditor struct {
+	// edited object
+	obj Object
+	// scene revision the fields were filled at
+	revision int
+	// fields names and text areas
+	names  []string
+	fields []text.Area
+}
+
+// editedObject returns the single selected building or path (with both ends or only one), and false
+// if the selection is anything else or is being transformed
+func editedObject() (Object, bool) {
+	if app.Mode != ModeSelection || selection.mode != SelectionNormal || len(selection.TextBoxIdxs) > 0 {
+		return Object{}, false
+	}
+	switch {
+	case len(selection.BuildingIdxs) == 1 && len(selection.PathIdxs) == 0:
+		return Object{Type: TypeBuilding, Idx: selection.BuildingIdxs[0]}, true
+	case len(selection.PathIdxs) == 1 && len(selection.BuildingIdxs) == 0:
+		return Object{Type: TypePath, Idx: selection.PathIdxs[0].Idx}, true
+	default:
+		return Object{}, false
+	}
+}
+
+func (pe *guiPositionEditor) reset() {
+	pe.obj = Object{}
+	pe.names = nil
+	pe.fields = nil
+}
+
+// focused returns true if a field is focused
+func (pe *guiPositionEditor) focused() bool {
+	for i := range pe.fields {
+		if pe.fields[i].Focused() {
+			return true
+		}
+	}
+	return false
+}
+
+// fill sets the fields to the current values of obj
+func (pe *guiPositionEditor) fill(obj Object) {
+	names, values, _ := scene.PositionFields(obj)
+	pe.obj, pe.revision, pe.names = obj, scene.revision, names
+	pe.fields = pe.fields[:0]
+	for _, v := range values {
+		pe.fields = append(pe.fields, text.NewArea(rl.Rectangle{}, formatField(v), textAreaOpts()))
+	}
+}
+
+// doCommit sets the typed values, and reselects the object so that the selection bounds follow
+func (pe *guiPositionEditor) doCommit() Action {
+	values := make([]float32, len(pe.fields))
+	for i := range pe.fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(pe.fields[i].Text()), 32)
+		if err != nil {
+			log.Warn("cannot set position", "reason", "invalid number", "field", pe.names[i], "text", pe.fields[i].Text())
+			return nil
+		}
+		values[i] = float32(v)
+	}
+	for i := range pe.fields {
+		pe.fields[i].SetFocused(false)
+	}
+	if !scene.SetPosition(pe.obj, values) {
+		return nil
+	}
+	sel := selection.ObjectSelection.clone()
+	sel.recomputeBounds(scene.ObjectCollection)
+	return selection.doInitSelection(sel)
+}
+
+// updateAndDraw draws the fields of obj in bar, and returns the action committing them if any
+func (pe *guiPositionEditor) updateAndDraw(bar rl.Rectangle, obj Object) Action {
+	// refill on selection change, or on scene change when not typing
+	if obj != pe.obj || (scene.revision != pe.revision && !pe.focused()) {
+		pe.fill(obj)
+	}
+
+	key := keyboard.Pressed
+	var action Action
+	if pe.focused() {
+		switch key {
+		case rl.KeyEnter, rl.KeyKpEnter:
+			action = pe.doCommit()
+			key = 0
+		case rl.KeyEscape:
+			log.Debug("positionEditor", "action", "cancel")
+			pe.fill(obj)
+			key = 0
+		case rl.KeyTab:
+			for i := range pe.fields {
+				if pe.fields[i].Focused() {
+					pe.fields[i].SetFocused(false)
+					pe.fields[(i+1)%len(pe.fields)].SetFocused(true)
+					break
+				}
+			}
+			key = 0
+		}
+	}
+
+	titleBounds := bar
+	titleBounds.Height = 30
+	text.DrawText(titleBounds, "Position", text.Options{Font: font, Size: 24, Color: colors.Gray700})
+	raygui.SetStyle(raygui.DEFAULT, raygui.TEXT_SIZE, 24)
+
+	const labelWidth, rowHeight = 110, 40
+	y := bar.Y + 40
+	for i := range pe.fields {
+		labelBounds := rl.NewRectangle(bar.X, y, labelWidth, 30)
+		text.DrawText(labelBounds, pe.names[i], text.Options{Font: font, Size: 20, Color: colors.Gray700})
+		pe.fields[i].SetBounds(rl.NewRectangle(bar.X+labelWidth, y, bar.Width-labelWidth, 30))
+		pe.fields[i].Draw(key)
+		y += rowHeight
+	}
+	if raygui.Button(rl.NewRectangle(bar.X, y+10, bar.Width, 30), "Apply (Enter)") {
+		action = pe.doCommit()
+	}
+	return action
+}
@@ -0,0 +1,74 @@
+// reference - Read-only reference layout drawn faded behind the scene (tracing)
+
+package app
+
+import (
+	"os"
+
+	"github.com/bonoboris/satisfied/log"
+	tfd "github.com/bonoboris/satisfied/tinyfiledialogs"
+)
+
+// reference holds the objects of the reference layout, see [SetReferenceScene]
+var reference ObjectCollection
+
+// SetReferenceScene sets the reference layout drawn faded behind the scene, replacing the previous one
+//
+// The reference objects are only drawn: they are not part of the scene, so they are never hovered,
+// selected, validated, counted or saved, and they are kept when another project is opened.
+func SetReferenceScene(col ObjectCollection) {
+	reference = col.clone()
+	log.Info("set reference scene", "buildings", len(col.Buildings), "paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+}
+
+// ClearReferenceScene removes the reference layout, see [SetReferenceScene]
+func ClearReferenceScene() {
+	reference = ObjectCollection{}
+	log.Info("cleared reference scene")
+}
+
+// HasReferenceScene returns true if a reference layout is set, see [SetReferenceScene]
+func HasReferenceScene() bool { return !reference.IsEmpty() }
+
+// drawReference draws the reference layout faded
+func drawReference() {
+	for _, tb := range reference.TextBoxes {
+		if tb.Background {
+			tb.Draw(DrawFaded, false)
+		}
+	}
+	for _, p := range reference.Paths {
+		p.Draw(DrawFaded)
+	}
+	for _, b := range reference.Buildings {
+		b.Draw(DrawFaded)
+	}
+	for _, tb := range reference.TextBoxes {
+		if !tb.Background {
+			tb.Draw(DrawFaded, false)
+		}
+	}
+}
+
+// doOpenReference prompts for a project file and sets it as the reference layout
+func (a *App) doOpenReference() Action {
+	log.Info("open reference")
+	filepath, ok := tfd.OpenFileDialog("Open reference project", "", []string{extFilter}, extFilterDesc)
+	if !ok {
+		log.Debug("open reference", "action", "cancel")
+		return nil
+	}
+	file, err := os.Open(filepath)
+	if err != nil {
+		log.Error("cannot open file", "path", filepath, "err", err)
+		return nil
+	}
+	defer file.Close()
+	var ref Scene
+	if _, err := ref.LoadFromTextMode(file, DecodeLenient); err != nil {
+		log.Error("error parsing reference project", "path", filepath, "err", err)
+		return nil
+	}
+	SetReferenceScene(ref.ObjectCollection)
+	return nil
+}
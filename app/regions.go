@@ -0,0 +1,102 @@
+// regions - Optional polygons (plots) buildings are restricted to
+
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// tagRegions is the metadata tag of the scene regions, see [Scene.Regions]
+//
+// Its value is a ';' separated list of polygons, each a space separated list of vertices coordinates
+// "x1 y1 x2 y2 ...". It is stored with the unknown metadata so older versions preserve it.
+const tagRegions = "#REGIONS"
+
+// Regions returns the scene region polygons (world coordinates), nil if none is defined
+//
+// Invalid polygons are skipped with a warning.
+func (s Scene) Regions() [][]rl.Vector2 {
+	value, ok := s.metadata[tagRegions]
+	if !ok {
+		return nil
+	}
+	var regions [][]rl.Vector2
+	for i, poly := range strings.Split(value, ";") {
+		fields := strings.Fields(poly)
+		if len(fields) < 6 || len(fields)%2 != 0 {
+			log.Warn("invalid region", "idx", i, "reason", "expected at least 3 vertices", "value", poly)
+			continue
+		}
+		vertices := make([]rl.Vector2, 0, len(fields)/2)
+		for k := 0; k < len(fields); k += 2 {
+			x, errX := ParseFloat32(fields[k])
+			y, errY := ParseFloat32(fields[k+1])
+			if errX != nil || errY != nil || !AllFinite(x, y) {
+				log.Warn("invalid region", "idx", i, "reason", "invalid coordinates", "value", poly)
+				vertices = nil
+				break
+			}
+			vertices = append(vertices, vec2(x, y))
+		}
+		if vertices != nil {
+			regions = append(regions, vertices)
+		}
+	}
+	return regions
+}
+
+// SetRegions sets the scene region polygons, nil or empty removes them
+//
+// Regions are saved with the scene metadata, changing them is not recorded in history but marks the
+// scene as modified.
+func (s *Scene) SetRegions(regions [][]rl.Vector2) {
+	polys := make([]string, len(regions))
+	for i, poly := range regions {
+		coords := make([]string, 0, 2*len(poly))
+		for _, v := range poly {
+			coords = append(coords, fmt.Sprint(v.X), fmt.Sprint(v.Y))
+		}
+		polys[i] = strings.Join(coords, " ")
+	}
+	value := strings.Join(polys, ";")
+	if old, ok := s.metadata[tagRegions]; value == old && ok == (len(regions) > 0) {
+		return
+	}
+	if len(regions) == 0 {
+		delete(s.metadata, tagRegions)
+	} else {
+		if s.metadata == nil {
+			s.metadata = map[string]string{}
+		}
+		s.metadata[tagRegions] = value
+	}
+	s.savedHistoryPos = -1 // not reachable through history
+}
+
+// BuildingsOutsideRegions returns the indices of the buildings whose center is not inside any region,
+// nothing if no region is defined (see [Scene.Regions]).
+func (s Scene) BuildingsOutsideRegions() []int {
+	regions := s.Regions()
+	if len(regions) == 0 {
+		return nil
+	}
+	var idxs []int
+	for i, b := range s.Buildings {
+		center := b.Bounds().Center()
+		inside := false
+		for _, poly := range regions {
+			if PointInPolygon(center, poly) {
+				inside = true
+				break
+			}
+		}
+		if !inside {
+			idxs = append(idxs, i)
+		}
+	}
+	return idxs
+}
@@ -0,0 +1,226 @@
+// routing - Auto-routing of orthogonal paths around buildings (A* on a grid)
+
+package app
+
+import (
+	"container/heap"
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// margin around the route ends bounding box the search is limited to (in grid steps)
+	autoRouteMargin = 32
+	// maximum number of grid points of a search, routes needing more fail
+	autoRouteMaxPoints = 1 << 20
+	// cost of a turn (in grid steps), so that routes with fewer bends are preferred
+	autoRouteTurnCost = 2
+)
+
+// autoRouteStep is the [Scene.AutoRoutePath] grid resolution (world units), see [SetAutoRouteStep]
+var autoRouteStep float32 = 1
+
+// SetAutoRouteStep sets the grid resolution of [Scene.AutoRoutePath], and returns false (leaving it
+// unchanged) if step is not strictly positive and finite.
+func SetAutoRouteStep(step float32) bool {
+	if !AllFinite(step) || step <= 0 {
+		log.Warn("cannot set auto-route step", "reason", "invalid step", "step", step)
+		return false
+	}
+	autoRouteStep = step
+	return true
+}
+
+// routeDirs are the grid moves of [Scene.AutoRoutePath] (right, down, left, up)
+var routeDirs = [4][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+
+// AutoRoutePath returns the waypoints (start and end included) of an orthogonal route from start to
+// end avoiding the buildings grown by clearance, and false if there is none.
+//
+// The route follows a grid of [SetAutoRouteStep] resolution aligned on start, restricted to the
+// ends bounding box grown by [autoRouteMargin] steps: the shortest route with the fewest turns is
+// found with A*. When end is not on the grid, the route goes to the nearest grid point then to end
+// with at most one more turn. Buildings containing an end within their clearance are only avoided by
+// their exact bounds (ends are usually on a building edge), buildings containing an end are ignored.
+//
+// Waypoints can be added as paths with [Scene.AddRoute].
+func (s *Scene) AutoRoutePath(start, end rl.Vector2, clearance float32) ([]rl.Vector2, bool) {
+	if !AllFinite(start.X, start.Y, end.X, end.Y, clearance) || clearance < 0 {
+		log.Warn("cannot auto-route path", "reason", "invalid arguments", "start", start, "end", end, "clearance", clearance)
+		return nil, false
+	}
+	if start.Equals(end) {
+		log.Warn("cannot auto-route path", "reason", "zero-length path", "start", start)
+		return nil, false
+	}
+	step := autoRouteStep
+	// grid coordinates (relative to start) of the nearest point to end
+	goalX, goalY := int(math32.Round((end.X-start.X)/step)), int(math32.Round((end.Y-start.Y)/step))
+	minX, minY := min(0, goalX)-autoRouteMargin, min(0, goalY)-autoRouteMargin
+	nx, ny := max(0, goalX)+autoRouteMargin-minX+1, max(0, goalY)+autoRouteMargin-minY+1
+	if nx*ny > autoRouteMaxPoints {
+		log.Warn("cannot auto-route path", "reason", "route too long for the grid step", "step", step)
+		return nil, false
+	}
+	pointAt := func(x, y int) rl.Vector2 { return start.Add(vec2(float32(x)*step, float32(y)*step)) }
+
+	// obstacles in the search area
+	area := rl.NewRectangleCorners(pointAt(minX, minY), pointAt(minX+nx-1, minY+ny-1))
+	s.updateSpatialIndex()
+	near, ok := s.index.buildingsNear(GrowRect(area, clearance))
+	if !ok {
+		near = Range(0, len(s.Buildings))
+	}
+	slices.Sort(near)
+	near = slices.Compact(near)
+	var obstacles []rl.Rectangle
+	for _, i := range near {
+		exact := s.Buildings[i].Bounds()
+		grown := GrowRect(exact, clearance)
+		switch {
+		case rectContainsStrict(exact, start) || rectContainsStrict(exact, end):
+			continue
+		case rectContainsStrict(grown, start) || rectContainsStrict(grown, end):
+			obstacles = append(obstacles, exact)
+		default:
+			obstacles = append(obstacles, grown)
+		}
+	}
+	blocked := func(a, b rl.Vector2) bool {
+		for _, r := range obstacles {
+			if segmentCrossesRect(a, b, r) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// A* over (grid point, incoming direction) states, direction 4 for the start
+	const noDir = 4
+	state := func(x, y, dir int) int { return ((y-minY)*nx+(x-minX))*(noDir+1) + dir }
+	stateXY := func(st int) (x, y, dir int) {
+		p := st / (noDir + 1)
+		return p%nx + minX, p/nx + minY, st % (noDir + 1)
+	}
+	heuristic := func(x, y int) float32 {
+		return math32.Abs(float32(goalX-x)) + math32.Abs(float32(goalY-y))
+	}
+	cost := map[int]float32{state(0, 0, noDir): 0}
+	prev := map[int]int{}
+	queue := &nodeQueue{{node: state(0, 0, noDir), dist: heuristic(0, 0)}}
+	goal := -1
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(nodeQueueItem)
+		x, y, dir := stateXY(item.node)
+		g := cost[item.node]
+		if item.dist > g+heuristic(x, y) {
+			continue // outdated entry
+		}
+		if x == goalX && y == goalY {
+			goal = item.node
+			break
+		}
+		for d, move := range routeDirs {
+			if dir != noDir && d == (dir+2)%4 {
+				continue // no going back
+			}
+			nxX, nxY := x+move[0], y+move[1]
+			if nxX < minX || nxX >= minX+nx || nxY < minY || nxY >= minY+ny || blocked(pointAt(x, y), pointAt(nxX, nxY)) {
+				continue
+			}
+			c := g + 1
+			if dir != noDir && d != dir {
+				c += autoRouteTurnCost
+			}
+			next := state(nxX, nxY, d)
+			if old, ok := cost[next]; !ok || c < old {
+				cost[next] = c
+				prev[next] = item.node
+				heap.Push(queue, nodeQueueItem{node: next, dist: c + heuristic(nxX, nxY)})
+			}
+		}
+	}
+	if goal < 0 {
+		log.Debug("scene.AutoRoutePath", "start", start, "end", end, "found", false)
+		return nil, false
+	}
+
+	var points []rl.Vector2
+	for st := goal; ; st = prev[st] {
+		x, y, _ := stateXY(st)
+		points = append(points, pointAt(x, y))
+		if _, ok := prev[st]; !ok {
+			break
+		}
+	}
+	slices.Reverse(points)
+	// off-grid end
+	if last := points[len(points)-1]; !last.Equals(end) {
+		corner := vec2(end.X, last.Y)
+		if blocked(last, corner) || blocked(corner, end) {
+			corner = vec2(last.X, end.Y)
+			if blocked(last, corner) || blocked(corner, end) {
+				log.Debug("scene.AutoRoutePath", "start", start, "end", end, "found", false, "reason", "off-grid end blocked")
+				return nil, false
+			}
+		}
+		points = append(points, corner, end)
+	}
+	points = simplifyRoute(points)
+	log.Debug("scene.AutoRoutePath", "start", start, "end", end, "found", true, "waypoints", len(points))
+	return points, true
+}
+
+// AddRoute adds a path of definition defIdx between each consecutive waypoints (see
+// [Scene.AutoRoutePath]) as a single operation, and returns the selection of the added paths and
+// false if nothing was added.
+func (s *Scene) AddRoute(defIdx int, waypoints []rl.Vector2) (ObjectSelection, bool) {
+	var col ObjectCollection
+	for i := 1; i < len(waypoints); i++ {
+		if p := (Path{DefIdx: defIdx, Start: waypoints[i-1], End: waypoints[i]}); s.IsPathValid(p) {
+			col.Paths = append(col.Paths, p)
+		}
+	}
+	if len(col.Paths) == 0 {
+		log.Warn("cannot add route", "reason", "no path", "waypoints", len(waypoints))
+		return ObjectSelection{}, false
+	}
+	if !s.AddObjects(col) {
+		return ObjectSelection{}, false
+	}
+	return s.tailSelection(col), true
+}
+
+// simplifyRoute removes the duplicate and collinear intermediate waypoints of an orthogonal route
+func simplifyRoute(points []rl.Vector2) []rl.Vector2 {
+	points = slices.CompactFunc(points, rl.Vector2.Equals)
+	out := points[:0:0]
+	for i, p := range points {
+		if i > 0 && i < len(points)-1 {
+			a, b := points[i-1], points[i+1]
+			if a.X == p.X && p.X == b.X || a.Y == p.Y && p.Y == b.Y {
+				continue
+			}
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// rectContainsStrict returns true if p is in the interior of r (not on its edges)
+func rectContainsStrict(r rl.Rectangle, p rl.Vector2) bool {
+	return p.X > r.X && p.X < r.X+r.Width && p.Y > r.Y && p.Y < r.Y+r.Height
+}
+
+// segmentCrossesRect returns true if the axis aligned segment ab intersects the interior of r
+func segmentCrossesRect(a, b rl.Vector2, r rl.Rectangle) bool {
+	x0, x1 := min(a.X, b.X), max(a.X, b.X)
+	y0, y1 := min(a.Y, b.Y), max(a.Y, b.Y)
+	if x0 == x1 {
+		return x0 > r.X && x0 < r.X+r.Width && max(y0, r.Y) < min(y1, r.Y+r.Height)
+	}
+	return y0 > r.Y && y0 < r.Y+r.Height && max(x0, r.X) < min(x1, r.X+r.Width)
+}
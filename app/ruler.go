@@ -0,0 +1,122 @@
+// ruler - Transient measurement tool (distance between two world points)
+
+package app
+
+import (
+	"fmt"
+
+	"github.com/bonoboris/satisfied/colors"
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// size of the ruler readout (px)
+const rulerFontSize = 20.
+
+var ruler = Ruler{}
+
+// Ruler measures the distance between two points, without modifying the scene
+//
+// While active it captures the scene mouse clicks: the first click sets the start point, the end point
+// follows the mouse until the second click fixes it, a third click starts a new measurement.
+// Escape deactivates it.
+type Ruler struct {
+	// whether the ruler is active
+	active bool
+	// whether the start point is set
+	started bool
+	// whether the end point is fixed
+	fixed bool
+	// measured points (world coordinates)
+	start, end rl.Vector2
+}
+
+func (r Ruler) traceState(key, val string) {
+	log.Trace("ruler", key, val, "active", r.active, "started", r.started, "fixed", r.fixed, "start", r.start, "end", r.end)
+}
+
+// Active returns true if the ruler captures the inputs
+func (r Ruler) Active() bool { return r.active }
+
+// Measure returns the distance between the measured points, their X and Y offsets, and false if
+// the start point is not set.
+func (r Ruler) Measure() (dist, dx, dy float32, ok bool) {
+	if !r.active || !r.started {
+		return 0, 0, 0, false
+	}
+	d := r.end.Subtract(r.start)
+	return r.start.Distance(r.end), d.X, d.Y, true
+}
+
+// Update processes inputs while the ruler is active
+func (r *Ruler) Update() {
+	if !r.active {
+		if app.isNormal() && keyboard.Binding() == BindingMeasure {
+			r.doStart()
+		}
+		return
+	}
+	if keyboard.Binding() == BindingEscape || keyboard.Binding() == BindingMeasure {
+		r.doClear()
+		return
+	}
+	if !mouse.InScene {
+		return
+	}
+	pos := grid.Snap(camera.WorldPos(mouse.ScreenPos))
+	if mouse.Left.Pressed {
+		r.doClick(pos)
+	} else {
+		r.doMove(pos)
+	}
+}
+
+// doStart activates the ruler, without points
+func (r *Ruler) doStart() {
+	log.Debug("ruler.doStart")
+	*r = Ruler{active: true}
+}
+
+// doClear deactivates the ruler
+func (r *Ruler) doClear() {
+	log.Debug("ruler.doClear")
+	*r = Ruler{}
+}
+
+// doClick sets the start point, fixes the end point, or starts a new measurement
+func (r *Ruler) doClick(pos rl.Vector2) {
+	if !r.active {
+		return
+	}
+	if !r.started || r.fixed {
+		r.started, r.fixed = true, false
+		r.start, r.end = pos, pos
+	} else {
+		r.end, r.fixed = pos, true
+		dist, dx, dy, _ := r.Measure()
+		log.Info("ruler measure", "start", r.start, "end", r.end, "dist", dist, "dx", dx, "dy", dy)
+	}
+	r.traceState("after", "doClick")
+}
+
+// doMove moves the end point until it is fixed
+func (r *Ruler) doMove(pos rl.Vector2) {
+	if r.active && r.started && !r.fixed {
+		r.end = pos
+	}
+}
+
+// Draw draws the measured line and the readout (in world coordinates)
+func (r Ruler) Draw() {
+	dist, dx, dy, ok := r.Measure()
+	if !ok {
+		return
+	}
+	zoom := camera.Zoom()
+	rl.DrawLineEx(r.start, r.end, 2/zoom, colors.Red500)
+	rl.DrawCircleV(r.start, 3/zoom, colors.Red500)
+	rl.DrawCircleV(r.end, 3/zoom, colors.Red500)
+	label := fmt.Sprintf("%.2f (dx %.2f, dy %.2f)", dist, dx, dy)
+	pos := r.end.Add(vec2(8/zoom, 8/zoom))
+	rl.DrawTextEx(font, label, pos, rulerFontSize/zoom, 0, colors.Black)
+}
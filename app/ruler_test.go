@@ -0,0 +1,36 @@
+package app
+
+import "testing"
+
+// TestRuler checks the ruler points sequence and measure
+func TestRuler(t *testing.T) {
+	t.Cleanup(func() { ruler = Ruler{} })
+	if _, _, _, ok := ruler.Measure(); ok {
+		t.Fatal("inactive ruler measures")
+	}
+	ruler.doStart()
+	ruler.doMove(vec2(1, 1))
+	if _, _, _, ok := ruler.Measure(); ok {
+		t.Fatal("ruler without start point measures")
+	}
+
+	ruler.doClick(vec2(1, 2))
+	ruler.doMove(vec2(4, 6))
+	if dist, dx, dy, ok := ruler.Measure(); !ok || dist != 5 || dx != 3 || dy != 4 {
+		t.Errorf("live measure %v %v %v %v, want 5 3 4 true", dist, dx, dy, ok)
+	}
+	ruler.doClick(vec2(1, -1))
+	ruler.doMove(vec2(100, 100)) // end point is fixed
+	if dist, dx, dy, ok := ruler.Measure(); !ok || dist != 3 || dx != 0 || dy != -3 {
+		t.Errorf("fixed measure %v %v %v %v, want 3 0 -3 true", dist, dx, dy, ok)
+	}
+	ruler.doClick(vec2(10, 10))
+	if dist, _, _, ok := ruler.Measure(); !ok || dist != 0 {
+		t.Errorf("new measure %v %v, want 0 true", dist, ok)
+	}
+
+	ruler.doClear()
+	if _, _, _, ok := ruler.Measure(); ok || ruler.Active() {
+		t.Error("cleared ruler measures")
+	}
+}
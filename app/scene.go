@@ -2,12 +2,19 @@ package app
 
 import (
 	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
 	"fmt"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	"github.com/bonoboris/satisfied/matrix"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -23,14 +30,56 @@ type Scene struct {
 	//   - history[:historyPos] all have been done
 	//   - history[historyPos:] all have been undone (if existing)
 	historyPos int
+	// Nesting depth of the undo group in progress, 0 if none (see [Scene.BeginUndoGroup])
+	groupDepth int
+	// Operations done in the undo group in progress
+	group []sceneOp
+	// Maximum number of operations kept in history, 0 for no limit (see [Scene.SetMaxHistory])
+	maxHistory int
 
 	// History position the last time the scene was saved
 	savedHistoryPos int
+	// Unknown metadata entries (tag -> value) loaded from file, preserved on save
+	metadata map[string]string
+	// Save file format version the scene was last loaded from or saved to (see [Scene.FormatVersion])
+	formatVersion int
+	// Last rigid transformation performed, reset by any other operation (see [Scene.RepeatLastTransform])
+	lastTransform *lastTransform
+	// revision is incremented on every change to the scene objects (see [Scene.invalidateCaches])
+	revision int
+	// Layers not drawn nor hit-tested (see [Scene.SetLayerVisible])
+	hiddenLayers Layer
+	// Origin of the displayed coordinates, nil for the world origin (see [Scene.SetReferenceOrigin])
+	referenceOrigin *rl.Vector2
+	// Display only transform of the objects, nil if none (see [Scene.Explode])
+	explode *explodeView
 
 	// The scene object currently hovered by the mouse
 	Hovered Object
 	// was in modified state last frame
 	wasModified bool
+
+	// cached path crossings, see [Scene.FindPathCrossings]
+	crossings []PathCrossing
+	// whether [Scene.crossings] is up to date
+	crossingsUpToDate bool
+	// spatial index of the objects, see [Scene.updateSpatialIndex]
+	index spatialIndex
+	// whether [Scene.index] is up to date
+	indexUpToDate bool
+	// cached overlapping buildings, see [Scene.OverlappingSet]
+	overlapping map[int]bool
+	// buildings changed by the operations since [Scene.overlapping] was computed, see [sceneOp.trackOverlaps]
+	overlapChanges map[int]overlapChange
+	// whether the changes since [Scene.overlapping] was computed are all in [Scene.overlapChanges],
+	// it is fully recomputed otherwise
+	overlapsIncremental bool
+	// whether [Scene.overlapping] is up to date
+	overlapsUpToDate bool
+	// cached classes summary, see [Scene.Summary]
+	summary map[string]ClassSummary
+	// whether [Scene.summary] is up to date
+	summaryUpToDate bool
 }
 
 func (s Scene) traceState(key, val string) {
@@ -66,6 +115,7 @@ const (
 	SceneOpAdd    sceneOpType = "add"
 	SceneOpDelete sceneOpType = "delete"
 	SceneOpModify sceneOpType = "modify"
+	SceneOpGroup  sceneOpType = "group"
 )
 
 // sceneOp represents a scene operation
@@ -81,6 +131,81 @@ type sceneOp struct {
 	Old ObjectCollection
 	// New is the objects after the operation (empty for [SceneOpDelete])
 	New ObjectCollection
+	// Delta is the transformation applied by a [SceneOpModify] operation (optional)
+	//
+	// If not nil, Old and New are empty and the operation is replayed by applying Delta (or its
+	// inverse) to the objects in Sel.
+	Delta *sceneOpDelta
+	// Ops is the operations of a [SceneOpGroup], in the order they were done (see [Scene.BeginUndoGroup])
+	Ops []sceneOp
+	// Time is when the operation was first done, unchanged by undo / redo
+	Time time.Time
+}
+
+// sceneOpDelta is a rigid transformation (rotation around a center then translation) of a selection
+type sceneOpDelta struct {
+	// Center of the rotation
+	Center rl.Vector2
+	// Translation, applied after the rotation
+	Translate rl.Vector2
+	// Rotation angle in degrees
+	Rot int32
+}
+
+// matrix returns the transformation matrix of the delta
+func (d sceneOpDelta) matrix() matrix.Matrix {
+	return matrix.NewTranslateV(d.Translate.Add(d.Center)).Rotate(d.Rot).TranslateV(d.Center.Negate())
+}
+
+// inverseMatrix returns the transformation matrix of the inverse delta
+func (d sceneOpDelta) inverseMatrix() matrix.Matrix {
+	return matrix.NewTranslateV(d.Center).Rotate(-d.Rot).TranslateV(d.Center.Add(d.Translate).Negate())
+}
+
+// applyCollection transforms every object of col by the delta
+func (d sceneOpDelta) applyCollection(col *ObjectCollection) {
+	mat := d.matrix()
+	for i := range col.Paths {
+		col.Paths[i].Start = mat.ApplyV(col.Paths[i].Start)
+		col.Paths[i].End = mat.ApplyV(col.Paths[i].End)
+	}
+	for i := range col.Buildings {
+		col.Buildings[i].Pos = mat.ApplyV(col.Buildings[i].Pos)
+		col.Buildings[i].Rot = (col.Buildings[i].Rot + d.Rot%360) % 360
+	}
+	for i := range col.TextBoxes {
+		pos := mat.ApplyV(col.TextBoxes[i].Bounds.Position())
+		col.TextBoxes[i].Bounds.X = pos.X
+		col.TextBoxes[i].Bounds.Y = pos.Y
+	}
+}
+
+// apply transforms the objects in sel by the delta, or by its inverse if inverse is true
+func (d sceneOpDelta) apply(s *Scene, sel ObjectSelection, inverse bool) {
+	mat, rot := d.matrix(), d.Rot%360
+	if inverse {
+		mat, rot = d.inverseMatrix(), 360-rot
+	}
+	for _, elt := range sel.PathIdxs {
+		p := &s.Paths[elt.Idx]
+		if elt.Start {
+			p.Start = mat.ApplyV(p.Start)
+		}
+		if elt.End {
+			p.End = mat.ApplyV(p.End)
+		}
+	}
+	for _, idx := range sel.BuildingIdxs {
+		b := &s.Buildings[idx]
+		b.Pos = mat.ApplyV(b.Pos)
+		b.Rot = (b.Rot + rot) % 360
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		tb := &s.TextBoxes[idx]
+		pos := mat.ApplyV(tb.Bounds.Position())
+		tb.Bounds.X = pos.X
+		tb.Bounds.Y = pos.Y
+	}
 }
 
 func (op sceneOp) traceState() {
@@ -90,16 +215,151 @@ func (op sceneOp) traceState() {
 	case SceneOpDelete:
 		log.Trace("scene.operation", "type", "delete", "Sel", op.Sel, "Old", op.Old)
 	case SceneOpModify:
-		log.Trace("scene.operation", "type", "modify", "Sel", op.Sel, "Old", op.Old, "New", op.New)
+		if op.Delta != nil {
+			log.Trace("scene.operation", "type", "modify", "Sel", op.Sel, "Delta", *op.Delta)
+		} else {
+			log.Trace("scene.operation", "type", "modify", "Sel", op.Sel, "Old", op.Old, "New", op.New)
+		}
+	case SceneOpGroup:
+		log.Trace("scene.operation", "type", "group", "ops", len(op.Ops))
+	default:
+		panic("invalid scene operation type")
+	}
+}
+
+// AffectedBounds returns the union of the bounds of the objects touched by the operation, before
+// and after it, and false if it touches none.
+//
+// s must be the scene right after the operation if done, right before it otherwise. It is only used
+// for transformations (see [sceneOp.Delta]) whose objects are not stored, which are approximated
+// within groups.
+func (op sceneOp) AffectedBounds(s Scene, done bool) (rl.Rectangle, bool) {
+	var rects []rl.Rectangle
+	op.collectBounds(s, done, &rects)
+	if len(rects) == 0 {
+		return rl.Rectangle{}, false
+	}
+	tl, br := rects[0].TopLeft(), rects[0].BottomRight()
+	for _, r := range rects[1:] {
+		tl = vec2(min(tl.X, r.X), min(tl.Y, r.Y))
+		br = vec2(max(br.X, r.X+r.Width), max(br.Y, r.Y+r.Height))
+	}
+	return rl.NewRectangleCorners(tl, br), true
+}
+
+// collectBounds appends the bounds of the objects touched by the operation to rects, see [sceneOp.AffectedBounds]
+func (op sceneOp) collectBounds(s Scene, done bool, rects *[]rl.Rectangle) {
+	addCol := func(col ObjectCollection) {
+		if !col.IsEmpty() {
+			*rects = append(*rects, col.SelectAll().Bounds)
+		}
+	}
+	switch op.Type {
+	case SceneOpAdd:
+		addCol(op.New)
+	case SceneOpDelete:
+		addCol(op.Old)
+	case SceneOpModify:
+		if op.Delta == nil {
+			addCol(op.Old)
+			addCol(op.New)
+			return
+		}
+		sel := op.Sel.clone()
+		sel.keepValidIdxs(s.ObjectCollection)
+		if sel.IsEmpty() {
+			return
+		}
+		// the objects of s are in one state, the delta gives the other one
+		mat := op.Delta.matrix()
+		if done {
+			mat = op.Delta.inverseMatrix()
+		}
+		*rects = append(*rects, sel.Bounds, mat.ApplyRecRec(sel.Bounds))
+	case SceneOpGroup:
+		for _, sub := range op.Ops {
+			sub.collectBounds(s, done, rects)
+		}
+	}
+}
+
+// count returns the number of objects affected by the operation
+func (op sceneOp) count() int {
+	switch op.Type {
+	case SceneOpAdd:
+		return len(op.New.Buildings) + len(op.New.Paths) + len(op.New.TextBoxes)
+	case SceneOpDelete:
+		return len(op.Sel.BuildingIdxs) + len(op.Sel.FullPathIdxs()) + len(op.Sel.TextBoxIdxs)
+	case SceneOpModify:
+		return len(op.Sel.BuildingIdxs) + len(op.Sel.PathIdxs) + len(op.Sel.TextBoxIdxs)
+	case SceneOpGroup:
+		n := 0
+		for _, sub := range op.Ops {
+			n += sub.count()
+		}
+		return n
 	default:
 		panic("invalid scene operation type")
 	}
 }
 
+// remapSecondary keeps the secondary selection (see [Selection.SwapSelections]) on the same objects
+// across the swap deletions and insertions of the operation, it must be called before performing it.
+//
+// Only operations on the global scene are tracked.
+func (op sceneOp) remapSecondary(s *Scene, undo bool) {
+	if s != &scene || selection.secondary.IsEmpty() {
+		return
+	}
+	if b, p, t, ok := op.remapping(s, undo); ok {
+		selection.secondary.remap(b, p, t)
+	}
+}
+
+// remapping returns the old index of the building, path and text box at each index once the operation
+// is performed (-1 for inserted objects), for [ObjectSelection.remap], and false if the operation
+// does not reorder objects. It must be called before performing the operation.
+func (op sceneOp) remapping(s *Scene, undo bool) (b, p, t []int, ok bool) {
+	b, p, t = Range(0, len(s.Buildings)), Range(0, len(s.Paths)), Range(0, len(s.TextBoxes))
+	switch {
+	case op.Type == SceneOpDelete && !undo:
+		b = SwapDeleteMany(b, op.Sel.BuildingIdxs)
+		p = SwapDeleteMany(p, op.Sel.FullPathIdxs())
+		t = SwapDeleteMany(t, op.Sel.TextBoxIdxs)
+	case op.Type == SceneOpDelete && undo:
+		pathIdxs := op.Sel.FullPathIdxs()
+		b = SwapInsertMany(b, op.Sel.BuildingIdxs, newIdxs(len(op.Sel.BuildingIdxs)))
+		p = SwapInsertMany(p, pathIdxs, newIdxs(len(pathIdxs)))
+		t = SwapInsertMany(t, op.Sel.TextBoxIdxs, newIdxs(len(op.Sel.TextBoxIdxs)))
+	case op.Type == SceneOpAdd && undo:
+		b = b[:len(b)-len(op.New.Buildings)]
+		p = p[:len(p)-len(op.New.Paths)]
+		t = t[:len(t)-len(op.New.TextBoxes)]
+	case op.Type == SceneOpAdd && !undo:
+		b = append(b, newIdxs(len(op.New.Buildings))...)
+		p = append(p, newIdxs(len(op.New.Paths))...)
+		t = append(t, newIdxs(len(op.New.TextBoxes))...)
+	default:
+		return nil, nil, nil, false
+	}
+	return b, p, t, true
+}
+
+// newIdxs returns n -1 indices, marking inserted objects for [ObjectSelection.remap]
+func newIdxs(n int) []int {
+	idxs := make([]int, n)
+	for i := range idxs {
+		idxs[i] = -1
+	}
+	return idxs
+}
+
 // do performs the operation
 func (op sceneOp) do(s *Scene) {
 	s.traceState("before", "sceneOp.do")
 	op.traceState()
+	op.remapSecondary(s, false)
+	op.trackOverlaps(s, false)
 	log.Info("scene.operation", "do", string(op.Type))
 	switch op.Type {
 
@@ -125,14 +385,23 @@ func (op sceneOp) do(s *Scene) {
 		pathIdxs := op.Sel.AnyPathIdxs()
 		log.Debug("scene.operation.modify", "action", "do",
 			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
-		for i, idx := range pathIdxs {
-			s.Paths[idx] = op.New.Paths[i]
-		}
-		for i, idx := range op.Sel.BuildingIdxs {
-			s.Buildings[idx] = op.New.Buildings[i]
+		if op.Delta != nil {
+			op.Delta.apply(s, op.Sel, false)
+		} else {
+			for i, idx := range pathIdxs {
+				s.Paths[idx] = op.New.Paths[i]
+			}
+			for i, idx := range op.Sel.BuildingIdxs {
+				s.Buildings[idx] = op.New.Buildings[i]
+			}
+			for i, idx := range op.Sel.TextBoxIdxs {
+				s.TextBoxes[idx] = op.New.TextBoxes[i]
+			}
 		}
-		for i, idx := range op.Sel.TextBoxIdxs {
-			s.TextBoxes[idx] = op.New.TextBoxes[i]
+
+	case SceneOpGroup:
+		for _, sub := range op.Ops {
+			sub.do(s)
 		}
 
 	default:
@@ -145,6 +414,8 @@ func (op sceneOp) do(s *Scene) {
 func (op sceneOp) redo(s *Scene) ObjectSelection {
 	s.traceState("before", "sceneOp.redo")
 	op.traceState()
+	op.remapSecondary(s, false)
+	op.trackOverlaps(s, false)
 	log.Info("scene.operation", "redo", string(op.Type))
 
 	var newSel ObjectSelection
@@ -182,18 +453,32 @@ func (op sceneOp) redo(s *Scene) ObjectSelection {
 		pathIdxs := op.Sel.AnyPathIdxs()
 		log.Debug("scene.operation.modify", "action", "redo",
 			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
-		for i, idx := range pathIdxs {
-			s.Paths[idx] = op.New.Paths[i]
-		}
-		for i, idx := range op.Sel.BuildingIdxs {
-			s.Buildings[idx] = op.New.Buildings[i]
-		}
-		for i, idx := range op.Sel.TextBoxIdxs {
-			s.TextBoxes[idx] = op.New.TextBoxes[i]
+		if op.Delta != nil {
+			op.Delta.apply(s, op.Sel, false)
+		} else {
+			for i, idx := range pathIdxs {
+				s.Paths[idx] = op.New.Paths[i]
+			}
+			for i, idx := range op.Sel.BuildingIdxs {
+				s.Buildings[idx] = op.New.Buildings[i]
+			}
+			for i, idx := range op.Sel.TextBoxIdxs {
+				s.TextBoxes[idx] = op.New.TextBoxes[i]
+			}
 		}
 		newSel = op.Sel
 		newSel.recomputeBounds(s.ObjectCollection)
 
+	case SceneOpGroup:
+		// the selections of every operation, following the objects across the next ones
+		for _, sub := range op.Ops {
+			if b, p, t, ok := sub.remapping(s, false); ok {
+				newSel.remap(b, p, t)
+			}
+			newSel.merge(sub.redo(s))
+		}
+		newSel.recomputeBounds(s.ObjectCollection)
+
 	default:
 		panic("invalid scene operation type")
 	}
@@ -205,6 +490,8 @@ func (op sceneOp) redo(s *Scene) ObjectSelection {
 func (op sceneOp) undo(s *Scene) ObjectSelection {
 	s.traceState("before", "sceneOp.undo")
 	op.traceState()
+	op.remapSecondary(s, true)
+	op.trackOverlaps(s, true)
 	log.Info("scene.operation", "undo", string(op.Type))
 
 	var newSel ObjectSelection
@@ -233,19 +520,29 @@ func (op sceneOp) undo(s *Scene) ObjectSelection {
 		pathIdxs := op.Sel.AnyPathIdxs()
 		log.Debug("scene.operation.modify", "action", "redo",
 			"paths", pathIdxs, "buildings", op.Sel.BuildingIdxs, "textboxes", op.Sel.TextBoxIdxs)
-		for i, idx := range pathIdxs {
-			s.Paths[idx] = op.Old.Paths[i]
-		}
-		for i, idx := range op.Sel.BuildingIdxs {
-			s.Buildings[idx] = op.Old.Buildings[i]
-		}
-		for i, idx := range op.Sel.TextBoxIdxs {
-			s.TextBoxes[idx] = op.Old.TextBoxes[i]
+		if op.Delta != nil {
+			op.Delta.apply(s, op.Sel, true)
+		} else {
+			for i, idx := range pathIdxs {
+				s.Paths[idx] = op.Old.Paths[i]
+			}
+			for i, idx := range op.Sel.BuildingIdxs {
+				s.Buildings[idx] = op.Old.Buildings[i]
+			}
+			for i, idx := range op.Sel.TextBoxIdxs {
+				s.TextBoxes[idx] = op.Old.TextBoxes[i]
+			}
 		}
 
 		newSel = op.Sel
 		newSel.recomputeBounds(s.ObjectCollection)
 
+	case SceneOpGroup:
+		// the selection of the first operation, as before doing the group
+		for i := len(op.Ops) - 1; i >= 0; i-- {
+			newSel = op.Ops[i].undo(s)
+		}
+
 	default:
 		panic("invalid scene operation type")
 	}
@@ -257,41 +554,145 @@ func (op sceneOp) undo(s *Scene) ObjectSelection {
 // Scene Modifiers methods
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// lastTransform represents the last rigid transformation of a selection (see [Scene.RepeatLastTransform])
+type lastTransform struct {
+	sceneOpDelta
+	// whether the transformed objects were duplicated (or moved)
+	duplicate bool
+	// last duplicated objects, which a repeated duplicate copies again to continue the array
+	added ObjectSelection
+}
+
 // doSceneOp adds the given operation to the scene history and performs it
+//
+// Inside an undo group (see [Scene.BeginUndoGroup]) the operation is added to the group instead.
+// It resets the last transformation, see [Scene.setLastTransform].
 func (s *Scene) doSceneOp(op sceneOp) {
+	s.lastTransform = nil
 	s.history = s.history[:s.historyPos] // trim any undone operations
-	op.do(s)                             // actually perform the operation
-	s.history = append(s.history, op)    // append the operation to the history
-	s.historyPos++                       // increment history position
-	s.Hovered = Object{}                 // invalidate hovered object just in case
+	op.Time = time.Now()
+	op.do(s) // actually perform the operation
+	if s.groupDepth > 0 {
+		s.group = append(s.group, op)
+	} else {
+		s.history = append(s.history, op) // append the operation to the history
+		s.historyPos++                    // increment history position
+		s.evictHistory()
+	}
+	s.Hovered = Object{} // invalidate hovered object just in case
+	s.invalidateOpCaches()
+}
+
+// BeginUndoGroup starts grouping the following operations into a single history step, undone and
+// redone at once, until the matching [Scene.EndUndoGroup].
+//
+// Groups can be nested, inner groups are merged into the outermost one.
+func (s *Scene) BeginUndoGroup() {
+	s.groupDepth++
+	log.Debug("scene.BeginUndoGroup", "depth", s.groupDepth)
+}
+
+// EndUndoGroup ends the group started by the matching [Scene.BeginUndoGroup]
+//
+// Ending the outermost group adds its operations to the history as a single step, if any.
+func (s *Scene) EndUndoGroup() {
+	if s.groupDepth == 0 {
+		log.Warn("cannot end undo group", "reason", "no group in progress")
+		return
+	}
+	s.groupDepth--
+	log.Debug("scene.EndUndoGroup", "depth", s.groupDepth, "ops", len(s.group))
+	if s.groupDepth > 0 || len(s.group) == 0 {
+		return
+	}
+	op := sceneOp{Type: SceneOpGroup, Ops: s.group, Time: s.group[0].Time}
+	if len(s.group) == 1 {
+		op = s.group[0]
+	}
+	s.group = nil
+	s.history = append(s.history, op)
+	s.historyPos++
+	s.evictHistory()
+}
+
+// invalidateCaches marks the scene derived data as outdated, it must be called after any change to
+// the scene objects
+func (s *Scene) invalidateCaches() {
+	s.overlapsIncremental = false
+	s.invalidateOpCaches()
+}
+
+// invalidateOpCaches is [Scene.invalidateCaches] after scene operations, whose changed buildings are
+// tracked for the incremental update of the overlapping buildings (see [sceneOp.trackOverlaps])
+func (s *Scene) invalidateOpCaches() {
+	s.revision++
+	s.crossingsUpToDate = false
+	s.indexUpToDate = false
+	s.overlapsUpToDate = false
+	s.summaryUpToDate = false
+}
+
+// checkFinite returns true if every coordinate of col is finite, and logs a warning otherwise
+//
+// Non-finite coordinates would poison bounds, collisions and camera computations.
+func checkFinite(action string, col ObjectCollection) bool {
+	if col.isFinite() {
+		return true
+	}
+	log.Warn("cannot "+action, "reason", errNotFinite.Error())
+	return false
 }
 
-// AddPath adds the given path to the scene.
+// AddPath adds the given path to the scene, unless it has non-finite coordinates.
 //
 // No validity check is performed.
 func (s *Scene) AddPath(path Path) {
-	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{Paths: []Path{path}}})
+	col := ObjectCollection{Paths: []Path{path}}
+	if checkFinite("add path", col) {
+		s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+	}
 }
 
-// AddBuilding adds the given building to the scene.
+// AddBuilding adds the given building to the scene, unless it has non-finite coordinates.
 //
-// No validity check is performed.
+// The building position is the placement position: the placement default offset of its class is
+// applied, see [PreviewBuildingAt]. No validity check is performed.
 func (s *Scene) AddBuilding(building Building) {
-	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{Buildings: []Building{building}}})
+	col := ObjectCollection{Buildings: []Building{PreviewBuildingAt(building, building.Pos)}}
+	if checkFinite("add building", col) {
+		s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+	}
 }
 
-// AddTextBox adds the given text box to the scene.
+// AddTextBox adds the given text box to the scene, unless it has non-finite coordinates.
 func (s *Scene) AddTextBox(tb TextBox) {
-	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: ObjectCollection{TextBoxes: []TextBox{tb}}})
+	col := ObjectCollection{TextBoxes: []TextBox{tb}}
+	if checkFinite("add text box", col) {
+		s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+	}
 }
 
-// AddObjects adds the given paths and buildings to the scene.
+// AddObjects adds the given paths and buildings to the scene, and returns whether they have been
+// added: nothing is added if the scene would exceed the maximum number of objects (see [SetMaxObjects])
+// or if any object has non-finite coordinates.
 //
 // No validity checks is performed.
-func (s *Scene) AddObjects(col ObjectCollection) {
+func (s *Scene) AddObjects(col ObjectCollection) bool {
+	if !checkFinite("add objects", col) {
+		return false
+	}
+	n := len(col.Buildings) + len(col.Paths) + len(col.TextBoxes)
+	if maxObjects > 0 && s.objectCount()+n > maxObjects {
+		log.Warn("cannot add objects", "reason", "too many objects", "count", n, "limit", maxObjects)
+		return false
+	}
 	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col.clone()})
+	return true
 }
 
+// objectCount returns the total number of objects in the scene
+func (s Scene) objectCount() int { return len(s.Buildings) + len(s.Paths) + len(s.TextBoxes) }
+
 // DeleteObjects deletes the given paths and buildings from the scene.
 func (s *Scene) DeleteObjects(sel ObjectSelection) {
 	sel = sel.clone()
@@ -304,48 +705,1456 @@ func (s *Scene) DeleteObjects(sel ObjectSelection) {
 
 // ModifyObjects updates the given paths and buildings in the scene.
 //
+// Only the objects actually changed are recorded, if none is nothing is recorded in history.
+// Nothing is modified if any new object has non-finite coordinates.
+//
 // No validity checks is performed.
 func (s *Scene) ModifyObjects(sel ObjectSelection, new ObjectCollection) {
-	sel = sel.clone()
-	op := sceneOp{Type: SceneOpModify, Sel: sel, New: new.clone()}
-	op.Old.Buildings = CopyIdxs(op.Old.Buildings, s.Buildings, sel.BuildingIdxs)
-	op.Old.Paths = CopyIdxs(op.Old.Paths, s.Paths, sel.AnyPathIdxs())
-	op.Old.TextBoxes = CopyIdxs(op.Old.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	if !checkFinite("modify objects", new) {
+		return
+	}
+	op := sceneOp{Type: SceneOpModify, Sel: ObjectSelection{Bounds: sel.Bounds}}
+	for i, idx := range sel.BuildingIdxs {
+		if s.Buildings[idx] != new.Buildings[i] {
+			op.Sel.BuildingIdxs = append(op.Sel.BuildingIdxs, idx)
+			op.Old.Buildings = append(op.Old.Buildings, s.Buildings[idx])
+			op.New.Buildings = append(op.New.Buildings, new.Buildings[i])
+		}
+	}
+	for i, elt := range sel.PathIdxs {
+		if s.Paths[elt.Idx] != new.Paths[i] {
+			op.Sel.PathIdxs = append(op.Sel.PathIdxs, elt)
+			op.Old.Paths = append(op.Old.Paths, s.Paths[elt.Idx])
+			op.New.Paths = append(op.New.Paths, new.Paths[i])
+		}
+	}
+	for i, idx := range sel.TextBoxIdxs {
+		if s.TextBoxes[idx] != new.TextBoxes[i] {
+			op.Sel.TextBoxIdxs = append(op.Sel.TextBoxIdxs, idx)
+			op.Old.TextBoxes = append(op.Old.TextBoxes, s.TextBoxes[idx])
+			op.New.TextBoxes = append(op.New.TextBoxes, new.TextBoxes[i])
+		}
+	}
+	if op.Sel.IsEmpty() {
+		log.Debug("scene.ModifyObjects", "action", "skipped", "reason", "no change")
+		return
+	}
 	s.doSceneOp(op)
 }
 
-// Undo tries to undo the last operation, and returns whether it has, and the action to be performed.
-func (s *Scene) Undo() (bool, Action) {
-	if s.historyPos > 0 {
-		s.historyPos-- // decrement history position
-		op := s.history[s.historyPos]
-		s.Hovered = Object{} // invalidate hovered object just in case
-		// will switch to [ModeSelection] or [ModeNormal] if new selection is empty
-		return true, selection.doInitSelection(op.undo(s))
+// modifySelectedCopies modifies copies of the objects of sel with the given functions, and records
+// them with [Scene.ModifyObjects]. Objects of a type whose function is nil are left untouched.
+//
+// Each copy is modified along with its selection element: its index, or for paths its selected ends.
+func (s *Scene) modifySelectedCopies(sel ObjectSelection, building func(idx int, b *Building), path func(elt PathSel, p *Path), textBox func(idx int, tb *TextBox)) {
+	var col ObjectCollection
+	if building == nil {
+		sel.BuildingIdxs = nil
+	}
+	for _, idx := range sel.BuildingIdxs {
+		b := s.Buildings[idx]
+		building(idx, &b)
+		col.Buildings = append(col.Buildings, b)
+	}
+	if path == nil {
+		sel.PathIdxs = nil
+	}
+	for _, elt := range sel.PathIdxs {
+		p := s.Paths[elt.Idx]
+		path(elt, &p)
+		col.Paths = append(col.Paths, p)
+	}
+	if textBox == nil {
+		sel.TextBoxIdxs = nil
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		tb := s.TextBoxes[idx]
+		textBox(idx, &tb)
+		col.TextBoxes = append(col.TextBoxes, tb)
+	}
+	s.ModifyObjects(sel, col)
+}
+
+// SnapPathToGrid snaps both ends of the path idx to the grid (see [Grid.Snap]), and returns the
+// action selecting it if it was modified (nil otherwise).
+func (s *Scene) SnapPathToGrid(idx int) Action {
+	if idx < 0 || idx >= len(s.Paths) {
+		log.Warn("cannot snap path to grid", "reason", "invalid path index", "idx", idx)
+		return nil
+	}
+	return s.SnapPathsToGrid(ObjectSelection{PathIdxs: []PathSel{{Idx: idx, Start: true, End: true}}})
+}
+
+// SnapPathsToGrid snaps both ends of the fully selected paths to the grid (see [Grid.Snap]), and
+// returns the action selecting them if any was modified (nil otherwise).
+//
+// Already aligned paths are left untouched, as are paths which would become zero-length.
+func (s *Scene) SnapPathsToGrid(sel ObjectSelection) Action {
+	var snapSel ObjectSelection
+	var snapped ObjectCollection
+	for _, idx := range sel.FullPathIdxs() {
+		p := s.Paths[idx]
+		p.Start, p.End = grid.Snap(p.Start), grid.Snap(p.End)
+		if p == s.Paths[idx] {
+			continue
+		}
+		if !s.IsPathValid(p) {
+			log.Warn("cannot snap path to grid", "reason", "zero-length path", "idx", idx)
+			continue
+		}
+		snapSel.PathIdxs = append(snapSel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+		snapped.Paths = append(snapped.Paths, p)
+	}
+	if snapSel.IsEmpty() {
+		log.Debug("scene.SnapPathsToGrid", "action", "skipped", "reason", "already aligned")
+		return nil
+	}
+	s.ModifyObjects(snapSel, snapped)
+	snapSel.recomputeBounds(s.ObjectCollection)
+	return selection.doInitSelection(snapSel)
+}
+
+// SnapSelectionToGrid translates the given objects so that their bounds top-left corner (or center
+// with [Grid.SnapCenters]) is on the grid, see [Grid.Snap].
+//
+// Objects are moved together, keeping their relative positions; for paths only the selected ends
+// are moved. It is recorded as a single modify operation, and is a no-op if already aligned.
+//
+// No validity checks is performed.
+func (s *Scene) SnapSelectionToGrid(sel ObjectSelection) {
+	if sel.IsEmpty() {
+		return
+	}
+	sel = sel.clone()
+	sel.recomputeBounds(s.ObjectCollection)
+	anchor := sel.Bounds.TopLeft()
+	if grid.SnapCenters {
+		anchor = sel.Bounds.Center()
+	}
+	d := grid.Snap(anchor).Subtract(anchor)
+	if d.X == 0 && d.Y == 0 {
+		log.Debug("scene.SnapSelectionToGrid", "action", "skipped", "reason", "already aligned")
+		return
+	}
+
+	log.Debug("scene.SnapSelectionToGrid", "translate", d)
+	s.modifySelectedCopies(sel,
+		func(_ int, b *Building) { b.Pos = b.Pos.Add(d) },
+		func(elt PathSel, p *Path) {
+			if elt.Start {
+				p.Start = p.Start.Add(d)
+			}
+			if elt.End {
+				p.End = p.End.Add(d)
+			}
+		},
+		func(_ int, tb *TextBox) {
+			tb.Bounds.X += d.X
+			tb.Bounds.Y += d.Y
+		})
+}
+
+// Canonicalize reorders the scene objects into a deterministic order, and returns sel with its
+// indices following their objects.
+//
+// Buildings are sorted by class then position (Y then X), paths by class then start and end
+// positions, text boxes by position then size and content. The reordering is a single (undoable)
+// modification, nothing is recorded if the objects are already in order.
+func (s *Scene) Canonicalize(sel ObjectSelection) ObjectSelection {
+	bOrder := canonicalOrder(s.Buildings, func(a, b Building) int {
+		return cmp.Or(
+			strings.Compare(a.Def().Class, b.Def().Class),
+			cmp.Compare(a.Pos.Y, b.Pos.Y), cmp.Compare(a.Pos.X, b.Pos.X),
+			cmp.Compare(a.Rot, b.Rot), compareBool(a.Hidden, b.Hidden))
+	})
+	pOrder := canonicalOrder(s.Paths, func(a, b Path) int {
+		return cmp.Or(
+			strings.Compare(a.Def().Class, b.Def().Class),
+			cmp.Compare(a.Start.Y, b.Start.Y), cmp.Compare(a.Start.X, b.Start.X),
+			cmp.Compare(a.End.Y, b.End.Y), cmp.Compare(a.End.X, b.End.X), compareBool(a.Hidden, b.Hidden))
+	})
+	tOrder := canonicalOrder(s.TextBoxes, func(a, b TextBox) int {
+		return cmp.Or(
+			cmp.Compare(a.Bounds.Y, b.Bounds.Y), cmp.Compare(a.Bounds.X, b.Bounds.X),
+			cmp.Compare(a.Bounds.Width, b.Bounds.Width), cmp.Compare(a.Bounds.Height, b.Bounds.Height),
+			strings.Compare(a.Content, b.Content), compareBool(a.Hidden, b.Hidden), compareBool(a.Background, b.Background))
+	})
+
+	all := s.ObjectCollection.SelectAll()
+	var col ObjectCollection
+	col.Buildings = CopyIdxs(col.Buildings, s.Buildings, bOrder)
+	col.Paths = CopyIdxs(col.Paths, s.Paths, pOrder)
+	col.TextBoxes = CopyIdxs(col.TextBoxes, s.TextBoxes, tOrder)
+	s.ModifyObjects(all, col)
+
+	// new index of each object
+	remap := func(order []int) []int {
+		newIdxs := make([]int, len(order))
+		for newIdx, oldIdx := range order {
+			newIdxs[oldIdx] = newIdx
+		}
+		return newIdxs
+	}
+	bNew, pNew, tNew := remap(bOrder), remap(pOrder), remap(tOrder)
+	remapped := ObjectSelection{Bounds: sel.Bounds}
+	for _, idx := range sel.BuildingIdxs {
+		remapped.BuildingIdxs = append(remapped.BuildingIdxs, bNew[idx])
+	}
+	for _, elt := range sel.PathIdxs {
+		remapped.PathIdxs = append(remapped.PathIdxs, PathSel{Idx: pNew[elt.Idx], Start: elt.Start, End: elt.End})
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		remapped.TextBoxIdxs = append(remapped.TextBoxIdxs, tNew[idx])
+	}
+	slices.Sort(remapped.BuildingIdxs)
+	slices.SortFunc(remapped.PathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	slices.Sort(remapped.TextBoxIdxs)
+	log.Info("scene.Canonicalize", "buildings", len(bOrder), "paths", len(pOrder), "textBoxes", len(tOrder))
+	return remapped
+}
+
+// canonicalOrder returns the indices of objs sorted with compare (stable)
+func canonicalOrder[T any](objs []T, compare func(a, b T) int) []int {
+	order := Range(0, len(objs))
+	slices.SortStableFunc(order, func(a, b int) int { return compare(objs[a], objs[b]) })
+	return order
+}
+
+// compareBool compares booleans, false first
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case a:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// TransformObjects rotates the given objects by rot degrees around center, then translates them.
+//
+// Unlike [Scene.ModifyObjects], only the transformation is stored in history, not the objects.
+//
+// Nothing is transformed if center or translate is non-finite. No validity checks is performed.
+func (s *Scene) TransformObjects(sel ObjectSelection, center rl.Vector2, translate rl.Vector2, rot int32) {
+	if !AllFinite(center.X, center.Y, translate.X, translate.Y) {
+		log.Warn("cannot transform objects", "reason", errNotFinite.Error())
+		return
+	}
+	delta := sceneOpDelta{Center: center, Translate: translate, Rot: rot}
+	s.doSceneOp(sceneOp{Type: SceneOpModify, Sel: sel.clone(), Delta: &delta})
+	s.setLastTransform(delta, false, ObjectSelection{})
+}
+
+// RotateSelection rotates the given objects by quarterTurns quarters of turn (clockwise on screen,
+// negative for counterclockwise) around the selection bounds center.
+//
+// Unlike [Scene.TransformObjects], coordinates are swapped and negated rather than multiplied by a
+// rotation matrix, and the objects are stored in history, so that four quarter turns restore them
+// exactly (up to the rounding of the sums). Text boxes stay axis aligned: their width and height are
+// swapped on odd quarter turns. It is recorded as a single modify operation.
+//
+// No validity checks is performed.
+func (s *Scene) RotateSelection(sel ObjectSelection, quarterTurns int) {
+	turns := (quarterTurns%4 + 4) % 4
+	if sel.IsEmpty() || turns == 0 {
+		log.Debug("scene.RotateSelection", "action", "skipped", "quarterTurns", quarterTurns)
+		return
+	}
+	sel = sel.clone()
+	sel.recomputeBounds(s.ObjectCollection)
+	r := quarterTurner{c: sel.Bounds.Center(), turns: turns}
+	log.Debug("scene.RotateSelection", "quarterTurns", quarterTurns, "center", r.c)
+	s.modifySelectedCopies(sel, r.building, r.path, r.textBox)
+}
+
+// quarterTurner rotates objects by turns (in [0, 4)) quarter turns clockwise around c, see
+// [Scene.RotateSelection] and [ObjectCollection.rotate]. Its methods match [Scene.modifySelectedCopies].
+type quarterTurner struct {
+	c     rl.Vector2
+	turns int
+}
+
+// point returns the rotation of v, coordinates are swapped and negated to be exact
+func (r quarterTurner) point(v rl.Vector2) rl.Vector2 {
+	for range r.turns {
+		v = vec2(r.c.X-(v.Y-r.c.Y), r.c.Y+(v.X-r.c.X))
+	}
+	return v
+}
+
+func (r quarterTurner) building(_ int, b *Building) {
+	b.Pos = r.point(b.Pos)
+	b.Rot = (b.Rot + int32(90*r.turns)) % 360
+}
+
+// path rotates the selected ends of p
+func (r quarterTurner) path(elt PathSel, p *Path) {
+	if elt.Start {
+		p.Start = r.point(p.Start)
+	}
+	if elt.End {
+		p.End = r.point(p.End)
+	}
+}
+
+// textBox rotates the corners of tb, which stays axis aligned
+func (r quarterTurner) textBox(_ int, tb *TextBox) {
+	tb.Bounds = rl.NewRectangleCorners(r.point(tb.Bounds.TopLeft()), r.point(tb.Bounds.BottomRight()))
+}
+
+// rotate rotates every object of oc by quarterTurns quarter turns clockwise (negative for
+// counterclockwise) around their bounds center, like [Scene.RotateSelection].
+func (oc *ObjectCollection) rotate(quarterTurns int) {
+	r := quarterTurner{c: oc.SelectAll().Bounds.Center(), turns: (quarterTurns%4 + 4) % 4}
+	for i := range oc.Buildings {
+		r.building(i, &oc.Buildings[i])
+	}
+	for i := range oc.Paths {
+		r.path(PathSel{Idx: i, Start: true, End: true}, &oc.Paths[i])
+	}
+	for i := range oc.TextBoxes {
+		r.textBox(i, &oc.TextBoxes[i])
+	}
+}
+
+// RotateBuildingsInPlace rotates each of the given buildings by 90° clockwise around its own center,
+// other selected objects are left untouched. It is recorded as a single modify operation.
+//
+// Positions are adjusted by whole world units, so that the footprints of odd sized buildings stay
+// grid aligned (their center moves by half a world unit at most).
+//
+// No validity checks is performed, so that overlaps can be fixed manually.
+func (s *Scene) RotateBuildingsInPlace(sel ObjectSelection) {
+	if len(sel.BuildingIdxs) == 0 {
+		log.Debug("scene.RotateBuildingsInPlace", "action", "skipped", "reason", "no building selected")
+		return
+	}
+	log.Debug("scene.RotateBuildingsInPlace", "buildings", len(sel.BuildingIdxs))
+	s.modifySelectedCopies(ObjectSelection{BuildingIdxs: slices.Clone(sel.BuildingIdxs)}, func(_ int, b *Building) {
+		center := b.Bounds().Center()
+		b.Rot = (b.Rot + 90) % 360
+		d := center.Subtract(b.Bounds().Center())
+		b.Pos = b.Pos.Add(vec2(math32.Round(d.X), math32.Round(d.Y)))
+	}, nil, nil)
+}
+
+// ReversePaths swaps the start and end of every path with at least one selected end, as a single
+// modify operation, and returns sel with the path ends selection swapped accordingly (the same
+// positions stay selected).
+func (s *Scene) ReversePaths(sel ObjectSelection) ObjectSelection {
+	if len(sel.PathIdxs) == 0 {
+		log.Debug("scene.ReversePaths", "action", "skipped", "reason", "no path selected")
+		return sel
+	}
+	log.Debug("scene.ReversePaths", "paths", len(sel.PathIdxs))
+	s.modifySelectedCopies(ObjectSelection{PathIdxs: slices.Clone(sel.PathIdxs)}, nil, func(_ PathSel, p *Path) {
+		p.Start, p.End = p.End, p.Start
+	}, nil)
+
+	sel = sel.clone()
+	for i := range sel.PathIdxs {
+		sel.PathIdxs[i].Start, sel.PathIdxs[i].End = sel.PathIdxs[i].End, sel.PathIdxs[i].Start
+	}
+	return sel
+}
+
+// maximum distance of a path end to the line of another path for them to be merged by
+// [Scene.MergeCollinearPaths], and maximum gap between their ends (world units)
+const collinearTolerance = 0.01
+
+// mergeCollinear returns the path spanning a and b, and false if they are not collinear (within
+// [collinearTolerance]) and overlapping or touching end to end, or of different definitions.
+//
+// The merged path keeps the direction and the hidden state of a, its ends are ends of a or b.
+func mergeCollinear(a, b Path) (Path, bool) {
+	length := a.End.Distance(a.Start)
+	if a.DefIdx != b.DefIdx || length == 0 || b.End.Equals(b.Start) {
+		return Path{}, false
+	}
+	dir := a.End.Subtract(a.Start).Scale(1 / length)
+	ends := [4]rl.Vector2{a.Start, a.End, b.Start, b.End}
+	var t [4]float32
+	for i, p := range ends {
+		d := p.Subtract(a.Start)
+		if math32.Abs(d.CrossProduct(dir)) > collinearTolerance {
+			return Path{}, false
+		}
+		t[i] = d.DotProduct(dir)
+	}
+	if max(t[0], t[1]) < min(t[2], t[3])-collinearTolerance || max(t[2], t[3]) < min(t[0], t[1])-collinearTolerance {
+		return Path{}, false // disjoint
+	}
+	first, last := 0, 0
+	for i := range t {
+		if t[i] < t[first] {
+			first = i
+		}
+		if t[i] > t[last] {
+			last = i
+		}
+	}
+	a.Start, a.End = ends[first], ends[last]
+	return a, true
+}
+
+// MergeCollinearPaths merges the selected paths of the same definition that are collinear and
+// overlapping or touching end to end (see [collinearTolerance]) into single paths spanning them, as
+// a single undoable step, and returns the selection of the merged paths and false if none was.
+//
+// Each merged path replaces the lowest index path of its group, the other ones are deleted. Paths
+// with at least one selected end are considered.
+func (s *Scene) MergeCollinearPaths(sel ObjectSelection) (ObjectSelection, bool) {
+	idxs := sel.AnyPathIdxs()
+	merged := CopyIdxs(nil, s.Paths, idxs)
+	deleted := make([]bool, len(idxs))
+	changed := make([]bool, len(idxs))
+	// merge pairs until no more merge is possible, as merged paths may reach new paths
+	for again := true; again; {
+		again = false
+		for i := range merged {
+			for j := i + 1; j < len(merged) && !deleted[i]; j++ {
+				if deleted[j] {
+					continue
+				}
+				if p, ok := mergeCollinear(merged[i], merged[j]); ok {
+					merged[i], deleted[j], changed[i], again = p, true, true, true
+				}
+			}
+		}
+	}
+	var modSel, delSel ObjectSelection
+	var col ObjectCollection
+	for i, idx := range idxs {
+		switch {
+		case deleted[i]:
+			delSel.PathIdxs = append(delSel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+		case changed[i]:
+			modSel.PathIdxs = append(modSel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+			col.Paths = append(col.Paths, merged[i])
+		}
+	}
+	if len(delSel.PathIdxs) == 0 {
+		log.Debug("scene.MergeCollinearPaths", "action", "skipped", "reason", "no collinear paths")
+		return ObjectSelection{}, false
+	}
+	// indices of the merged paths after the deletion, which moves the last paths into the deleted slots
+	newIdx := map[int]int{}
+	for i, idx := range SwapDeleteMany(Range(0, len(s.Paths)), delSel.FullPathIdxs()) {
+		newIdx[idx] = i
+	}
+	s.BeginUndoGroup()
+	s.ModifyObjects(modSel, col)
+	s.DeleteObjects(delSel)
+	s.EndUndoGroup()
+	log.Info("merged collinear paths", "paths", len(modSel.PathIdxs), "deleted", len(delSel.PathIdxs))
+
+	var out ObjectSelection
+	for _, ps := range modSel.PathIdxs {
+		out.PathIdxs = append(out.PathIdxs, PathSel{Idx: newIdx[ps.Idx], Start: true, End: true})
+	}
+	slices.SortFunc(out.PathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	out.recomputeBounds(s.ObjectCollection)
+	return out, true
+}
+
+// setLastTransform records the last rigid transformation, for [Scene.RepeatLastTransform], with the
+// selection of the added objects for duplicates
+//
+// It must be called after the corresponding operation, as [Scene.doSceneOp] resets it.
+func (s *Scene) setLastTransform(delta sceneOpDelta, duplicate bool, added ObjectSelection) {
+	s.lastTransform = &lastTransform{sceneOpDelta: delta, duplicate: duplicate, added: added}
+}
+
+// HasLastTransform returns true if there is a transformation to repeat with [Scene.RepeatLastTransform]
+func (s *Scene) HasLastTransform() bool { return s.lastTransform != nil }
+
+// RepeatLastTransform applies the last duplicate or move transformation again on the given
+// selection, rotating around the selection center, and returns the resulting selection:
+//   - after a duplicate: the last duplicated objects are duplicated (instead of the given selection,
+//     so that repeats continue the array), and the new copies are returned (only fully selected paths
+//     are duplicated)
+//   - after a move: the given selection is moved, and returned with updated bounds
+//
+// The last transformation is reset by any other scene operation, undo and redo.
+// If there is no last transformation, or the selection is empty, it is a no-op returning sel.
+//
+// No validity checks is performed.
+func (s *Scene) RepeatLastTransform(sel ObjectSelection) ObjectSelection {
+	if s.lastTransform == nil || sel.IsEmpty() {
+		log.Debug("scene.repeatLastTransform", "action", "skipped", "hasLastTransform", s.lastTransform != nil)
+		return sel
+	}
+	last := *s.lastTransform
+	log.Debug("scene.repeatLastTransform", "delta", last.sceneOpDelta, "duplicate", last.duplicate)
+	sel = sel.clone()
+	sel.recomputeBounds(s.ObjectCollection)
+	delta := last.sceneOpDelta
+	delta.Center = sel.Bounds.Center()
+
+	if !last.duplicate {
+		s.TransformObjects(sel, delta.Center, delta.Translate, delta.Rot)
+		sel.recomputeBounds(s.ObjectCollection)
+		return sel
+	}
+	if !last.added.IsEmpty() {
+		sel = last.added.clone()
+		sel.recomputeBounds(s.ObjectCollection)
+		delta.Center = sel.Bounds.Center()
+	}
+
+	var col ObjectCollection
+	col.Buildings = CopyIdxs(col.Buildings, s.Buildings, sel.BuildingIdxs)
+	col.Paths = CopyIdxs(col.Paths, s.Paths, sel.FullPathIdxs())
+	col.TextBoxes = CopyIdxs(col.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	delta.applyCollection(&col)
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+	newSel := s.tailSelection(col)
+	s.setLastTransform(delta, true, newSel)
+	return newSel
+}
+
+// Anchor is the reference point of a building bounds kept fixed by [Scene.ReplaceBuilding]
+type Anchor int
+
+const (
+	AnchorCenter Anchor = iota
+	AnchorTopLeft
+	AnchorTopRight
+	AnchorBottomLeft
+	AnchorBottomRight
+)
+
+func (a Anchor) String() string {
+	switch a {
+	case AnchorCenter:
+		return "AnchorCenter"
+	case AnchorTopLeft:
+		return "AnchorTopLeft"
+	case AnchorTopRight:
+		return "AnchorTopRight"
+	case AnchorBottomLeft:
+		return "AnchorBottomLeft"
+	case AnchorBottomRight:
+		return "AnchorBottomRight"
+	default:
+		return "Invalid"
+	}
+}
+
+// point returns the anchor point of the given bounds
+func (a Anchor) point(r rl.Rectangle) rl.Vector2 {
+	switch a {
+	case AnchorTopLeft:
+		return r.TopLeft()
+	case AnchorTopRight:
+		return r.TopRight()
+	case AnchorBottomLeft:
+		return r.BottomLeft()
+	case AnchorBottomRight:
+		return r.BottomRight()
+	default:
+		return r.Center()
+	}
+}
+
+// ReplaceBuilding replaces the class of building idx with newDefIdx, keeping its rotation and the
+// anchor point of its bounds, and returns whether it has, and the action selecting it.
+//
+// The replacement is recorded as a single modify operation. It is refused (with a warning) if an
+// index is invalid or if the new building would overlap another one, see [Scene.ForceReplaceBuilding].
+func (s *Scene) ReplaceBuilding(idx int, newDefIdx int, anchor Anchor) (bool, Action) {
+	return s.replaceBuilding(idx, newDefIdx, anchor, false)
+}
+
+// ForceReplaceBuilding is like [Scene.ReplaceBuilding] but performs the replacement even if the
+// new building overlaps another one.
+func (s *Scene) ForceReplaceBuilding(idx int, newDefIdx int, anchor Anchor) (bool, Action) {
+	return s.replaceBuilding(idx, newDefIdx, anchor, true)
+}
+
+func (s *Scene) replaceBuilding(idx int, newDefIdx int, anchor Anchor, force bool) (bool, Action) {
+	if idx < 0 || idx >= len(s.Buildings) {
+		log.Warn("cannot replace building", "reason", "invalid building index", "idx", idx)
+		return false, nil
+	}
+	if newDefIdx < 0 || newDefIdx >= len(buildingDefs) {
+		log.Warn("cannot replace building", "reason", "invalid building definition index", "defIdx", newDefIdx)
+		return false, nil
+	}
+	old := s.Buildings[idx]
+	b := old
+	b.DefIdx = newDefIdx
+	b.Pos = b.Pos.Add(anchor.point(old.Bounds()).Subtract(anchor.point(b.Bounds())))
+	if !force && !s.IsBuildingValid(b, idx) {
+		log.Warn("cannot replace building", "reason", "overlap", "old", old, "new", b)
+		return false, nil
+	}
+	log.Debug("scene.replaceBuilding", "idx", idx, "old", old, "new", b, "anchor", anchor, "force", force)
+	sel := ObjectSelection{BuildingIdxs: []int{idx}}
+	s.ModifyObjects(sel, ObjectCollection{Buildings: []Building{b}})
+	sel.recomputeBounds(s.ObjectCollection)
+	return true, selection.doInitSelection(sel)
+}
+
+// DefTable is a definitions table, either [BuildingDefs] or [PathDefs]
+type DefTable interface {
+	Classes() []string
+	Index(class string) int
+}
+
+// RemapDefIndices updates the definition indices of the scene objects, history included, after
+// their definitions table changed from oldDefs to newDefs (both [BuildingDefs] or both [PathDefs]).
+//
+// For the global scene, the objects held outside of it are remapped too: the stamp objects, the
+// reference scene and the objects being placed. The clipboard holds classes, not indices (see
+// [Scene.CopyToClipboard]).
+//
+// Objects are matched by class. If classes used by the scene are missing from newDefs nothing is
+// changed, and the returned error lists them.
+func (s *Scene) RemapDefIndices(oldDefs, newDefs DefTable) error {
+	_, buildings := oldDefs.(BuildingDefs)
+	if _, ok := newDefs.(BuildingDefs); ok != buildings {
+		return errors.New("cannot remap definitions of different kinds")
+	}
+	oldClasses := oldDefs.Classes()
+	mapping := make([]int, len(oldClasses))
+	for i, class := range oldClasses {
+		mapping[i] = newDefs.Index(class)
+	}
+
+	// every definition index to remap (shared backing arrays are visited once)
+	idxs := map[*int]struct{}{}
+	collect := func(col *ObjectCollection) {
+		if buildings {
+			for i := range col.Buildings {
+				idxs[&col.Buildings[i].DefIdx] = struct{}{}
+			}
+		} else {
+			for i := range col.Paths {
+				idxs[&col.Paths[i].DefIdx] = struct{}{}
+			}
+		}
+	}
+	var collectOps func(ops []sceneOp)
+	collectOps = func(ops []sceneOp) {
+		for i := range ops {
+			collect(&ops[i].Old)
+			collect(&ops[i].New)
+			collectOps(ops[i].Ops)
+		}
+	}
+	collect(&s.ObjectCollection)
+	collectOps(s.history)
+	collectOps(s.group)
+	if s == &scene {
+		collect(&reference)
+		if stamp.active {
+			collect(&stamp.objects)
+		}
+		if app.Mode == ModeSelection && selection.mode != SelectionNormal && selection.mode != SelectionSingleTextBox {
+			collect(&selection.transform.ObjectCollection)
+		}
+		if buildings && app.Mode == ModeNewBuilding {
+			idxs[&newBuilding.building.DefIdx] = struct{}{}
+		}
+		if !buildings && app.Mode == ModeNewPath {
+			idxs[&newPath.path.DefIdx] = struct{}{}
+		}
+	}
+
+	var unknown []string
+	for idx := range idxs {
+		if *idx < 0 || *idx >= len(mapping) {
+			return fmt.Errorf("invalid definition index %d", *idx)
+		}
+		if class := oldClasses[*idx]; mapping[*idx] < 0 && !slices.Contains(unknown, class) {
+			unknown = append(unknown, class)
+		}
+	}
+	if len(unknown) > 0 {
+		slices.Sort(unknown)
+		return fmt.Errorf("unknown classes: %s", strings.Join(unknown, ", "))
+	}
+	for idx := range idxs {
+		*idx = mapping[*idx]
+	}
+	log.Info("scene.RemapDefIndices", "buildings", buildings, "count", len(idxs))
+	s.invalidateCaches()
+	return nil
+}
+
+// Axis is a world axis
+type Axis int
+
+const (
+	AxisX Axis = iota
+	AxisY
+)
+
+func (a Axis) String() string {
+	switch a {
+	case AxisX:
+		return "AxisX"
+	case AxisY:
+		return "AxisY"
+	default:
+		return "Invalid"
+	}
+}
+
+// span returns the start and end of r along the axis
+func (a Axis) span(r rl.Rectangle) (float32, float32) {
+	if a == AxisY {
+		return r.Y, r.Y + r.Height
+	}
+	return r.X, r.X + r.Width
+}
+
+// vec returns a vector of length v along the axis
+func (a Axis) vec(v float32) rl.Vector2 {
+	if a == AxisY {
+		return vec2(0, v)
+	}
+	return vec2(v, 0)
+}
+
+// sortedAlong returns the selected buildings indices sorted by their bounds start along the axis
+func (s Scene) sortedAlong(sel ObjectSelection, axis Axis) []int {
+	order := slices.Clone(sel.BuildingIdxs)
+	slices.SortStableFunc(order, func(i, j int) int {
+		si, _ := axis.span(s.Buildings[i].Bounds())
+		sj, _ := axis.span(s.Buildings[j].Bounds())
+		switch {
+		case si < sj:
+			return -1
+		case si > sj:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return order
+}
+
+// MeanGap returns the mean edge to edge gap between consecutive selected buildings along the axis,
+// 0 with fewer than two buildings
+func (s Scene) MeanGap(sel ObjectSelection, axis Axis) float32 {
+	order := s.sortedAlong(sel, axis)
+	if len(order) < 2 {
+		return 0
+	}
+	var sum float32
+	for k := 1; k < len(order); k++ {
+		_, prevEnd := axis.span(s.Buildings[order[k-1]].Bounds())
+		start, _ := axis.span(s.Buildings[order[k]].Bounds())
+		sum += start - prevEnd
+	}
+	return sum / float32(len(order)-1)
+}
+
+// EqualizeGaps moves the selected buildings along the axis so that consecutive buildings have
+// exactly gap between their bounds, the first one being fixed.
+//
+// Buildings are ordered by their bounds start along the axis, other selected objects are left
+// untouched. It is recorded as a single modify operation, and is a no-op with fewer than two buildings.
+//
+// No validity checks is performed.
+func (s *Scene) EqualizeGaps(sel ObjectSelection, axis Axis, gap float32) {
+	if len(sel.BuildingIdxs) < 2 {
+		log.Debug("scene.EqualizeGaps", "action", "skipped", "reason", "fewer than two buildings")
+		return
+	}
+	order := s.sortedAlong(sel, axis)
+	newPos := make(map[int]rl.Vector2, len(order))
+	_, end := axis.span(s.Buildings[order[0]].Bounds())
+	for _, idx := range order[1:] {
+		b := s.Buildings[idx]
+		start, stop := axis.span(b.Bounds())
+		delta := end + gap - start
+		newPos[idx] = b.Pos.Add(axis.vec(delta))
+		end = stop + delta
+	}
+
+	log.Debug("scene.EqualizeGaps", "axis", axis, "gap", gap)
+	s.modifySelectedCopies(ObjectSelection{BuildingIdxs: slices.Clone(sel.BuildingIdxs)}, func(idx int, b *Building) {
+		if pos, ok := newPos[idx]; ok {
+			b.Pos = pos
+		}
+	}, nil, nil)
+}
+
+// Alignment is an edge or center buildings can be aligned to, see [Scene.AlignBuildings]
+type Alignment int
+
+const (
+	AlignLeft Alignment = iota
+	AlignRight
+	AlignTop
+	AlignBottom
+	// AlignCenterX aligns horizontal centers (on a vertical line)
+	AlignCenterX
+	// AlignCenterY aligns vertical centers (on a horizontal line)
+	AlignCenterY
+)
+
+func (a Alignment) String() string {
+	switch a {
+	case AlignLeft:
+		return "AlignLeft"
+	case AlignRight:
+		return "AlignRight"
+	case AlignTop:
+		return "AlignTop"
+	case AlignBottom:
+		return "AlignBottom"
+	case AlignCenterX:
+		return "AlignCenterX"
+	case AlignCenterY:
+		return "AlignCenterY"
+	default:
+		return "Invalid"
+	}
+}
+
+// axis returns the axis buildings are moved along
+func (a Alignment) axis() Axis {
+	if a == AlignTop || a == AlignBottom || a == AlignCenterY {
+		return AxisY
+	}
+	return AxisX
+}
+
+// coord returns the aligned coordinate of r along the alignment axis
+func (a Alignment) coord(r rl.Rectangle) float32 {
+	start, end := a.axis().span(r)
+	switch a {
+	case AlignLeft, AlignTop:
+		return start
+	case AlignRight, AlignBottom:
+		return end
+	default:
+		return (start + end) / 2
+	}
+}
+
+// AlignBuildings moves the selected buildings so that their bounds share the same edge or center,
+// that of the bounds of all the selected buildings.
+//
+// Bounds account for the buildings rotation, other selected objects are left untouched. It is
+// recorded as a single modify operation, and is a no-op with fewer than two buildings.
+//
+// No validity checks is performed.
+func (s *Scene) AlignBuildings(sel ObjectSelection, align Alignment) {
+	if len(sel.BuildingIdxs) < 2 {
+		log.Debug("scene.AlignBuildings", "action", "skipped", "reason", "fewer than two buildings")
+		return
+	}
+	bSel := ObjectSelection{BuildingIdxs: slices.Clone(sel.BuildingIdxs)}
+	bSel.recomputeBounds(s.ObjectCollection)
+	target := align.coord(bSel.Bounds)
+
+	moved := 0
+	s.modifySelectedCopies(bSel, func(_ int, b *Building) {
+		if delta := target - align.coord(b.Bounds()); delta != 0 {
+			b.Pos = b.Pos.Add(align.axis().vec(delta))
+			moved++
+		}
+	}, nil, nil)
+	log.Info("align buildings", "alignment", align, "moved", moved)
+}
+
+// Hide hides the given objects: they are not drawn and cannot be hovered or selected.
+//
+// It is recorded as a single modify operation (undoable), and hidden state is saved with the scene.
+// For paths, selecting either end is enough.
+func (s *Scene) Hide(sel ObjectSelection) { s.setHidden(sel, true) }
+
+// Show shows the given hidden objects, see [Scene.Hide]
+func (s *Scene) Show(sel ObjectSelection) { s.setHidden(sel, false) }
+
+// ShowAll shows every hidden object, see [Scene.Hide]
+func (s *Scene) ShowAll() { s.setHidden(s.HiddenObjects(), false) }
+
+// HiddenObjects returns the selection of the hidden objects (bounds are not computed)
+func (s *Scene) HiddenObjects() ObjectSelection {
+	var sel ObjectSelection
+	for i, b := range s.Buildings {
+		if b.Hidden {
+			sel.BuildingIdxs = append(sel.BuildingIdxs, i)
+		}
+	}
+	for i, p := range s.Paths {
+		if p.Hidden {
+			sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+		}
+	}
+	for i, tb := range s.TextBoxes {
+		if tb.Hidden {
+			sel.TextBoxIdxs = append(sel.TextBoxIdxs, i)
+		}
+	}
+	return sel
+}
+
+// setHidden sets the hidden state of the given objects, see [Scene.ModifyObjects]
+func (s *Scene) setHidden(sel ObjectSelection, hidden bool) {
+	var col ObjectCollection
+	col.Buildings = CopyIdxs(col.Buildings, s.Buildings, sel.BuildingIdxs)
+	for i := range col.Buildings {
+		col.Buildings[i].Hidden = hidden
+	}
+	col.Paths = CopyIdxs(col.Paths, s.Paths, sel.AnyPathIdxs())
+	for i := range col.Paths {
+		col.Paths[i].Hidden = hidden
+	}
+	col.TextBoxes = CopyIdxs(col.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+	for i := range col.TextBoxes {
+		col.TextBoxes[i].Hidden = hidden
+	}
+	log.Debug("scene.setHidden", "hidden", hidden)
+	s.ModifyObjects(sel, col)
+}
+
+// Layer is a category of scene objects that can be hidden as a whole, see [Scene.SetLayerVisible]
+type Layer int
+
+const (
+	LayerPaths Layer = 1 << iota
+	LayerBuildings
+	LayerTextBoxes
+)
+
+func (l Layer) String() string {
+	switch l {
+	case LayerPaths:
+		return "LayerPaths"
+	case LayerBuildings:
+		return "LayerBuildings"
+	case LayerTextBoxes:
+		return "LayerTextBoxes"
+	default:
+		return "Invalid"
+	}
+}
+
+// SetLayerVisible shows or hides a whole category of objects.
+//
+// Objects of a hidden layer are not drawn and cannot be hovered or selected, contrary to
+// [Scene.Hide] it is a view setting: it is neither recorded in history nor saved.
+func (s *Scene) SetLayerVisible(layer Layer, visible bool) {
+	log.Debug("scene.SetLayerVisible", "layer", layer, "visible", visible)
+	if visible {
+		s.hiddenLayers &^= layer
+	} else {
+		s.hiddenLayers |= layer
+	}
+}
+
+// IsLayerVisible returns true if the given layer is visible, see [Scene.SetLayerVisible]
+func (s Scene) IsLayerVisible(layer Layer) bool { return s.hiddenLayers&layer == 0 }
+
+// SetReferenceOrigin sets the origin displayed coordinates are relative to (world coordinates)
+//
+// It only affects the coordinates readout and typed coordinates, see [Scene.DisplayPos] and
+// [Scene.WorldPosOf]. Like layers visibility, it is a view setting: not saved nor recorded in history.
+func (s *Scene) SetReferenceOrigin(pos rl.Vector2) {
+	log.Debug("scene.SetReferenceOrigin", "pos", pos)
+	s.referenceOrigin = &pos
+}
+
+// ClearReferenceOrigin reverts displayed coordinates to the world origin
+func (s *Scene) ClearReferenceOrigin() {
+	log.Debug("scene.ClearReferenceOrigin")
+	s.referenceOrigin = nil
+}
+
+// DisplayPos returns the world position pos as displayed: relative to the reference origin (see
+// [Scene.SetReferenceOrigin]).
+func (s Scene) DisplayPos(pos rl.Vector2) rl.Vector2 {
+	if s.referenceOrigin == nil {
+		return pos
+	}
+	return pos.Subtract(*s.referenceOrigin)
+}
+
+// WorldPosOf returns the world position of displayed position pos, the inverse of [Scene.DisplayPos]
+func (s Scene) WorldPosOf(pos rl.Vector2) rl.Vector2 {
+	if s.referenceOrigin == nil {
+		return pos
+	}
+	return pos.Add(*s.referenceOrigin)
+}
+
+// WrapSelectionInTextBox adds a background text box enclosing the given selection with pad margin
+// and title content, and returns the action selecting it.
+//
+// The selection bounds are expected to be up to date. It is a no-op for empty selections, and if the
+// text box is rejected by [Scene.AddTextBox].
+func (s *Scene) WrapSelectionInTextBox(sel ObjectSelection, title string, pad float32) Action {
+	if sel.IsEmpty() {
+		log.Warn("cannot wrap selection in text box", "reason", "empty selection")
+		return nil
+	}
+	tb := TextBox{Bounds: sel.BoundsWithMargin(pad), Content: title, Background: true}
+	n := len(s.TextBoxes)
+	if s.AddTextBox(tb); len(s.TextBoxes) == n {
+		return nil
+	}
+	return selection.doInitSelection(ObjectSelection{TextBoxIdxs: []int{len(s.TextBoxes) - 1}, Bounds: tb.Bounds})
+}
+
+// reflector reflects objects across the line passing through a and b (a != b), see
+// [Scene.MirrorDuplicate] and [Scene.Mirror]. Its methods match [Scene.modifySelectedCopies].
+type reflector struct{ a, b rl.Vector2 }
+
+// rot returns the reflection of the orientation rot, rounded to the closest multiple of 90 degrees
+func (r reflector) rot(rot int32) int32 {
+	// reflection of the direction at angle rot is 2*axisAngle - rot
+	dir := r.b.Subtract(r.a)
+	axisRot := 2 * math32.Atan2(dir.Y, dir.X) * rl.Rad2deg
+	rot = int32(math32.Round((axisRot-float32(rot))/90)) * 90
+	return (rot%360 + 360) % 360
+}
+
+func (r reflector) building(_ int, b *Building) {
+	b.Pos = ReflectPoint(b.Pos, r.a, r.b)
+	b.Rot = r.rot(b.Rot)
+}
+
+// path reflects both ends, keeping the path direction
+func (r reflector) path(_ PathSel, p *Path) {
+	p.Start, p.End = ReflectPoint(p.Start, r.a, r.b), ReflectPoint(p.End, r.a, r.b)
+}
+
+// textBox reflects the bounds center, text boxes stay axis aligned
+func (r reflector) textBox(_ int, tb *TextBox) {
+	center := ReflectPoint(tb.Bounds.Center(), r.a, r.b)
+	tb.Bounds.X = center.X - tb.Bounds.Width/2
+	tb.Bounds.Y = center.Y - tb.Bounds.Height/2
+}
+
+// mirrorReflector returns the reflector across the vertical (flip is [AxisX]) or horizontal (flip
+// is [AxisY]) line through c
+func mirrorReflector(c rl.Vector2, flip Axis) reflector {
+	if flip == AxisX {
+		return reflector{a: c, b: c.Add(vec2(0, 1))}
+	}
+	return reflector{a: c, b: c.Add(vec2(1, 0))}
+}
+
+// MirrorDuplicate adds a mirrored copy of the given selection, reflected across the line passing
+// through axisA and axisB, and returns the selection of the added objects.
+//
+// Buildings cannot be mirrored, their rotation is the reflection of their orientation rounded to
+// the closest multiple of 90 degrees. Only fully selected paths are duplicated.
+//
+// No validity checks is performed.
+func (s *Scene) MirrorDuplicate(sel ObjectSelection, axisA, axisB rl.Vector2) ObjectSelection {
+	if axisA == axisB {
+		log.Warn("cannot mirror duplicate", "reason", "degenerate axis", "axis", axisA)
+		return ObjectSelection{}
+	}
+	r := reflector{a: axisA, b: axisB}
+	var col ObjectCollection
+	for _, idx := range sel.BuildingIdxs {
+		b := s.Buildings[idx]
+		r.building(idx, &b)
+		col.Buildings = append(col.Buildings, b)
+	}
+	for _, idx := range sel.FullPathIdxs() {
+		p := s.Paths[idx]
+		r.path(PathSel{Idx: idx, Start: true, End: true}, &p)
+		col.Paths = append(col.Paths, p)
+	}
+	for _, idx := range sel.TextBoxIdxs {
+		tb := s.TextBoxes[idx]
+		r.textBox(idx, &tb)
+		col.TextBoxes = append(col.TextBoxes, tb)
+	}
+	if col.IsEmpty() {
+		return ObjectSelection{}
+	}
+	s.doSceneOp(sceneOp{Type: SceneOpAdd, New: col})
+	return s.tailSelection(col)
+}
+
+// DuplicateArray adds count copies of the given selection, the k-th one translated by k times offset,
+// and returns the selection of the added objects (empty if nothing was added).
+//
+// Only fully selected paths are duplicated. All copies are added as a single operation, see
+// [Scene.AddObjects]. It is a no-op for a count of 0 or less.
+//
+// No validity checks is performed.
+func (s *Scene) DuplicateArray(sel ObjectSelection, count int, offset rl.Vector2) ObjectSelection {
+	if count <= 0 || sel.IsEmpty() {
+		log.Debug("scene.DuplicateArray", "action", "skipped", "count", count)
+		return ObjectSelection{}
+	}
+	var orig ObjectCollection
+	orig.Buildings = CopyIdxs(orig.Buildings, s.Buildings, sel.BuildingIdxs)
+	orig.Paths = CopyIdxs(orig.Paths, s.Paths, sel.FullPathIdxs())
+	orig.TextBoxes = CopyIdxs(orig.TextBoxes, s.TextBoxes, sel.TextBoxIdxs)
+
+	var col ObjectCollection
+	for k := 1; k <= count; k++ {
+		cp := orig.clone()
+		sceneOpDelta{Translate: offset.Scale(float32(k))}.applyCollection(&cp)
+		col.Buildings = append(col.Buildings, cp.Buildings...)
+		col.Paths = append(col.Paths, cp.Paths...)
+		col.TextBoxes = append(col.TextBoxes, cp.TextBoxes...)
+	}
+	if col.IsEmpty() || !s.AddObjects(col) {
+		return ObjectSelection{}
+	}
+	log.Debug("scene.DuplicateArray", "count", count, "offset", offset)
+	return s.tailSelection(col)
+}
+
+// mirrorRot returns the rotation of a building mirrored along the flip axis, see [Scene.Mirror]
+//
+// Rotations are clockwise from the X axis (Y points down):
+//   - flipping X (vertical mirror line) maps rot to 180-rot: 0 <-> 180, 90 and 270 unchanged
+//   - flipping Y (horizontal mirror line) maps rot to 360-rot: 90 <-> 270, 0 and 180 unchanged
+//
+// Mirroring twice returns the original rotation.
+func mirrorRot(rot int32, flip Axis) int32 { return mirrorReflector(rl.Vector2{}, flip).rot(rot) }
+
+// Mirror mirrors the given selection in place, across the vertical (flip is [AxisX]) or horizontal
+// (flip is [AxisY]) line through its bounds center.
+//
+// Objects are reflected as by [Scene.MirrorDuplicate], building rotations are mapped by [mirrorRot].
+// Only fully selected paths are mirrored. It is recorded as a single modify operation.
+//
+// No validity checks is performed.
+func (s *Scene) Mirror(sel ObjectSelection, flip Axis) {
+	modSel := ObjectSelection{BuildingIdxs: slices.Clone(sel.BuildingIdxs), TextBoxIdxs: slices.Clone(sel.TextBoxIdxs)}
+	for _, idx := range sel.FullPathIdxs() {
+		modSel.PathIdxs = append(modSel.PathIdxs, PathSel{Idx: idx, Start: true, End: true})
+	}
+	if modSel.IsEmpty() {
+		log.Debug("scene.Mirror", "action", "skipped", "reason", "empty selection")
+		return
+	}
+	modSel.recomputeBounds(s.ObjectCollection)
+	c := modSel.Bounds.Center()
+	r := mirrorReflector(c, flip)
+	log.Debug("scene.Mirror", "flip", flip, "center", c)
+	s.modifySelectedCopies(modSel, r.building, r.path, r.textBox)
+}
+
+// tailSelection returns the selection of the last objects of the scene, as many as in col, with its
+// bounds: the objects just added from col.
+func (s Scene) tailSelection(col ObjectCollection) ObjectSelection {
+	sel := ObjectSelection{
+		BuildingIdxs: Range(len(s.Buildings)-len(col.Buildings), len(s.Buildings)),
+		TextBoxIdxs:  Range(len(s.TextBoxes)-len(col.TextBoxes), len(s.TextBoxes)),
+	}
+	for i := len(s.Paths) - len(col.Paths); i < len(s.Paths); i++ {
+		sel.PathIdxs = append(sel.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+	}
+	sel.recomputeBounds(s.ObjectCollection)
+	return sel
+}
+
+// Undo tries to undo the last operation, and returns whether it has, the resulting selection, and the
+// action to be performed.
+//
+// The resulting selection is empty if nothing remains to be selected (e.g. undoing an addition).
+func (s *Scene) Undo() (bool, ObjectSelection, Action) {
+	if s.groupDepth > 0 {
+		log.Warn("cannot undo operation", "reason", "undo group in progress")
+		return false, ObjectSelection{}, nil
+	}
+	if s.historyPos > 0 {
+		s.historyPos-- // decrement history position
+		op := s.history[s.historyPos]
+		s.Hovered = Object{} // invalidate hovered object just in case
+		s.lastTransform = nil
+		newSel := op.undo(s)
+		s.invalidateOpCaches()
+		// will switch to [ModeSelection] or [ModeNormal] if new selection is empty
+		return true, newSel, selection.doInitSelection(newSel)
+	}
+	log.Warn("cannot undo operation", "reason", "no more operations to undo")
+	return false, ObjectSelection{}, nil
+}
+
+// Redo tries to redo the last undone operation, and returns whether it has, the resulting selection,
+// and the action to be performed.
+//
+// The resulting selection is empty if nothing remains to be selected (e.g. redoing a deletion).
+func (s *Scene) Redo() (bool, ObjectSelection, Action) {
+	if s.groupDepth > 0 {
+		log.Warn("cannot redo operation", "reason", "undo group in progress")
+		return false, ObjectSelection{}, nil
+	}
+	if s.historyPos < len(s.history) {
+		op := s.history[s.historyPos]
+		s.historyPos++       // increment history position
+		s.Hovered = Object{} // invalidate hovered object just in case
+		s.lastTransform = nil
+		newSel := op.redo(s)
+		s.invalidateOpCaches()
+		// will switch to [ModeSelection] or [ModeNormal] if new selection is empty
+		return true, newSel, selection.doInitSelection(newSel)
+	}
+	log.Warn("cannot redo operation", "reason", "no more operations to redo")
+	return false, ObjectSelection{}, nil
+}
+
+// HasUndo returns true if there are more undo operations to perform
+func (s *Scene) HasUndo() bool { return s.historyPos > 0 }
+
+// HasRedo returns true if there are more redo operations to perform
+func (s *Scene) HasRedo() bool { return s.historyPos < len(s.history) }
+
+// PeekUndo returns the type and number of affected objects of the operation [Scene.Undo] would
+// revert, and false if there is none.
+func (s *Scene) PeekUndo() (sceneOpType, int, bool) {
+	if !s.HasUndo() {
+		return "", 0, false
+	}
+	op := s.history[s.historyPos-1]
+	return op.Type, op.count(), true
+}
+
+// PeekRedo returns the type and number of affected objects of the operation [Scene.Redo] would
+// perform, and false if there is none.
+func (s *Scene) PeekRedo() (sceneOpType, int, bool) {
+	if !s.HasRedo() {
+		return "", 0, false
+	}
+	op := s.history[s.historyPos]
+	return op.Type, op.count(), true
+}
+
+// HistoryOpBounds returns the bounds of the objects touched by the history operation i, see
+// [sceneOp.AffectedBounds], and false if there are none.
+//
+// Only the last done operation (i is the history position - 1, eg. after a redo) and the last undone
+// one (i is the history position, eg. after an undo) are supported.
+func (s Scene) HistoryOpBounds(i int) (rl.Rectangle, bool) {
+	if i < 0 || i >= len(s.history) || (i != s.historyPos-1 && i != s.historyPos) {
+		return rl.Rectangle{}, false
+	}
+	return s.history[i].AffectedBounds(s, i < s.historyPos)
+}
+
+// HistoryEntry describes an operation of the scene history, see [Scene.HistoryEntries]
+type HistoryEntry struct {
+	// Type of the operation
+	Type sceneOpType
+	// Number of affected objects
+	Count int
+	// When the operation was first done, unchanged by undo / redo
+	Time time.Time
+	// Whether the operation is done (false if undone)
+	Done bool
+}
+
+// HistoryEntries returns the scene history operations, oldest first, undone operations included
+func (s *Scene) HistoryEntries() []HistoryEntry {
+	entries := make([]HistoryEntry, len(s.history))
+	for i, op := range s.history {
+		entries[i] = HistoryEntry{Type: op.Type, Count: op.count(), Time: op.Time, Done: i < s.historyPos}
+	}
+	return entries
+}
+
+// resetHistory drops every operation and marks the scene as unmodified, as a freshly loaded scene (see
+// [Scene.LoadFromJSON]), the operations would not apply to the new objects
+func (s *Scene) resetHistory() {
+	clear(s.history) // release the dropped operations objects
+	s.history = s.history[:0]
+	s.historyPos, s.savedHistoryPos = 0, 0
+	s.groupDepth, s.group = 0, nil
+	s.lastTransform = nil
+}
+
+// ClearRedo drops the undone operations, they cannot be redone anymore
+func (s *Scene) ClearRedo() {
+	if !s.HasRedo() {
+		return
+	}
+	log.Debug("scene.ClearRedo", "dropped", len(s.history)-s.historyPos)
+	clear(s.history[s.historyPos:]) // release the dropped operations objects
+	s.history = s.history[:s.historyPos]
+	if s.savedHistoryPos > s.historyPos {
+		// the saved state is not reachable anymore
+		s.savedHistoryPos = -1
+	}
+}
+
+// SetMaxHistory sets the maximum number of operations kept in history, 0 or less for no limit
+//
+// The oldest operations are dropped when exceeded, they cannot be undone anymore.
+func (s *Scene) SetMaxHistory(n int) {
+	s.maxHistory = max(n, 0)
+	log.Debug("scene.SetMaxHistory", "maxHistory", s.maxHistory)
+	s.evictHistory()
+}
+
+// evictHistory drops the oldest done operations, then the undone ones, exceeding [Scene.maxHistory]
+func (s *Scene) evictHistory() {
+	if s.maxHistory == 0 || len(s.history) <= s.maxHistory {
+		return
+	}
+	n := min(len(s.history)-s.maxHistory, s.historyPos)
+	log.Debug("scene.evictHistory", "evicted", n, "maxHistory", s.maxHistory)
+	clear(s.history[:n]) // release the evicted operations objects
+	s.history = s.history[n:]
+	s.historyPos -= n
+	if s.savedHistoryPos >= 0 {
+		s.savedHistoryPos -= n
+		if s.savedHistoryPos < 0 {
+			// the saved state is not reachable anymore
+			s.savedHistoryPos = -1
+		}
+	}
+	if len(s.history) > s.maxHistory {
+		if s.savedHistoryPos > s.maxHistory {
+			s.savedHistoryPos = -1
+		}
+		clear(s.history[s.maxHistory:])
+		s.history = s.history[:s.maxHistory]
+	}
+}
+
+// Optimize releases the memory held by the scene beyond its actual content: it drops the undone
+// operations (see [Scene.ClearRedo]), and reallocates the objects, history and metadata to their
+// actual size.
+//
+// The scene objects and the undo history are unchanged.
+func (s *Scene) Optimize() {
+	before := s.allocatedSlots()
+	s.ClearRedo()
+	s.ObjectCollection = s.ObjectCollection.clone()
+	var cloneOps func(ops []sceneOp) []sceneOp
+	cloneOps = func(ops []sceneOp) []sceneOp {
+		ops = slices.Clone(ops)
+		for i := range ops {
+			op := &ops[i]
+			op.Sel, op.Old, op.New = op.Sel.clone(), op.Old.clone(), op.New.clone()
+			if op.Ops != nil {
+				op.Ops = cloneOps(op.Ops)
+			}
+		}
+		return ops
+	}
+	s.history = cloneOps(s.history)
+	if s.metadata != nil {
+		// maps never shrink, copy into a right-sized one
+		metadata := make(map[string]string, len(s.metadata))
+		for tag, value := range s.metadata {
+			metadata[tag] = value
+		}
+		s.metadata = metadata
+	}
+	if !s.crossingsUpToDate {
+		s.crossings = nil
+	}
+	after := s.allocatedSlots()
+	log.Info("scene.Optimize", "reclaimedSlots", before-after, "allocatedSlots", after)
+}
+
+// allocatedSlots returns the total capacity of the scene objects and history slices, see [Scene.Optimize]
+func (s *Scene) allocatedSlots() int {
+	colSlots := func(col ObjectCollection) int {
+		return cap(col.Buildings) + cap(col.Paths) + cap(col.TextBoxes)
+	}
+	var opsSlots func(ops []sceneOp) int
+	opsSlots = func(ops []sceneOp) int {
+		n := cap(ops)
+		for _, op := range ops[:cap(ops)] {
+			n += colSlots(op.Old) + colSlots(op.New) + opsSlots(op.Ops)
+			n += cap(op.Sel.BuildingIdxs) + cap(op.Sel.PathIdxs) + cap(op.Sel.TextBoxIdxs)
+		}
+		return n
+	}
+	return colSlots(s.ObjectCollection) + opsSlots(s.history) + cap(s.crossings)
+}
+
+// LastOpAffecting returns the history index of the most recent done operation that added or
+// modified the given object, and whether there is one.
+//
+// Object indices are tracked back through history as add and delete operations shift them.
+// For paths, [TypePath], [TypePathStart] and [TypePathEnd] are equivalent.
+func (s *Scene) LastOpAffecting(obj Object) (int, bool) {
+	var n int
+	switch obj.Type {
+	case TypeBuilding:
+		n = len(s.Buildings)
+	case TypePath, TypePathStart, TypePathEnd:
+		n = len(s.Paths)
+	case TypeTextBox:
+		n = len(s.TextBoxes)
+	default:
+		return 0, false
+	}
+	idx := obj.Idx
+	if idx < 0 || idx >= n {
+		return 0, false
+	}
+
+	// affects replays op backward, idx and n are the object index and the objects count after op
+	var affects func(op sceneOp) bool
+	affects = func(op sceneOp) bool {
+		var selIdxs []int
+		var added int
+		switch obj.Type {
+		case TypeBuilding:
+			selIdxs, added = op.Sel.BuildingIdxs, len(op.New.Buildings)
+		case TypePath, TypePathStart, TypePathEnd:
+			if op.Type == SceneOpDelete {
+				selIdxs = op.Sel.FullPathIdxs()
+			} else {
+				selIdxs = op.Sel.AnyPathIdxs()
+			}
+			added = len(op.New.Paths)
+		case TypeTextBox:
+			selIdxs, added = op.Sel.TextBoxIdxs, len(op.New.TextBoxes)
+		}
+
+		switch op.Type {
+		case SceneOpAdd:
+			// added objects are appended
+			if idx >= n-added {
+				return true
+			}
+			n -= added
+		case SceneOpDelete:
+			// replay the undo insertions (see [SwapInsertMany]) to find the index before deletion
+			for _, k := range selIdxs {
+				if idx == k {
+					idx = n
+				}
+				n++
+			}
+		case SceneOpModify:
+			if SortedIntsIndex(selIdxs, idx) >= 0 {
+				return true
+			}
+		case SceneOpGroup:
+			for k := len(op.Ops) - 1; k >= 0; k-- {
+				if affects(op.Ops[k]) {
+					return true
+				}
+			}
+		}
+		return false
 	}
-	log.Warn("cannot undo operation", "reason", "no more operations to undo")
-	return false, nil
-}
 
-// Redo tries to redo the last undone operation, and returns whether it has, and the action to be performed.
-func (s *Scene) Redo() (bool, Action) {
-	if s.historyPos < len(s.history) {
-		op := s.history[s.historyPos]
-		s.historyPos++       // increment history position
-		s.Hovered = Object{} // invalidate hovered object just in case
-		// will switch to [ModeSelection] or [ModeNormal] if new selection is empty
-		return true, selection.doInitSelection(op.redo(s))
+	// walk history backward
+	for i := s.historyPos - 1; i >= 0; i-- {
+		if affects(s.history[i]) {
+			return i, true
+		}
 	}
-	log.Warn("cannot redo operation", "reason", "no more operations to redo")
-	return false, nil
+	return 0, false
 }
 
-// HasUndo returns true if there are more undo operations to perform
-func (s *Scene) HasUndo() bool { return s.historyPos > 0 }
-
-// HasRedo returns true if there are more redo operations to perform
-func (s *Scene) HasRedo() bool { return s.historyPos < len(s.history) }
-
 // IsModified returns true if the scene has been modified since last save
 func (s *Scene) IsModified() bool {
 	return s.historyPos != s.savedHistoryPos
@@ -359,6 +2168,19 @@ func (s *Scene) ResetModified() {
 	s.traceState("after", "ResetModified")
 }
 
+// MarkSavedAt marks the state at history position pos (the state after the first pos operations) as
+// the saved one, see [Scene.IsModified].
+//
+// pos must be between 0 and the history length, undone operations included.
+func (s *Scene) MarkSavedAt(pos int) error {
+	if pos < 0 || pos > len(s.history) {
+		return fmt.Errorf("history position %d out of range [0, %d]", pos, len(s.history))
+	}
+	s.savedHistoryPos = pos
+	log.Debug("scene.MarkSavedAt", "savedHistoryPos", s.savedHistoryPos, "historyPos", s.historyPos)
+	return nil
+}
+
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 // Scene other methods
 ////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -375,9 +2197,16 @@ func (s *Scene) ResetModified() {
 //
 // This is (mostly) the reverse of [Scene.Draw] order to make viewing/selecting masked objects easier.
 //
+// Non selected objects are looked up with the spatial index when up to date (see
+// [Scene.updateSpatialIndex]), by a linear scan otherwise.
+//
 // If no object is found, returns an zero-valued [Object]
 func (s Scene) GetObjectAt(pos rl.Vector2) Object {
-	for i := len(selection.PathIdxs) - 1; i >= 0; i-- {
+	showPaths := s.IsLayerVisible(LayerPaths)
+	showBuildings := s.IsLayerVisible(LayerBuildings)
+	showTextBoxes := s.IsLayerVisible(LayerTextBoxes)
+
+	for i := len(selection.PathIdxs) - 1; i >= 0 && showPaths; i-- {
 		elt := selection.PathIdxs[i]
 		p := s.Paths[elt.Idx]
 		if elt.Start && p.CheckStartCollisionPoint(pos) {
@@ -391,20 +2220,36 @@ func (s Scene) GetObjectAt(pos rl.Vector2) Object {
 		}
 	}
 
-	for i := len(selection.BuildingIdxs) - 1; i >= 0; i-- {
+	for i := len(selection.BuildingIdxs) - 1; i >= 0 && showBuildings; i-- {
 		if s.Buildings[selection.BuildingIdxs[i]].Bounds().CheckCollisionPoint(pos) {
 			return Object{Type: TypeBuilding, Idx: selection.BuildingIdxs[i]}
 		}
 	}
-	for i := len(selection.TextBoxIdxs) - 1; i >= 0; i-- {
+	for i := len(selection.TextBoxIdxs) - 1; i >= 0 && showTextBoxes; i-- {
 		if s.TextBoxes[selection.TextBoxIdxs[i]].Bounds.CheckCollisionPoint(pos) {
 			return Object{Type: TypeTextBox, Idx: selection.TextBoxIdxs[i]}
 		}
 	}
 
+	if s.indexUpToDate {
+		return s.index.objectAt(s, pos)
+	}
+	return s.getObjectAtLinear(pos)
+}
+
+// getObjectAtLinear is the brute-force scan of the non selected objects of [Scene.GetObjectAt],
+// used when the spatial index is outdated.
+func (s Scene) getObjectAtLinear(pos rl.Vector2) Object {
+	showPaths := s.IsLayerVisible(LayerPaths)
+	showBuildings := s.IsLayerVisible(LayerBuildings)
+	showTextBoxes := s.IsLayerVisible(LayerTextBoxes)
+
 	// TODO: do not check selected paths / buildings again ?
-	for i := len(s.Paths) - 1; i >= 0; i-- {
+	for i := len(s.Paths) - 1; i >= 0 && showPaths; i-- {
 		p := s.Paths[i]
+		if p.Hidden {
+			continue
+		}
 		if p.CheckStartCollisionPoint(pos) {
 			return Object{Type: TypePathStart, Idx: i}
 		}
@@ -416,14 +2261,14 @@ func (s Scene) GetObjectAt(pos rl.Vector2) Object {
 		}
 	}
 
-	for i := len(s.Buildings) - 1; i >= 0; i-- {
-		if s.Buildings[i].Bounds().CheckCollisionPoint(pos) {
+	for i := len(s.Buildings) - 1; i >= 0 && showBuildings; i-- {
+		if !s.Buildings[i].Hidden && s.Buildings[i].Bounds().CheckCollisionPoint(pos) {
 			return Object{Type: TypeBuilding, Idx: i}
 		}
 	}
 
-	for i := len(s.TextBoxes) - 1; i >= 0; i-- {
-		if s.TextBoxes[i].Bounds.CheckCollisionPoint(pos) {
+	for i := len(s.TextBoxes) - 1; i >= 0 && showTextBoxes; i-- {
+		if !s.TextBoxes[i].Hidden && s.TextBoxes[i].Bounds.CheckCollisionPoint(pos) {
 			return Object{Type: TypeTextBox, Idx: i}
 		}
 	}
@@ -431,16 +2276,292 @@ func (s Scene) GetObjectAt(pos rl.Vector2) Object {
 	return Object{}
 }
 
-// Update hovered object
+// GetObjectsInRectOfType returns the window selection of the objects of type t in rec, with its bounds
+//
+// Objects are selected as [Scene.ObjectsInRect] does when contained, in a single pass.
+// For [TypePathStart] (resp. [TypePathEnd]) only the paths start (resp. end) are selected.
+func (s Scene) GetObjectsInRectOfType(rec rl.Rectangle, t ObjectType) ObjectSelection {
+	return s.selectFromRectOfType(rec, t, true)
+}
+
+// ObjectsInRect returns the selection of the objects in the world rectangle r, with its bounds:
+//   - if contained, the buildings and text boxes fully inside r (window selection)
+//   - otherwise, the buildings and text boxes whose bounds intersect r (crossing selection)
+//
+// Building bounds account for their rotation. Path ends inside r are selected on their own, when
+// not contained the paths crossing r without an end inside are fully selected. Hidden objects and
+// objects of hidden layers are skipped, edges are inclusive.
+func (s Scene) ObjectsInRect(r rl.Rectangle, contained bool) ObjectSelection {
+	return s.selectFromRectOfType(r, TypeInvalid, contained)
+}
+
+// selectFromRectOfType is [Scene.ObjectsInRect] restricted to objects of type t, or every object for
+// [TypeInvalid].
+//
+// For [TypePathStart] (resp. [TypePathEnd]) only the paths start (resp. end) are selected, and
+// crossing paths are not.
+func (s Scene) selectFromRectOfType(r rl.Rectangle, t ObjectType, contained bool) ObjectSelection {
+	all := t == TypeInvalid
+	withStarts := all || t == TypePath || t == TypePathStart
+	withEnds := all || t == TypePath || t == TypePathEnd
+	in := func(bounds rl.Rectangle) bool {
+		if contained {
+			return RectContainsRect(r, bounds)
+		}
+		return bounds.X <= r.X+r.Width && r.X <= bounds.X+bounds.Width &&
+			bounds.Y <= r.Y+r.Height && r.Y <= bounds.Y+bounds.Height
+	}
+	inside := func(p rl.Vector2) bool { return RectContainsRect(r, rl.NewRectangle(p.X, p.Y, 0, 0)) }
+
+	var sel ObjectSelection
+	if (all || t == TypeBuilding) && s.IsLayerVisible(LayerBuildings) {
+		for i, b := range s.Buildings {
+			if !b.Hidden && in(b.Bounds()) {
+				sel.BuildingIdxs = append(sel.BuildingIdxs, i)
+			}
+		}
+	}
+	if (withStarts || withEnds) && s.IsLayerVisible(LayerPaths) {
+		for i, p := range s.Paths {
+			if p.Hidden {
+				continue
+			}
+			ps := PathSel{Idx: i, Start: withStarts && inside(p.Start), End: withEnds && inside(p.End)}
+			if !ps.Start && !ps.End && !contained && withStarts && withEnds && SegmentIntersectsRect(p.Start, p.End, r) {
+				ps.Start, ps.End = true, true
+			}
+			if ps.Start || ps.End {
+				sel.PathIdxs = append(sel.PathIdxs, ps)
+			}
+		}
+	}
+	if (all || t == TypeTextBox) && s.IsLayerVisible(LayerTextBoxes) {
+		for i, tb := range s.TextBoxes {
+			if !tb.Hidden && in(tb.Bounds) {
+				sel.TextBoxIdxs = append(sel.TextBoxIdxs, i)
+			}
+		}
+	}
+	sel.recomputeBounds(s.ObjectCollection)
+	log.Trace("scene.selectFromRectOfType", "rect", r, "type", t, "contained", contained, "selected", sel)
+	return sel
+}
+
+// GrowSelection returns sel extended with the visible objects whose bounds (see [Scene.ObjectBounds])
+// are within radius of sel bounds, with its bounds.
+//
+// Paths within radius are fully selected. Repeated calls flood outward. It is a no-op for empty
+// selections.
+func (s Scene) GrowSelection(sel ObjectSelection, radius float32) ObjectSelection {
+	if sel.IsEmpty() {
+		return sel
+	}
+	sel = sel.clone()
+	sel.recomputeBounds(s.ObjectCollection)
+	near := func(obj Object) bool {
+		bounds, ok := s.ObjectBounds(obj)
+		return ok && RectGap(sel.Bounds, bounds) <= radius
+	}
+
+	var grown ObjectSelection
+	for i, b := range s.Buildings {
+		obj := Object{Type: TypeBuilding, Idx: i}
+		if sel.Contains(obj) || !b.Hidden && s.IsLayerVisible(LayerBuildings) && near(obj) {
+			grown.BuildingIdxs = append(grown.BuildingIdxs, i)
+		}
+	}
+	j := 0 // index in sel.PathIdxs
+	for i, p := range s.Paths {
+		for j < len(sel.PathIdxs) && sel.PathIdxs[j].Idx < i {
+			j++
+		}
+		if !p.Hidden && s.IsLayerVisible(LayerPaths) && near(Object{Type: TypePath, Idx: i}) {
+			grown.PathIdxs = append(grown.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+		} else if j < len(sel.PathIdxs) && sel.PathIdxs[j].Idx == i {
+			grown.PathIdxs = append(grown.PathIdxs, sel.PathIdxs[j])
+		}
+	}
+	for i, tb := range s.TextBoxes {
+		obj := Object{Type: TypeTextBox, Idx: i}
+		if sel.Contains(obj) || !tb.Hidden && s.IsLayerVisible(LayerTextBoxes) && near(obj) {
+			grown.TextBoxIdxs = append(grown.TextBoxIdxs, i)
+		}
+	}
+	grown.recomputeBounds(s.ObjectCollection)
+	log.Debug("scene.GrowSelection", "radius", radius, "before", sel, "after", grown)
+	return grown
+}
+
+// SelectAll returns the selection of every selectable object of the scene, with its bounds: hidden
+// objects and the objects of hidden layers are skipped, as by [Scene.InvertSelection].
+//
+// Use [ObjectCollection.SelectAll] to select every object.
+func (s Scene) SelectAll() ObjectSelection { return s.SelectAllOfType(TypeInvalid) }
+
+// SelectAllOfType returns the selection of every selectable object of the given type (all types for
+// [TypeInvalid]), see [Scene.SelectAll] and [ObjectCollection.SelectAllOfType].
+func (s Scene) SelectAllOfType(t ObjectType) ObjectSelection {
+	all := t == TypeInvalid
+	sel := s.InvertSelection(ObjectSelection{})
+	if !all && t != TypeBuilding {
+		sel.BuildingIdxs = nil
+	}
+	if !all && t != TypePath && t != TypePathStart && t != TypePathEnd {
+		sel.PathIdxs = nil
+	}
+	if !all && t != TypeTextBox {
+		sel.TextBoxIdxs = nil
+	}
+	sel.Bounds = rl.Rectangle{}
+	if !sel.IsEmpty() {
+		sel.recomputeBounds(s.ObjectCollection)
+	}
+	return sel
+}
+
+// InvertSelection returns the complement of sel among the visible objects, with its bounds
+//
+// Paths not fully selected (neither end, or only one) are fully selected in the complement, fully
+// selected paths are not. Hidden objects and objects of hidden layers are never selected.
+func (s Scene) InvertSelection(sel ObjectSelection) ObjectSelection {
+	var inv ObjectSelection
+	if s.IsLayerVisible(LayerBuildings) {
+		for i, b := range s.Buildings {
+			if !b.Hidden && SortedIntsIndex(sel.BuildingIdxs, i) < 0 {
+				inv.BuildingIdxs = append(inv.BuildingIdxs, i)
+			}
+		}
+	}
+	if s.IsLayerVisible(LayerPaths) {
+		full := sel.FullPathIdxs()
+		for i, p := range s.Paths {
+			if !p.Hidden && SortedIntsIndex(full, i) < 0 {
+				inv.PathIdxs = append(inv.PathIdxs, PathSel{Idx: i, Start: true, End: true})
+			}
+		}
+	}
+	if s.IsLayerVisible(LayerTextBoxes) {
+		for i, tb := range s.TextBoxes {
+			if !tb.Hidden && SortedIntsIndex(sel.TextBoxIdxs, i) < 0 {
+				inv.TextBoxIdxs = append(inv.TextBoxIdxs, i)
+			}
+		}
+	}
+	if !inv.IsEmpty() {
+		inv.recomputeBounds(s.ObjectCollection)
+	}
+	log.Debug("scene.InvertSelection", "before", sel, "after", inv)
+	return inv
+}
+
+// ObjectBounds returns the world bounding box of the given object, and false for an empty or
+// out of range object.
+//
+// Paths bounds include their width, path ends bounds are their end circle bounds.
+func (s Scene) ObjectBounds(obj Object) (rl.Rectangle, bool) {
+	switch obj.Type {
+	case TypeBuilding:
+		if obj.Idx >= 0 && obj.Idx < len(s.Buildings) {
+			return s.Buildings[obj.Idx].Bounds(), true
+		}
+	case TypePath, TypePathStart, TypePathEnd:
+		if obj.Idx < 0 || obj.Idx >= len(s.Paths) {
+			break
+		}
+		p := s.Paths[obj.Idx]
+		radius := p.Def().Width / 2
+		switch obj.Type {
+		case TypePathStart:
+			return GrowRect(rl.NewRectangleV(p.Start, rl.Vector2{}), radius), true
+		case TypePathEnd:
+			return GrowRect(rl.NewRectangleV(p.End, rl.Vector2{}), radius), true
+		default:
+			return GrowRect(rl.NewRectangleCorners(p.Start, p.End), radius), true
+		}
+	case TypeTextBox:
+		if obj.Idx >= 0 && obj.Idx < len(s.TextBoxes) {
+			return s.TextBoxes[obj.Idx].Bounds, true
+		}
+	}
+	return rl.Rectangle{}, false
+}
+
+// ObjectScreenRect returns the screen rectangle of the given object bounds (see [Scene.ObjectBounds])
+// as seen by cam, and false for an empty object.
+func (s Scene) ObjectScreenRect(obj Object, cam Camera) (rl.Rectangle, bool) {
+	bounds, ok := s.ObjectBounds(obj)
+	if !ok {
+		return rl.Rectangle{}, false
+	}
+	// the camera may flip or rotate axes: use the 4 corners
+	corners := [4]rl.Vector2{bounds.TopLeft(), bounds.TopRight(), bounds.BottomLeft(), bounds.BottomRight()}
+	tl, br := cam.ScreenPos(corners[0]), cam.ScreenPos(corners[0])
+	for _, c := range corners[1:] {
+		p := cam.ScreenPos(c)
+		tl = vec2(min(tl.X, p.X), min(tl.Y, p.Y))
+		br = vec2(max(br.X, p.X), max(br.Y, p.Y))
+	}
+	return rl.NewRectangleCorners(tl, br), true
+}
+
+// isObjectAt returns true if obj is a visible object under pos, with the same hit test as [Scene.GetObjectAt]
+func (s Scene) isObjectAt(obj Object, pos rl.Vector2) bool {
+	switch obj.Type {
+	case TypeBuilding:
+		return obj.Idx < len(s.Buildings) && !s.Buildings[obj.Idx].Hidden && s.IsLayerVisible(LayerBuildings) &&
+			s.Buildings[obj.Idx].Bounds().CheckCollisionPoint(pos)
+	case TypePath, TypePathStart, TypePathEnd:
+		if obj.Idx >= len(s.Paths) || s.Paths[obj.Idx].Hidden || !s.IsLayerVisible(LayerPaths) {
+			return false
+		}
+		p := s.Paths[obj.Idx]
+		switch obj.Type {
+		case TypePathStart:
+			return p.CheckStartCollisionPoint(pos)
+		case TypePathEnd:
+			return p.CheckEndCollisionPoint(pos)
+		default:
+			return p.CheckCollisionPoint(pos)
+		}
+	case TypeTextBox:
+		return obj.Idx < len(s.TextBoxes) && !s.TextBoxes[obj.Idx].Hidden && s.IsLayerVisible(LayerTextBoxes) &&
+			s.TextBoxes[obj.Idx].Bounds.CheckCollisionPoint(pos)
+	default:
+		return false
+	}
+}
+
+// updateHovered updates the hovered object with hysteresis: the hovered object is kept as long as it
+// is under the mouse, to avoid flickering between stacked objects.
+//
+// Path ends always take precedence, as they are smaller than the objects they overlap.
+func (s *Scene) updateHovered() {
+	hovered := s.GetObjectAt(mouse.Pos)
+	if hovered.Type != TypePathStart && hovered.Type != TypePathEnd && s.isObjectAt(s.Hovered, mouse.Pos) {
+		return
+	}
+	s.Hovered = hovered
+}
+
+// Update hovered object and cached data
 func (s *Scene) Update() (action Action) {
-	s.Hovered = s.GetObjectAt(mouse.Pos)
+	s.updateSpatialIndex()
+	s.updateHovered()
+	s.updateCrossings()
+	if highlightOverlaps {
+		s.updateOverlaps()
+	}
 
 	if app.isNormal() && keyboard.Ctrl {
 		switch keyboard.Binding() {
 		case BindingUndo:
-			_, action = s.Undo()
+			_, _, action = s.Undo()
 		case BindingRedo:
-			_, action = s.Redo()
+			_, _, action = s.Redo()
+		case BindingCopy:
+			action = app.doCopy()
+		case BindingPaste:
+			action = app.doPaste()
 		}
 	}
 	return action
@@ -463,73 +2584,44 @@ func (s Scene) IsPathValid(path Path) bool {
 	return !path.Start.Equals(path.End)
 }
 
-// draws the scene objects accounting for selection / selector
+// draws the scene objects accounting for selection / selector, see [drawStateCache]
 func (s Scene) drawWithSel() {
-	var state DrawState
-	var buildingIt MaskIterator
-	var pathIt PathSelMaskIterator
-	var textBoxIt MaskIterator
-	if app.Mode == ModeSelection {
-		buildingIt = selection.BuildingsIterator()
-		pathIt = selection.PathsIterator()
-		textBoxIt = selection.TextBoxesIterator()
-		switch selection.mode {
-		case SelectionNormal, SelectionSingleTextBox:
-			state = DrawSkip
-		case SelectionDrag, SelectionTextBoxResize:
-			state = DrawShadow
-		case SelectionDuplicate:
-			state = DrawClicked
-		}
-	} else {
-		buildingIt = selector.BuildingsIterator()
-		pathIt = selector.PathsIterator()
-		textBoxIt = selector.TextBoxesIterator()
-		state = DrawSkip
-	}
-
-	if app.Mode == ModeSelection && selection.mode == SelectionDrag {
-		// in drag mode, draw the whole path as shadow
-		for _, p := range s.Paths {
-			start, end := pathIt.Next()
-			if start || end {
-				p.Draw(state)
-			} else {
-				p.Draw(DrawNormal)
+	drawStates.update(s)
+	ds := &drawStates
+
+	// background text boxes first
+	showTextBoxes := s.IsLayerVisible(LayerTextBoxes)
+	if showTextBoxes {
+		for i, b := range s.TextBoxes {
+			if b.Background {
+				b.Draw(ds.textBoxes[i], false)
 			}
 		}
-	} else {
-		for _, b := range s.Paths {
-			start, end := pathIt.Next()
-			if start {
-				b.DrawStart(state)
-			} else {
-				b.DrawStart(DrawNormal)
-			}
-			if end {
-				b.DrawEnd(state)
-			} else {
-				b.DrawEnd(DrawNormal)
+	}
+
+	if s.IsLayerVisible(LayerPaths) {
+		if ds.wholePaths {
+			for i, p := range s.Paths {
+				p.Draw(ds.pathBodies[i])
 			}
-			if start && end {
-				b.DrawBody(state)
-			} else {
-				b.DrawBody(DrawNormal)
+		} else {
+			for i, p := range s.Paths {
+				p.DrawStart(ds.pathStarts[i])
+				p.DrawEnd(ds.pathEnds[i])
+				p.DrawBody(ds.pathBodies[i])
 			}
 		}
 	}
-	for _, b := range s.Buildings {
-		if buildingIt.Next() {
-			b.Draw(state)
-		} else {
-			b.Draw(DrawNormal)
+	if s.IsLayerVisible(LayerBuildings) {
+		for i, b := range s.Buildings {
+			b.Draw(ds.buildings[i])
 		}
 	}
-	for _, b := range s.TextBoxes {
-		if textBoxIt.Next() {
-			b.Draw(state, false)
-		} else {
-			b.Draw(DrawNormal, false)
+	if showTextBoxes {
+		for i, b := range s.TextBoxes {
+			if !b.Background {
+				b.Draw(ds.textBoxes[i], false)
+			}
 		}
 	}
 }
@@ -547,10 +2639,16 @@ func (s Scene) drawSelSkipped() {
 	}
 
 	for _, idx := range sel.BuildingIdxs {
+		if !s.IsLayerVisible(LayerBuildings) {
+			break
+		}
 		s.Buildings[idx].Draw(state)
 	}
 
 	for _, elt := range sel.PathIdxs {
+		if !s.IsLayerVisible(LayerPaths) {
+			break
+		}
 		p := s.Paths[elt.Idx]
 		if elt.Start && elt.End {
 			p.DrawBody(state)
@@ -564,23 +2662,43 @@ func (s Scene) drawSelSkipped() {
 	}
 
 	for _, idx := range sel.TextBoxIdxs {
+		if !s.IsLayerVisible(LayerTextBoxes) {
+			break
+		}
 		s.TextBoxes[idx].Draw(state, selection.mode == SelectionSingleTextBox)
 	}
 }
 
 // Draw scene objects
 func (s Scene) Draw() {
+	if s.explode != nil {
+		s.ObjectCollection = s.explodedObjects() // s is a copy, the scene is unchanged
+	}
+	// reference layout behind everything
+	drawReference()
 	if app.Mode == ModeSelection || app.Mode == ModeNormal && selector.selecting {
 		s.drawWithSel()
 	} else {
-		for _, b := range s.Paths {
-			b.Draw(DrawNormal)
+		showTextBoxes := s.IsLayerVisible(LayerTextBoxes)
+		for _, b := range s.TextBoxes {
+			if showTextBoxes && b.Background {
+				b.Draw(DrawNormal, false)
+			}
+		}
+		if s.IsLayerVisible(LayerPaths) {
+			for _, b := range s.Paths {
+				b.Draw(DrawNormal)
+			}
 		}
-		for _, b := range s.Buildings {
-			b.Draw(DrawNormal)
+		if s.IsLayerVisible(LayerBuildings) {
+			for _, b := range s.Buildings {
+				b.Draw(DrawNormal)
+			}
 		}
 		for _, b := range s.TextBoxes {
-			b.Draw(DrawNormal, false)
+			if showTextBoxes && !b.Background {
+				b.Draw(DrawNormal, false)
+			}
 		}
 	}
 
@@ -590,6 +2708,12 @@ func (s Scene) Draw() {
 		s.drawSelSkipped()
 	}
 
+	// highlight path crossings
+	s.drawCrossings()
+	if highlightOverlaps {
+		s.drawOverlaps()
+	}
+
 	// draw hovered object
 	if !s.Hovered.IsEmpty() {
 		if app.Mode == ModeNormal && !selector.selecting {
@@ -611,53 +2735,166 @@ func (s Scene) Draw() {
 const (
 	tagVersion   = "#VERSION"
 	textboxClass = "TextBox"
+
+	// metadata tags (since version 1), values are space separated object indices
+	tagHiddenBuildings     = "#HIDDEN_BUILDINGS"
+	tagHiddenPaths         = "#HIDDEN_PATHS"
+	tagHiddenTextBoxes     = "#HIDDEN_TEXTBOXES"
+	tagBackgroundTextBoxes = "#BACKGROUND_TEXTBOXES"
 )
 
-// SaveToText saves the scene into text format.
+// FormatVersion returns the save file format version the scene was last loaded from or saved to
+//
+// A scene loaded from an older format is upgraded to the current format on save, in which case
+// FormatVersion is lower than the current version until it is saved.
+func (s *Scene) FormatVersion() int { return s.formatVersion }
+
+// SaveToText saves the scene into text format, always using the current format version.
 //
 // All errors originate from the underlying [io.Writer].
 func (s *Scene) SaveToText(w io.Writer) error {
-	// // bufSize is kind of low estimation of actual size of the save
-	// //   - version line is minimum 10 chars + '\n'
-	// //   - the minimum building line is 7 chars + '\n'
-	// //   - the minimum path line is 10 chars + '\n'
-	// //
-	// // Most of the actual lines will be longer as classes are more than 1 char long
-	// // and numbers will have multiple digits.
-	// bufSize := 10 * (len(s.Paths) + len(s.Buildings) + 1)
-	// br := bufio.NewWriterSize(w, bufSize)
-	br := bufio.NewWriter(w)
-	defer br.Flush()
-	// version
-	_, err := br.WriteString(fmt.Sprintf("%s=%d\n", tagVersion, version))
-	if err != nil {
-		return err
-	}
-	// buildings
+	sw := NewSceneTextWriter(w)
+	sw.WriteHeader(version)
 	for _, b := range s.Buildings {
-		_, err := br.WriteString(fmt.Sprintf("%s %v %v %d\n", b.Def().Class, b.Pos.X, b.Pos.Y, b.Rot))
-		if err != nil {
-			return err
-		}
+		sw.WriteBuilding(b)
 	}
-	// paths
 	for _, p := range s.Paths {
-		_, err := br.WriteString(fmt.Sprintf("%s %v %v %v %v\n",
-			p.Def().Class, p.Start.X, p.Start.Y, p.End.X, p.End.Y))
-		if err != nil {
-			return err
-		}
+		sw.WritePath(p)
 	}
-	// textboxes
 	for _, tb := range s.TextBoxes {
-		_, err := br.WriteString(fmt.Sprintf("%s %v %v %v %v %v\n",
-			textboxClass, tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height,
-			strconv.Quote(tb.Content)))
+		sw.WriteTextBox(tb)
+	}
+	sw.metadata = s.metadata
+	if err := sw.Close(); err != nil {
+		return err
+	}
+	s.formatVersion = version
+	return nil
+}
+
+// SceneTextWriter writes a scene in text format one object at a time, without holding the whole
+// scene in memory, see [NewSceneTextWriter].
+//
+// Objects can be written in any order, but the output is identical to [Scene.SaveToText] only if
+// the buildings are written first, then the paths, then the text boxes.
+//
+// Errors are sticky: once a write has failed, every following call returns the same error.
+type SceneTextWriter struct {
+	w   *bufio.Writer
+	err error
+	// number of objects written so far, by type
+	numBuildings, numPaths, numTextBoxes int
+	// objects indices metadata, written on close
+	hiddenBuildings, hiddenPaths, hiddenTextBoxes, bgTextBoxes []int
+	// unknown metadata entries (tag -> value), written on close
+	metadata map[string]string
+}
+
+// NewSceneTextWriter returns a [SceneTextWriter] writing to w
+//
+// [SceneTextWriter.WriteHeader] must be called first, and [SceneTextWriter.Close] last.
+func NewSceneTextWriter(w io.Writer) *SceneTextWriter {
+	return &SceneTextWriter{w: bufio.NewWriter(w)}
+}
+
+// write writes a formatted line, unless a previous write has failed
+func (sw *SceneTextWriter) write(format string, args ...any) error {
+	if sw.err == nil {
+		_, sw.err = sw.w.WriteString(fmt.Sprintf(format, args...))
+	}
+	return sw.err
+}
+
+// WriteHeader writes the format version line
+func (sw *SceneTextWriter) WriteHeader(version int) error {
+	return sw.write("%s=%d\n", tagVersion, version)
+}
+
+// WriteBuilding writes a building line
+func (sw *SceneTextWriter) WriteBuilding(b Building) error {
+	if b.Hidden {
+		sw.hiddenBuildings = append(sw.hiddenBuildings, sw.numBuildings)
+	}
+	sw.numBuildings++
+	return sw.write("%s %v %v %d\n", b.Def().Class, b.Pos.X, b.Pos.Y, b.Rot)
+}
+
+// WritePath writes a path line
+func (sw *SceneTextWriter) WritePath(p Path) error {
+	if p.Hidden {
+		sw.hiddenPaths = append(sw.hiddenPaths, sw.numPaths)
+	}
+	sw.numPaths++
+	return sw.write("%s %v %v %v %v\n", p.Def().Class, p.Start.X, p.Start.Y, p.End.X, p.End.Y)
+}
+
+// WriteTextBox writes a text box line
+func (sw *SceneTextWriter) WriteTextBox(tb TextBox) error {
+	if tb.Hidden {
+		sw.hiddenTextBoxes = append(sw.hiddenTextBoxes, sw.numTextBoxes)
+	}
+	if tb.Background {
+		sw.bgTextBoxes = append(sw.bgTextBoxes, sw.numTextBoxes)
+	}
+	sw.numTextBoxes++
+	return sw.write("%s %v %v %v %v %v\n", textboxClass, tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width,
+		tb.Bounds.Height, strconv.Quote(tb.Content))
+}
+
+// Close writes the metadata lines and flushes the output, it does not close the underlying
+// [io.Writer].
+func (sw *SceneTextWriter) Close() error {
+	for _, meta := range []struct {
+		tag  string
+		idxs []int
+	}{
+		{tagHiddenBuildings, sw.hiddenBuildings},
+		{tagHiddenPaths, sw.hiddenPaths},
+		{tagHiddenTextBoxes, sw.hiddenTextBoxes},
+		{tagBackgroundTextBoxes, sw.bgTextBoxes},
+	} {
+		if len(meta.idxs) > 0 {
+			sw.write("%s=%s\n", meta.tag, formatIdxs(meta.idxs))
+		}
+	}
+	// unknown metadata, sorted for reproducible saves
+	tags := make([]string, 0, len(sw.metadata))
+	for tag := range sw.metadata {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+	for _, tag := range tags {
+		sw.write("%s=%s\n", tag, sw.metadata[tag])
+	}
+	if sw.err == nil {
+		sw.err = sw.w.Flush()
+	}
+	return sw.err
+}
+
+// formatIdxs formats indices as a space separated list
+func formatIdxs(idxs []int) string {
+	strs := make([]string, len(idxs))
+	for i, idx := range idxs {
+		strs[i] = strconv.Itoa(idx)
+	}
+	return strings.Join(strs, " ")
+}
+
+// parseIdxs parses a space separated list of non-negative indices
+func parseIdxs(s string) ([]int, error) {
+	var idxs []int
+	for _, field := range strings.Fields(s) {
+		idx, err := strconv.Atoi(field)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		if idx < 0 {
+			return nil, fmt.Errorf("negative index %d", idx)
+		}
+		idxs = append(idxs, idx)
 	}
-	return nil
+	return idxs, nil
 }
 
 type DecodeTextError struct {
@@ -676,6 +2913,37 @@ const (
 	msgInvalidBuilding      = "invalid building line expected '[class] [posX] [posY] [rotation]'"
 	msgInvalidTextBox       = "invalid textbox line expected '[class] [posX] [posY] [width] [height] [content]'"
 	msgInvalidClass         = "unknown class"
+	msgCannotRead           = "cannot read file"
+	msgInvalidMetadata      = "invalid metadata line expected '#[TAG]=[value]'"
+	msgTooManyObjects       = "too many objects, limit is %d (see SetMaxObjects)"
+
+	// lenient mode warnings
+	msgInvalidTextBoxContent = "invalid textbox content, loaded as literal string"
+)
+
+// errNotFinite is the underlying error of number fields being NaN or infinite
+var errNotFinite = errors.New("non-finite number")
+
+// defaultMaxObjects is the default maximum number of objects of a scene, see [SetMaxObjects]
+const defaultMaxObjects = 1_000_000
+
+// maxObjects is the maximum number of objects of a scene, see [SetMaxObjects]
+var maxObjects = defaultMaxObjects
+
+// SetMaxObjects sets the maximum number of objects (buildings, paths and text boxes) a scene can
+// hold when loading a file or adding objects with [Scene.AddObjects], 0 means unlimited.
+//
+// Loading a file exceeding the limit fails with a [DecodeTextError].
+func SetMaxObjects(n int) { maxObjects = max(n, 0) }
+
+// DecodeMode controls how [Scene.LoadFromTextMode] handles recoverable errors
+type DecodeMode int
+
+const (
+	// Any error aborts the load
+	DecodeStrict DecodeMode = iota
+	// Recoverable errors (eg. invalid textbox content) are reported as warnings
+	DecodeLenient
 )
 
 func (e DecodeTextError) Error() string {
@@ -685,38 +2953,139 @@ func (e DecodeTextError) Error() string {
 	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
 }
 
+// LoadFromText loads the scene from text format, see [Scene.LoadFromTextMode] in [DecodeStrict] mode.
 func (s *Scene) LoadFromText(r io.Reader) error {
+	_, err := s.LoadFromTextMode(r, DecodeStrict)
+	return err
+}
+
+// LoadFromTextMode loads the scene, in [DecodeLenient] mode recoverable errors are returned as
+// warnings instead of aborting the load.
+//
+// The returned warnings are nil in [DecodeStrict] mode or if loading fails.
+//
+// Decoding never panics on arbitrary input: every error is a [DecodeTextError], read errors of r
+// included (wrapped in [DecodeTextError.Err]).
+func (s *Scene) LoadFromTextMode(r io.Reader, mode DecodeMode) ([]DecodeTextError, error) {
 	scanner := bufio.NewScanner(r)
 	scanner.Scan()
 	line := scanner.Text()
 	if err := scanner.Err(); err != nil {
-		return err
+		return nil, DecodeTextError{Msg: msgCannotRead, Line: 1, Err: err}
 	}
 	if len(line) == 0 {
-		return DecodeTextError{Msg: msgEmpty}
+		return nil, DecodeTextError{Msg: msgEmpty}
 	}
 	// parse version
 	var ver int
 	if _, err := fmt.Sscanf(string(line), tagVersion+"=%d", &ver); err != nil {
-		return DecodeTextError{Msg: msgInvalidVersionLine, Line: 1, Err: err}
+		return nil, DecodeTextError{Msg: msgInvalidVersionLine, Line: 1, Err: err}
 	}
 	if ver < 0 {
-		return DecodeTextError{Msg: msgInvalidVersionNumber, Line: 1}
+		return nil, DecodeTextError{Msg: msgInvalidVersionNumber, Line: 1}
 	}
+	s.formatVersion = ver
 	// call version specific function
 	switch ver {
-	case 0:
-		return s.decodeText(scanner, ver)
+	case 0, 1:
+		return s.decodeText(scanner, ver, mode)
+	default:
+		return nil, DecodeTextError{Msg: msgVersionTooHigh, Version: ver, Line: 1}
+	}
+}
+
+// LoadFromTextValidated loads the scene with [Scene.LoadFromText] then runs [Scene.Validate]
+//
+// Semantic issues never block loading, they are only returned for the caller to report.
+// The returned issues are nil if loading fails.
+func (s *Scene) LoadFromTextValidated(r io.Reader) ([]SceneIssue, error) {
+	_, issues, err := s.LoadFromTextValidatedMode(r, DecodeStrict)
+	return issues, err
+}
+
+// LoadFromTextValidatedMode is [Scene.LoadFromTextValidated] with the decoding mode of
+// [Scene.LoadFromTextMode], whose warnings it also returns
+func (s *Scene) LoadFromTextValidatedMode(r io.Reader, mode DecodeMode) ([]DecodeTextError, []SceneIssue, error) {
+	warnings, err := s.LoadFromTextMode(r, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return warnings, s.Validate(), nil
+}
+
+// FileFormat enumerates the save file formats recognized by [SniffFormat]
+type FileFormat int
+
+const (
+	FormatUnknown FileFormat = iota
+	// Text format, see [Scene.SaveToText]
+	FormatText
+	// JSON format, see [Scene.SaveToJSON]
+	FormatJSON
+	// Binary format (not supported yet), starts with [binaryMagic]
+	FormatBinary
+)
+
+func (f FileFormat) String() string {
+	switch f {
+	case FormatText:
+		return "text"
+	case FormatJSON:
+		return "JSON"
+	case FormatBinary:
+		return "binary"
+	default:
+		return "unknown"
+	}
+}
+
+const (
+	// magic bytes starting binary save files
+	binaryMagic = "\x00SATISFIED"
+	// number of bytes looked at by [SniffFormat]
+	sniffSize = 512
+)
+
+// SniffFormat returns the format of the save file read by br, without consuming any byte
+func SniffFormat(br *bufio.Reader) FileFormat {
+	head, _ := br.Peek(sniffSize) // a short read is not an error here
+	switch {
+	case bytes.HasPrefix(head, []byte(tagVersion)):
+		return FormatText
+	case bytes.HasPrefix(head, []byte(binaryMagic)):
+		return FormatBinary
+	case bytes.HasPrefix(bytes.TrimLeft(head, " \t\r\n"), []byte("{")):
+		return FormatJSON
+	default:
+		return FormatUnknown
+	}
+}
+
+// Load loads the scene from any supported format, detected with [SniffFormat]
+//
+// Use the format specific functions (eg. [Scene.LoadFromText]) when the format is known.
+func (s *Scene) Load(r io.Reader) error {
+	br := bufio.NewReaderSize(r, sniffSize)
+	switch format := SniffFormat(br); format {
+	case FormatText:
+		return s.LoadFromText(br)
+	case FormatJSON:
+		return s.LoadFromJSON(br)
+	case FormatUnknown:
+		return errors.New("unknown file format")
 	default:
-		return DecodeTextError{Msg: msgVersionTooHigh, Version: ver, Line: 1}
+		return fmt.Errorf("unsupported file format: %s", format)
 	}
 }
 
-func (s *Scene) decodeText(scanner *bufio.Scanner, ver int) error {
+func (s *Scene) decodeText(scanner *bufio.Scanner, ver int, mode DecodeMode) ([]DecodeTextError, error) {
 	no := 2
 	var (
-		p Path
-		b Building
+		p        Path
+		b        Building
+		warnings []DecodeTextError
+		// object indices metadata, applied once every object is loaded
+		metaIdxs []metadataIdxs
 	)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -724,51 +3093,121 @@ func (s *Scene) decodeText(scanner *bufio.Scanner, ver int) error {
 			continue
 		}
 		class, fields, _ := strings.Cut(line, " ")
-		if class == textboxClass {
+		if line[0] == '#' {
+			tag, value, _ := strings.Cut(line, "=")
+			switch tag {
+			case tagHiddenBuildings, tagHiddenPaths, tagHiddenTextBoxes, tagBackgroundTextBoxes:
+				idxs, err := parseIdxs(value)
+				if err != nil {
+					return nil, DecodeTextError{Msg: msgInvalidMetadata, Line: no, Err: err, Version: ver}
+				}
+				metaIdxs = append(metaIdxs, metadataIdxs{tag: tag, idxs: idxs, line: no})
+			case "#", tagVersion:
+				return nil, DecodeTextError{Msg: msgInvalidMetadata, Line: no, Version: ver}
+			default:
+				// unknown metadata (eg. from a newer version): preserved as is
+				if s.metadata == nil {
+					s.metadata = map[string]string{}
+				}
+				s.metadata[tag] = value
+			}
+		} else if maxObjects > 0 && s.objectCount() >= maxObjects {
+			return nil, DecodeTextError{Msg: fmt.Sprintf(msgTooManyObjects, maxObjects), Line: no, Version: ver}
+		} else if class == textboxClass {
 			var tb TextBox
 			var err error
 			elts := strings.SplitN(fields, " ", 5)
 			if len(elts) != 5 {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Version: ver}
 			}
 			tb.Bounds.X, err = ParseFloat32(elts[0])
 			if err != nil {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
 			}
 			tb.Bounds.Y, err = ParseFloat32(elts[1])
 			if err != nil {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
 			}
 			tb.Bounds.Width, err = ParseFloat32(elts[2])
 			if err != nil {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
 			}
 			tb.Bounds.Height, err = ParseFloat32(elts[3])
 			if err != nil {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+			}
+			if !AllFinite(tb.Bounds.X, tb.Bounds.Y, tb.Bounds.Width, tb.Bounds.Height) {
+				return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: errNotFinite, Version: ver}
 			}
 			tb.Content, err = strconv.Unquote(elts[4])
 			if err != nil {
-				return DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				if mode != DecodeLenient {
+					return nil, DecodeTextError{Msg: msgInvalidTextBox, Line: no, Err: err, Version: ver}
+				}
+				// recover: use the raw content without its surrounding quotes
+				tb.Content = strings.TrimSuffix(strings.TrimPrefix(elts[4], `"`), `"`)
+				warnings = append(warnings, DecodeTextError{Msg: msgInvalidTextBoxContent, Line: no, Err: err, Version: ver})
 			}
 			s.TextBoxes = append(s.TextBoxes, tb)
 		} else if defIdx := pathDefs.Index(string(class)); defIdx >= 0 {
 			p.DefIdx = defIdx
 			if _, err := fmt.Sscanf(fields, "%f %f %f %f", &p.Start.X, &p.Start.Y, &p.End.X, &p.End.Y); err != nil {
-				return DecodeTextError{Msg: msgInvalidPath, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidPath, Line: no, Err: err, Version: ver}
+			}
+			if !AllFinite(p.Start.X, p.Start.Y, p.End.X, p.End.Y) {
+				return nil, DecodeTextError{Msg: msgInvalidPath, Line: no, Err: errNotFinite, Version: ver}
 			}
 			s.Paths = append(s.Paths, p)
 		} else if defIdx := buildingDefs.Index(string(class)); defIdx >= 0 {
 			b.DefIdx = defIdx
 			if _, err := fmt.Sscanf(fields, "%f %f %d", &b.Pos.X, &b.Pos.Y, &b.Rot); err != nil {
-				return DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: err, Version: ver}
+				return nil, DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: err, Version: ver}
+			}
+			if !AllFinite(b.Pos.X, b.Pos.Y) {
+				return nil, DecodeTextError{Msg: msgInvalidBuilding, Line: no, Err: errNotFinite, Version: ver}
 			}
 			s.Buildings = append(s.Buildings, b)
 		} else {
-			return DecodeTextError{Msg: msgInvalidClass, Line: no, Version: ver}
+			return nil, DecodeTextError{Msg: msgInvalidClass, Line: no, Version: ver}
 		}
 		no++
 	}
+	if err := scanner.Err(); err != nil {
+		return nil, DecodeTextError{Msg: msgCannotRead, Line: no, Err: err, Version: ver}
+	}
+	for _, meta := range metaIdxs {
+		for _, idx := range meta.idxs {
+			var ok bool
+			switch meta.tag {
+			case tagHiddenBuildings:
+				if ok = idx < len(s.Buildings); ok {
+					s.Buildings[idx].Hidden = true
+				}
+			case tagHiddenPaths:
+				if ok = idx < len(s.Paths); ok {
+					s.Paths[idx].Hidden = true
+				}
+			case tagHiddenTextBoxes:
+				if ok = idx < len(s.TextBoxes); ok {
+					s.TextBoxes[idx].Hidden = true
+				}
+			case tagBackgroundTextBoxes:
+				if ok = idx < len(s.TextBoxes); ok {
+					s.TextBoxes[idx].Background = true
+				}
+			}
+			if !ok {
+				err := fmt.Errorf("index %d out of range", idx)
+				return nil, DecodeTextError{Msg: msgInvalidMetadata, Line: meta.line, Err: err, Version: ver}
+			}
+		}
+	}
+	return warnings, nil
+}
 
-	return nil
+// metadataIdxs is a decoded object indices metadata line
+type metadataIdxs struct {
+	tag  string
+	idxs []int
+	line int
 }
@@ -0,0 +1,765 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const sampleText = `#VERSION=1
+Assembler 10 20 90
+Belt 0 0 10 0
+Pipe 1.5 2.5 -3 4
+TextBox 0 0 20 10 "hello\nworld"
+#HIDDEN_PATHS=1
+#BACKGROUND_TEXTBOXES=0
+`
+
+// FuzzLoadFromText checks that decoding arbitrary input never panics, only returns
+// [DecodeTextError] errors, and that successfully loaded scenes round-trip through [Scene.SaveToText].
+func FuzzLoadFromText(f *testing.F) {
+	f.Add([]byte(sampleText))
+	f.Add([]byte(""))
+	f.Add([]byte("#VERSION=0\n"))
+	f.Add([]byte("#VERSION=-1\n"))
+	f.Add([]byte("#VERSION=99\nBelt 0 0 1 1\n"))
+	f.Add([]byte("#VERSION=0\nBelt NaN 0 Inf 1\n"))
+	f.Add([]byte("#VERSION=0\nAssembler 1 2\n"))
+	f.Add([]byte("#VERSION=0\nTextBox 0 0 1 1 \"\\q\"\n"))
+	f.Add([]byte("#VERSION=0\nTextBox 0 0 1\n"))
+	f.Add([]byte("#VERSION=0\n" + strings.Repeat("x", 70000) + "\n"))
+	f.Add([]byte("#VERSION=1\nBelt 0 0 1 1\n#HIDDEN_PATHS=0\n#BACKGROUND_TEXTBOXES=3\n#FUTURE=x y\n"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for _, mode := range []DecodeMode{DecodeStrict, DecodeLenient} {
+			var s Scene
+			_, err := s.LoadFromTextMode(bytes.NewReader(data), mode)
+			if err != nil {
+				var derr DecodeTextError
+				if !errors.As(err, &derr) {
+					t.Fatalf("mode %d: error is not a DecodeTextError: %T %v", mode, err, err)
+				}
+				continue
+			}
+
+			var buf bytes.Buffer
+			if err := s.SaveToText(&buf); err != nil {
+				t.Fatalf("mode %d: cannot save loaded scene: %v", mode, err)
+			}
+			var reloaded Scene
+			if err := reloaded.LoadFromText(bytes.NewReader(buf.Bytes())); err != nil {
+				t.Fatalf("mode %d: cannot reload saved scene: %v\n%s", mode, err, buf.String())
+			}
+			if s.Fingerprint() != reloaded.Fingerprint() {
+				t.Fatalf("mode %d: reloaded scene differs\n%s", mode, buf.String())
+			}
+			if pos, err := FirstSaveDifference(&s, &reloaded); err != nil || pos >= 0 {
+				t.Fatalf("mode %d: reloaded scene saves differently at byte %d (%v)\n%s", mode, pos, err, buf.String())
+			}
+		}
+	})
+}
+
+// TestLoadNonFinite checks that non-finite coordinates are rejected on load
+func TestLoadNonFinite(t *testing.T) {
+	for _, token := range []string{"nan", "NaN", "inf", "+Inf", "-inf"} {
+		for _, line := range []string{
+			"Assembler " + token + " 0 0",
+			"Belt 0 0 " + token + " 1",
+			"TextBox 0 " + token + " 20 10 \"text\"",
+		} {
+			var s Scene
+			err := s.LoadFromText(strings.NewReader("#VERSION=1\n" + line + "\n"))
+			var derr DecodeTextError
+			if !errors.As(err, &derr) || derr.Err != errNotFinite || derr.Line != 2 {
+				t.Errorf("%q: got error %v, want non-finite error at line 2", line, err)
+			}
+		}
+	}
+}
+
+// TestAddNonFinite checks that objects with non-finite coordinates are not added nor modified
+func TestAddNonFinite(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	nan := float32(math.NaN())
+	b := s.Buildings[0]
+	b.Pos.X = nan
+	if s.AddObjects(ObjectCollection{Buildings: []Building{b}}) {
+		t.Error("AddObjects accepted a NaN building")
+	}
+	s.AddPath(Path{Start: vec2(0, 0), End: vec2(float32(math.Inf(-1)), 0)})
+	s.ModifyObjects(ObjectSelection{BuildingIdxs: []int{0}}, ObjectCollection{Buildings: []Building{b}})
+	if len(s.history) != 0 || len(s.Buildings) != 1 || len(s.Paths) != 2 || !AllFinite(s.Buildings[0].Pos.X) {
+		t.Errorf("scene modified: history=%d buildings=%v paths=%d", len(s.history), s.Buildings, len(s.Paths))
+	}
+}
+
+// TestSceneTextWriter checks [Scene.SaveToText] and streamed objects against the text format
+// golden output (as written before [SceneTextWriter] existed)
+func TestSceneTextWriter(t *testing.T) {
+	const golden = "#VERSION=1\n" +
+		"Assembler 10 20 90\n" +
+		"Belt 0 0 10 0\n" +
+		"Pipe 1.5 2.5 -3 4\n" +
+		"TextBox 0 0 20 10 \"hello\\nworld\"\n" +
+		"#HIDDEN_PATHS=1\n" +
+		"#BACKGROUND_TEXTBOXES=0\n"
+
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	var saved, got bytes.Buffer
+	if err := s.SaveToText(&saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved.String() != golden {
+		t.Errorf("saved output differs\ngot:\n%s\nwant:\n%s", saved.String(), golden)
+	}
+
+	sw := NewSceneTextWriter(&got)
+	sw.WriteHeader(version)
+	for _, b := range s.Buildings {
+		sw.WriteBuilding(b)
+	}
+	for _, p := range s.Paths {
+		sw.WritePath(p)
+	}
+	for _, tb := range s.TextBoxes {
+		sw.WriteTextBox(tb)
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != golden {
+		t.Errorf("streamed output differs\ngot:\n%s\nwant:\n%s", got.String(), golden)
+	}
+}
+
+// TestJSONRoundTrip checks that saving to JSON then loading restores the same scene
+func TestJSONRoundTrip(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText + "TextBox 1 2 3 4 \"say \\\"hi\\\"\\n\\tbye\"\n#FUTURE=x y\n")); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := s.SaveToJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var reloaded Scene
+	if err := reloaded.Load(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("cannot reload JSON: %v\n%s", err, buf.String())
+	}
+	if equal, err := ScenesSaveEqual(&s, &reloaded); err != nil || !equal {
+		t.Errorf("reloaded scene differs (%v)\n%s", err, buf.String())
+	}
+
+	// loading over an edited scene drops its history
+	reloaded.DeleteObjects(reloaded.ObjectCollection.SelectAll())
+	if err := reloaded.LoadFromJSON(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.HasUndo() || reloaded.HasRedo() || reloaded.IsModified() || len(reloaded.Buildings) != len(s.Buildings) {
+		t.Errorf("reloaded over edits: undo=%v redo=%v modified=%v, want a fresh scene", reloaded.HasUndo(), reloaded.HasRedo(), reloaded.IsModified())
+	}
+
+	var future Scene
+	if err := future.LoadFromJSON(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Error("loading a future JSON version did not fail")
+	}
+}
+
+// TestExportLayersText checks that excluded layers are skipped by [Scene.ExportLayersText]
+func TestExportLayersText(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	s.SetLayerVisible(LayerTextBoxes, false) // visibility does not matter
+
+	for _, layers := range []Layer{LayerBuildings, LayerPaths | LayerTextBoxes} {
+		var buf bytes.Buffer
+		if err := s.ExportLayersText(&buf, layers); err != nil {
+			t.Fatal(err)
+		}
+		var exported Scene
+		if err := exported.LoadFromText(&buf); err != nil {
+			t.Fatalf("layers %d: cannot load export: %v", layers, err)
+		}
+		want := s.withLayers(layers)
+		if len(exported.Buildings) != len(want.Buildings) || len(exported.Paths) != len(want.Paths) ||
+			len(exported.TextBoxes) != len(want.TextBoxes) {
+			t.Errorf("layers %d: got %d buildings %d paths %d text boxes, want %d %d %d", layers,
+				len(exported.Buildings), len(exported.Paths), len(exported.TextBoxes),
+				len(want.Buildings), len(want.Paths), len(want.TextBoxes))
+		}
+		if layers&LayerPaths != 0 && !exported.Paths[1].Hidden {
+			t.Errorf("layers %d: hidden path not kept", layers)
+		}
+	}
+}
+
+// TestBlueprintText checks that selections saved as blueprint files are normalized, and inserted
+// at the given position
+func TestBlueprintText(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	// partially selected path
+	sel := ObjectSelection{BuildingIdxs: []int{0}, PathIdxs: []PathSel{{Idx: 1, Start: true}}}
+	var buf bytes.Buffer
+	if err := s.SaveSelectionToText(&buf, sel); err != nil {
+		t.Fatal(err)
+	}
+	var saved Scene
+	if err := saved.LoadFromText(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Buildings) != 1 || len(saved.Paths) != 1 || len(saved.TextBoxes) != 0 {
+		t.Fatalf("saved objects %v", saved.ObjectCollection)
+	}
+	if bounds := saved.ObjectCollection.SelectAll().Bounds; bounds.X != 0 || bounds.Y != 0 {
+		t.Errorf("saved bounds %v, want top-left at origin", bounds)
+	}
+
+	pos := vec2(100, 200)
+	inserted, err := s.InsertBlueprintText(&buf, pos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(inserted.BuildingIdxs, []int{1}) || len(inserted.PathIdxs) != 1 || inserted.PathIdxs[0].Idx != 2 {
+		t.Errorf("inserted selection %v", inserted)
+	}
+	offset := pos.Subtract(ObjectCollection{Buildings: s.Buildings[:1], Paths: s.Paths[1:2]}.SelectAll().Bounds.Position())
+	if got, want := s.Buildings[1].Pos, s.Buildings[0].Pos.Add(offset); got != want {
+		t.Errorf("inserted building at %v, want %v", got, want)
+	}
+	if got, want := s.Paths[2].End, s.Paths[1].End.Add(offset); got != want {
+		t.Errorf("inserted path end at %v, want %v", got, want)
+	}
+
+	if err := s.SaveSelectionToText(&buf, ObjectSelection{}); err == nil {
+		t.Error("empty selection saved")
+	}
+}
+
+// TestObjectCollectionEqual checks order independent comparisons, exact and with a tolerance
+func TestObjectCollectionEqual(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText + "Belt 0 0 10 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	col := s.ObjectCollection.clone()
+	reversed := col.clone()
+	slices.Reverse(reversed.Paths)
+	if !col.Equal(reversed) {
+		t.Errorf("Equal: got false for reordered paths")
+	}
+	subset := ObjectCollection{Paths: col.Paths[:1]}
+	if !col.ContainsAll(subset) || subset.ContainsAll(col) {
+		t.Errorf("ContainsAll: wrong result for subset %v", subset)
+	}
+	// duplicates are matched with distinct objects
+	if dup := (ObjectCollection{Paths: []Path{col.Paths[1], col.Paths[1]}}); col.ContainsAll(dup) {
+		t.Errorf("ContainsAll: got true for a duplicated object")
+	}
+
+	moved := col.clone()
+	moved.Buildings[0].Pos.X += 0.01
+	if col.Equal(moved) || !col.EqualWithin(moved, 0.05) || col.EqualWithin(moved, 0.001) {
+		t.Errorf("EqualWithin: wrong result for a building moved by 0.01")
+	}
+}
+
+// TestExplode checks that the exploded view moves drawn objects only
+func TestExplode(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	before := s.ObjectCollection.clone()
+	revision := s.revision
+
+	s.Explode(vec2(0, 0), 2)
+	exploded := s.explodedObjects()
+	if !s.ObjectCollection.Equal(before) || s.revision != revision || len(s.history) != 0 {
+		t.Errorf("explode modified the scene")
+	}
+	// the belt midpoint (5, 0) is pushed to (10, 0)
+	if want := (Path{DefIdx: s.Paths[0].DefIdx, Start: vec2(5, 0), End: vec2(15, 0)}); exploded.Paths[0] != want {
+		t.Errorf("exploded path: got %v, want %v", exploded.Paths[0], want)
+	}
+	if s.Unexplode(); s.IsExploded() {
+		t.Errorf("IsExploded after Unexplode: got true")
+	}
+}
+
+// TestExportDXF checks the DXF structure: group code / value pairs and one entity per object
+func TestExportDXF(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := s.ExportDXF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatalf("odd number of lines: %d", len(lines))
+	}
+	entities := map[string]int{}
+	for i := 0; i < len(lines); i += 2 {
+		if _, err := strconv.Atoi(lines[i]); err != nil {
+			t.Fatalf("line %d: invalid group code %q", i+1, lines[i])
+		}
+		if lines[i] == "0" {
+			entities[lines[i+1]]++
+		}
+	}
+	if lines[len(lines)-1] != "EOF" {
+		t.Errorf("last value: got %q, want EOF", lines[len(lines)-1])
+	}
+	want := map[string]int{"SECTION": 2, "ENDSEC": 2, "EOF": 1, "POLYLINE": 1, "VERTEX": 4, "SEQEND": 1, "LINE": 2, "TEXT": 2}
+	for name, n := range want {
+		if entities[name] != n {
+			t.Errorf("%s entities: got %d, want %d", name, entities[name], n)
+		}
+	}
+}
+
+// TestLoadFromTextValidated checks that semantic issues are reported without blocking the load
+func TestLoadFromTextValidated(t *testing.T) {
+	var s Scene
+	text := "#VERSION=1\nAssembler 10 20 0\nAssembler 12 20 0\n"
+	issues, err := s.LoadFromTextValidated(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Buildings) != 2 || len(issues) != 1 || issues[0].Kind != IssueOverlap {
+		t.Errorf("got %d buildings and issues %v, want 2 buildings and 1 overlap", len(s.Buildings), issues)
+	}
+	if issues, err := s.LoadFromTextValidated(strings.NewReader("#VERSION=1\nUnknown 0 0 0\n")); err == nil || issues != nil {
+		t.Errorf("syntax error: got issues %v and error %v, want no issues and an error", issues, err)
+	}
+}
+
+// TestSelectIssues checks the selection of the objects involved in validation issues
+func TestSelectIssues(t *testing.T) {
+	var s Scene
+	text := "#VERSION=1\nAssembler 10 20 0\nAssembler 12 20 0\nAssembler 100 100 0\nBelt 0 0 0.1 0\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	var overlaps, short []SceneIssue
+	for _, issue := range s.Validate() {
+		switch issue.Kind {
+		case IssueOverlap:
+			overlaps = append(overlaps, issue)
+		case IssueShortPath:
+			short = append(short, issue)
+		}
+	}
+	if len(overlaps) != 1 || len(short) != 1 {
+		t.Fatalf("issues: got %d overlaps and %d short paths, want 1 and 1", len(overlaps), len(short))
+	}
+	sel := s.SelectIssues(append(overlaps, short...))
+	if !slices.Equal(sel.BuildingIdxs, []int{0, 1}) || len(sel.PathIdxs) != 1 || sel.PathIdxs[0] != (PathSel{Idx: 0, Start: true, End: true}) {
+		t.Errorf("selection: got %v, want buildings 0 1 and path 0", sel)
+	}
+	// the short path ends are also dangling
+	if got := s.SelectIssues(s.Validate(), IssueOverlap, IssueShortPath); !slices.Equal(got.BuildingIdxs, sel.BuildingIdxs) || !slices.Equal(got.PathIdxs, sel.PathIdxs) {
+		t.Errorf("selection of overlaps and short paths: got %v, want %v", got, sel)
+	}
+	if got := s.SelectIssues(s.Validate(), IssueDanglingEnd); len(got.BuildingIdxs) != 0 || len(got.PathIdxs) != 1 {
+		t.Errorf("selection of dangling ends: got %v, want path 0", got)
+	}
+}
+
+// TestDanglingEnds checks the dangling path ends reported by [Scene.Validate], across index cells
+func TestDanglingEnds(t *testing.T) {
+	var s Scene
+	// building bounds: 53.8 0 10 15, path 0 start is in the next index cell
+	text := "#VERSION=1\nAssembler 58.8 8 0\nBelt 64.2 5 100 5\nBelt 100 5 100 50\nBelt 200 200 210 200\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	var got []Object
+	for _, issue := range s.Validate() {
+		if issue.Kind == IssueDanglingEnd {
+			got = append(got, issue.Object)
+		}
+	}
+	want := []Object{{Type: TypePathEnd, Idx: 1}, {Type: TypePathStart, Idx: 2}, {Type: TypePathEnd, Idx: 2}}
+	if !slices.Equal(got, want) {
+		t.Errorf("dangling ends: got %v, want %v", got, want)
+	}
+}
+
+// TestPasteMalformed checks that malformed text box contents are pasted as literal strings, and
+// that other malformed lines abort the paste
+func TestPasteMalformed(t *testing.T) {
+	var s Scene
+	text := "#VERSION=1\nAssembler 10 20 0\nTextBox 0 0 20 10 \"bad \\q escape\"\n"
+	sel, ok := s.pasteText(text)
+	if !ok || len(s.Buildings) != 1 || len(s.TextBoxes) != 1 {
+		t.Fatalf("paste: got %v with %d buildings and %d text boxes, want 1 of each", ok, len(s.Buildings), len(s.TextBoxes))
+	}
+	if got, want := s.TextBoxes[0].Content, `bad \q escape`; got != want {
+		t.Errorf("pasted content: got %q, want %q", got, want)
+	}
+	if !slices.Equal(sel.BuildingIdxs, []int{0}) || !slices.Equal(sel.TextBoxIdxs, []int{0}) {
+		t.Errorf("pasted selection: got %v, want building 0 and text box 0", sel)
+	}
+	if _, ok := s.pasteText("#VERSION=1\nAssembler 10 20 0\nUnknown 1 2 0\n"); ok || len(s.Buildings) != 1 {
+		t.Errorf("paste unknown class: got %v with %d buildings, want nothing pasted", ok, len(s.Buildings))
+	}
+}
+
+// TestSetRegionsModified checks that changing the regions marks the scene as modified
+func TestSetRegionsModified(t *testing.T) {
+	var s Scene
+	regions := [][]rl.Vector2{{vec2(0, 0), vec2(10, 0), vec2(10, 10)}}
+	for _, step := range []struct {
+		regions  [][]rl.Vector2
+		modified bool
+	}{{nil, false}, {regions, true}, {regions, false}, {nil, true}} {
+		s.ResetModified()
+		s.SetRegions(step.regions)
+		if got := s.IsModified(); got != step.modified {
+			t.Errorf("set %d regions: got modified %v, want %v", len(step.regions), got, step.modified)
+		}
+	}
+}
+
+// TestHiddenObjectsSkipped checks that hidden objects are not selected by select all, nor reported by
+// the validation and crossing detection
+func TestHiddenObjectsSkipped(t *testing.T) {
+	var s Scene
+	text := "#VERSION=1\nAssembler 5 8 0\nAssembler 8 8 0\nBelt 20 0 40 20\nBelt 20 20 40 0\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.FindPathCrossings()) != 1 || len(s.overlapIssues()) != 1 {
+		t.Fatalf("visible scene: got %d crossings and %d overlaps, want 1 of each", len(s.FindPathCrossings()), len(s.overlapIssues()))
+	}
+	s.Hide(ObjectSelection{BuildingIdxs: []int{1}, PathIdxs: []PathSel{{Idx: 1, Start: true, End: true}}})
+
+	sel := s.SelectAll()
+	if !slices.Equal(sel.BuildingIdxs, []int{0}) || !slices.Equal(sel.AnyPathIdxs(), []int{0}) {
+		t.Errorf("select all: got %v, want building 0 and path 0", sel)
+	}
+	if all := s.ObjectCollection.SelectAll(); len(all.BuildingIdxs) != 2 || len(all.PathIdxs) != 2 {
+		t.Errorf("collection select all: got %v, want every object", all)
+	}
+	if got := s.FindPathCrossings(); len(got) != 0 {
+		t.Errorf("crossings: got %v, want none", got)
+	}
+	for _, issue := range s.Validate() {
+		if issue.Kind == IssueOverlap || issue.Object.Idx == 1 {
+			t.Errorf("issue of hidden object: %v", issue)
+		}
+	}
+}
+
+// TestRouteDistance checks the routes along connected paths, across spatial index cells
+func TestRouteDistance(t *testing.T) {
+	var s Scene
+	// building bounds: 0 0 10 15, 200 0 10 15 and 500 500 10 15
+	text := "#VERSION=1\nAssembler 5 8 0\nAssembler 205 8 0\nAssembler 505 508 0\n" +
+		"Belt 130 5 200 5\nBelt 10 5 60 5\nBelt 60.5 5 130 5\nBelt 10 10 40 40\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	dist, paths, ok := s.RouteDistance(0, 1, 1)
+	if !ok || dist != 189.5 || !slices.Equal(paths, []int{1, 2, 0}) {
+		t.Errorf("route 0 -> 1: got %v %v %v, want 189.5 [1 2 0] true", dist, paths, ok)
+	}
+	if _, _, ok := s.RouteDistance(0, 2, 1); ok {
+		t.Error("route 0 -> 2: got connected, want not connected")
+	}
+}
+
+// TestReplaceBuildingInvalid checks that replacements with invalid indices are refused
+func TestReplaceBuildingInvalid(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader("#VERSION=1\nAssembler 10 20 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	for _, idxs := range [][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, len(buildingDefs)}} {
+		if ok, action := s.ForceReplaceBuilding(idxs[0], idxs[1], AnchorCenter); ok || action != nil || s.historyPos != 0 {
+			t.Errorf("replace building %d with definition %d: got %v, want refused", idxs[0], idxs[1], ok)
+		}
+	}
+}
+
+// TestBuildingAnchor checks that anchored footprints follow the building rotation and stay aligned
+func TestBuildingAnchor(t *testing.T) {
+	defs := buildingDefs
+	t.Cleanup(func() { buildingDefs = defs })
+	buildingDefs = append(slices.Clip(defs), BuildingDef{Class: "Anchored", Dims: vec2(5, 3), Anchor: vec2(-2, 1)})
+	b := Building{DefIdx: len(buildingDefs) - 1, Pos: vec2(10, 20)}
+
+	// origin is (3, 2) + (-2, 1) = (1, 3) from the top left corner
+	for rot, want := range map[int32]rl.Rectangle{
+		0:   {X: 9, Y: 17, Width: 5, Height: 3},
+		90:  {X: 10, Y: 19, Width: 3, Height: 5},
+		180: {X: 6, Y: 20, Width: 5, Height: 3},
+		270: {X: 7, Y: 16, Width: 3, Height: 5},
+	} {
+		b.Rot = rot
+		if got := b.Bounds(); got != want {
+			t.Errorf("rot %d: bounds %v, want %v", rot, got, want)
+		}
+	}
+}
+
+// TestCanonicalize checks that objects order does not depend on the initial order, and that the
+// selection follows its objects
+func TestCanonicalize(t *testing.T) {
+	selection = Selection{}
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText + "Belt 0 0 10 0\nAssembler 0 0 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	want := s.ObjectCollection.clone()
+	sel := ObjectSelection{BuildingIdxs: []int{0}, PathIdxs: []PathSel{{Idx: 1, End: true}}}
+	selected := s.Buildings[0]
+	selectedPath := s.Paths[1]
+
+	sel = s.Canonicalize(sel)
+	if got := s.Buildings[sel.BuildingIdxs[0]]; got != selected {
+		t.Errorf("selected building %v, want %v", got, selected)
+	}
+	if got := s.Paths[sel.PathIdxs[0].Idx]; got != selectedPath || sel.PathIdxs[0].Start || !sel.PathIdxs[0].End {
+		t.Errorf("selected path %v (%v), want %v end", got, sel.PathIdxs[0], selectedPath)
+	}
+	if !s.Equal(want) {
+		t.Errorf("canonicalized objects %v, want the same objects as %v", s.ObjectCollection, want)
+	}
+	var canonical bytes.Buffer
+	if err := s.SaveToText(&canonical); err != nil {
+		t.Fatal(err)
+	}
+
+	// reversed order
+	var r Scene
+	col := want.clone()
+	slices.Reverse(col.Buildings)
+	slices.Reverse(col.Paths)
+	slices.Reverse(col.TextBoxes)
+	r.ObjectCollection = col
+	r.Canonicalize(ObjectSelection{})
+	var got bytes.Buffer
+	if err := r.SaveToText(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.String() != canonical.String() {
+		t.Errorf("canonical text of reversed scene:\n%s\nwant:\n%s", got.String(), canonical.String())
+	}
+
+	// single undo
+	if ok, _, _ := s.Undo(); !ok || !slices.Equal(s.Buildings, want.Buildings) || !slices.Equal(s.Paths, want.Paths) {
+		t.Errorf("undo: objects %v, want %v", s.ObjectCollection, want)
+	}
+}
+
+// TestReferenceScene checks that the reference layout is independent of the scene
+func TestReferenceScene(t *testing.T) {
+	t.Cleanup(ClearReferenceScene)
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	var before bytes.Buffer
+	if err := s.SaveToText(&before); err != nil {
+		t.Fatal(err)
+	}
+
+	SetReferenceScene(s.ObjectCollection)
+	s.Buildings[0].Pos = vec2(-100, -100)
+	if !HasReferenceScene() || reference.Buildings[0].Pos == s.Buildings[0].Pos {
+		t.Errorf("reference %v shares the scene objects", reference)
+	}
+	s.DeleteObjects(s.SelectAll())
+	var after bytes.Buffer
+	if err := s.SaveToText(&after); err != nil {
+		t.Fatal(err)
+	}
+	if !s.SelectAll().IsEmpty() || len(s.Validate()) != 0 || after.Len() >= before.Len() {
+		t.Errorf("reference objects are part of the scene:\n%s", after.String())
+	}
+
+	ClearReferenceScene()
+	if HasReferenceScene() {
+		t.Errorf("reference %v not cleared", reference)
+	}
+}
+
+// TestCompact checks that only exact duplicates are found and removed, in a single operation (text
+// boxes are compared by bounds and content only)
+func TestCompact(t *testing.T) {
+	var s Scene
+	text := sampleText +
+		"Assembler 10 20 90\nAssembler 10 20 0\n" +
+		"Belt 0 0 10 0\nBelt 10 0 0 0\n" +
+		"TextBox 0 0 20 10 \"hello\\nworld\"\nTextBox 0 0 20 10 \"hello\\nworld \"\nTextBox 0 0 20 10 \"hello\\nworld\"\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.FindDuplicateTextBoxes(), [][]int{{0, 1, 3}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("duplicate text boxes %v, want %v", got, want)
+	}
+	if got, want := s.FindDuplicateBuildings(), [][]int{{0, 1}}; !slices.EqualFunc(got, want, slices.Equal) {
+		t.Errorf("duplicate buildings %v, want %v", got, want)
+	}
+
+	dups := s.FindDuplicates()
+	if !slices.Equal(dups.BuildingIdxs, []int{1}) || !slices.Equal(dups.PathIdxs, []PathSel{{Idx: 2, Start: true, End: true}}) ||
+		!slices.Equal(dups.TextBoxIdxs, []int{1, 3}) {
+		t.Errorf("duplicates %v", dups)
+	}
+
+	if n := s.Compact(); n != 4 {
+		t.Errorf("compact removed %d objects, want 4", n)
+	}
+	if len(s.Buildings) != 2 || len(s.Paths) != 3 || len(s.TextBoxes) != 2 {
+		t.Errorf("compacted objects %v", s.ObjectCollection)
+	}
+	if n := s.Compact(); n != 0 {
+		t.Errorf("compact removed %d objects again", n)
+	}
+	if ok, _, _ := s.Undo(); !ok || len(s.Buildings) != 3 || len(s.Paths) != 4 || len(s.TextBoxes) != 4 {
+		t.Errorf("undo: objects %v", s.ObjectCollection)
+	}
+}
+
+// TestSummary checks the classes summary, and that it follows scene changes
+func TestSummary(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText + "Belt 0 0 0 5\nAssembler 40 20 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	check := func(step string, want map[string]ClassSummary) {
+		t.Helper()
+		got := s.Summary()
+		if len(got) != len(want) {
+			t.Errorf("%s: summary %v, want %v", step, got, want)
+		}
+		for class, w := range want {
+			g := got[class]
+			if g.Buildings != w.Buildings || g.Paths != w.Paths || math.Abs(float64(g.PathsLength-w.PathsLength)) > 1e-5 {
+				t.Errorf("%s: %s summary %v, want %v", step, class, g, w)
+			}
+		}
+	}
+	want := map[string]ClassSummary{
+		"Assembler": {Buildings: 2},
+		"Belt":      {Paths: 2, PathsLength: 15},
+		"Pipe":      {Paths: 1, PathsLength: float32(math.Sqrt(4.5*4.5 + 1.5*1.5))},
+	}
+	check("load", want)
+	wantLines := []string{"Assembler: 2 building(s)", "Belt: 2 path(s), length 15.0", "Pipe: 1 path(s), length 4.7"}
+	if got := s.summaryLines(); !slices.Equal(got, wantLines) {
+		t.Errorf("summary lines %q, want %q", got, wantLines)
+	}
+	s.DeleteObjects(ObjectSelection{BuildingIdxs: []int{0}})
+	want["Assembler"] = ClassSummary{Buildings: 1}
+	check("delete", want)
+}
+
+// TestSceneMetrics checks the total paths length and buildings area on a hand-computed scene
+func TestSceneMetrics(t *testing.T) {
+	var s Scene
+	// 10x15 assemblers (one rotated), paths of length 10, 5 and 5 (3-4-5 triangle)
+	text := "#VERSION=1\nAssembler 0 0 0\nAssembler 20 0 90\nBelt 0 0 10 0\nBelt 0 0 0 5\nPipe 0 0 3 4\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Metrics(); got.PathsLength != 20 || got.BuildingsArea != 300 || got.Buildings != 2 || got.Paths != 3 {
+		t.Errorf("metrics %+v, want paths length 20 and buildings area 300", got)
+	}
+	if got := (Scene{}).Metrics(); got != (SelectionMetrics{}) {
+		t.Errorf("empty scene metrics %+v", got)
+	}
+}
+
+// TestObjectsInRect checks window (contained) and crossing box queries
+func TestObjectsInRect(t *testing.T) {
+	var s Scene
+	text := "#VERSION=1\nAssembler 5 8 0\nAssembler 100 0 90\n" +
+		"Belt 0 20 10 20\nBelt 5 30 50 30\nBelt -10 40 60 40\n" +
+		"TextBox 0 50 5 5 \"a\"\n"
+	if err := s.LoadFromText(strings.NewReader(text)); err != nil {
+		t.Fatal(err)
+	}
+	r := rl.NewRectangle(-1, -1, 12, 45)
+	full := func(i int) PathSel { return PathSel{Idx: i, Start: true, End: true} }
+
+	window := s.ObjectsInRect(r, true)
+	if !slices.Equal(window.BuildingIdxs, []int{0}) || len(window.TextBoxIdxs) != 0 ||
+		!slices.Equal(window.PathIdxs, []PathSel{full(0), {Idx: 1, Start: true}}) {
+		t.Errorf("window selection %v", window)
+	}
+	if want := rl.NewRectangle(0, 0, 10, 30); window.Bounds != want {
+		t.Errorf("window selection bounds %v, want %v", window.Bounds, want)
+	}
+	crossing := s.ObjectsInRect(r, false)
+	if !slices.Equal(crossing.BuildingIdxs, []int{0}) || len(crossing.TextBoxIdxs) != 0 ||
+		!slices.Equal(crossing.PathIdxs, []PathSel{full(0), {Idx: 1, Start: true}, full(2)}) {
+		t.Errorf("crossing selection %v", crossing)
+	}
+	starts := s.GetObjectsInRectOfType(r, TypePathStart)
+	if len(starts.BuildingIdxs) != 0 || !slices.Equal(starts.PathIdxs, []PathSel{{Idx: 0, Start: true}, {Idx: 1, Start: true}}) {
+		t.Errorf("path starts selection %v", starts)
+	}
+	if sel := s.ObjectsInRect(rl.NewRectangle(4, 52, 10, 10), false); !slices.Equal(sel.TextBoxIdxs, []int{0}) {
+		t.Errorf("crossing text box selection %v", sel)
+	}
+
+	// rotated building: 15 wide and 10 high
+	rotated := s.Buildings[1].Bounds()
+	if sel := s.ObjectsInRect(rotated, true); !slices.Equal(sel.BuildingIdxs, []int{1}) {
+		t.Errorf("rotated building bounds selection %v", sel)
+	}
+	unrotated := rl.NewRectangle(rotated.X, rotated.Y, 10, 15)
+	if sel := s.ObjectsInRect(unrotated, true); len(sel.BuildingIdxs) != 0 {
+		t.Errorf("rotated building selected by its unrotated footprint: %v", sel)
+	}
+	if sel := s.ObjectsInRect(unrotated, false); !slices.Equal(sel.BuildingIdxs, []int{1}) {
+		t.Errorf("rotated building crossing selection %v", sel)
+	}
+
+	s.SetLayerVisible(LayerPaths, false)
+	if sel := s.ObjectsInRect(r, false); len(sel.PathIdxs) != 0 {
+		t.Errorf("hidden layer paths selected: %v", sel)
+	}
+}
+
+// TestObjectBounds checks that out of range objects have no bounds
+func TestObjectBounds(t *testing.T) {
+	var s Scene
+	if err := s.LoadFromText(strings.NewReader(sampleText)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.ObjectBounds(Object{Type: TypeBuilding, Idx: 0}); !ok {
+		t.Errorf("building 0 has no bounds")
+	}
+	for _, typ := range []ObjectType{TypeBuilding, TypePath, TypePathStart, TypePathEnd, TypeTextBox} {
+		for _, idx := range []int{-1, 10} {
+			if r, ok := s.ObjectBounds(Object{Type: typ, Idx: idx}); ok {
+				t.Errorf("%v %d: got bounds %v, want none", typ, idx, r)
+			}
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/bonoboris/satisfied/colors"
 	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
 	"github.com/bonoboris/satisfied/matrix"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
@@ -26,6 +27,11 @@ type Selection struct {
 	transform selectionTransform
 	// update transform position on mouse down
 	transformMoveOnMouseDown bool
+	// secondary selection, swapped with the current one by [Selection.SwapSelections]
+	//
+	// It is not cleared by [Selection.Reset], and follows its objects across scene operations (see
+	// [sceneOp.remapSecondary]).
+	secondary ObjectSelection
 }
 
 func (s Selection) traceState(key, val string) {
@@ -37,6 +43,7 @@ func (s Selection) traceState(key, val string) {
 		log.Trace("selection", "pathIdxs", s.PathIdxs)
 		log.Trace("selection", "textboxIdxs", s.TextBoxIdxs)
 		log.Trace("selection", "mode", s.mode, "bounds", s.Bounds)
+		log.Trace("selection", "secondary", s.secondary)
 		s.transform.traceState()
 	}
 }
@@ -93,6 +100,10 @@ func (m SelectionMode) String() string {
 ////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // Represent a transformation of the selection (drag or duplicate)
+//
+// The transformed objects are a copy of the selected ones: the scene itself is only modified when
+// the transformation is committed (see [Selection.doEndTransformation]), so cancelling it leaves the
+// scene and its history untouched.
 type selectionTransform struct {
 	// Transformation state
 
@@ -102,6 +113,8 @@ type selectionTransform struct {
 	startPos rl.Vector2
 	// end position of the transformation
 	endPos rl.Vector2
+	// keep only the dominant axis of the translation (see [Selection.ComputeDragDelta])
+	axisLock bool
 
 	// Transformation results / data
 	ObjectCollection
@@ -130,7 +143,7 @@ func (st selectionTransform) traceState() {
 			log.Trace("selectionTransform.textboxes", "i", i, "value", tb)
 		}
 		log.Trace("selectionTransform", "isValid", st.isValid, "bounds", st.bounds)
-		log.Trace("selectionTransform", "rot", st.rot, "startPos", st.startPos, "endPos", st.endPos)
+		log.Trace("selectionTransform", "rot", st.rot, "startPos", st.startPos, "endPos", st.endPos, "axisLock", st.axisLock)
 	}
 }
 
@@ -138,6 +151,7 @@ func (st *selectionTransform) reset() {
 	st.rot = 0
 	st.startPos = rl.Vector2{}
 	st.endPos = rl.Vector2{}
+	st.axisLock = false
 
 	st.Paths = st.Paths[:0]
 	st.Buildings = st.Buildings[:0]
@@ -148,15 +162,31 @@ func (st *selectionTransform) reset() {
 	st.bounds = rl.Rectangle{}
 }
 
+// dragDelta returns the final translation from the raw delta, see [Selection.ComputeDragDelta]
+func (s selectionTransform) dragDelta(rawDelta rl.Vector2) rl.Vector2 {
+	delta := grid.Snap(rawDelta)
+	if s.axisLock {
+		if math32.Abs(delta.X) >= math32.Abs(delta.Y) {
+			delta.Y = 0
+		} else {
+			delta.X = 0
+		}
+	}
+	return delta
+}
+
+// translate returns the final translation of the transformation
+func (s selectionTransform) translate() rl.Vector2 { return s.dragDelta(s.endPos.Subtract(s.startPos)) }
+
 func (s selectionTransform) isIdentity() bool {
-	translate := grid.Snap(s.endPos.Subtract(s.startPos))
+	translate := s.translate()
 	return s.rot%360 == 0 && translate.X == 0 && translate.Y == 0
 }
 
 // Matrix returns the rotation matrix of the selection
 func (s selectionTransform) transformMatrix(baseBounds rl.Rectangle) matrix.Matrix {
 	center := baseBounds.Center()
-	translate := grid.Snap(s.endPos.Subtract(s.startPos))
+	translate := s.translate()
 	return matrix.NewTranslateV(translate.Add(center)).Rotate(s.rot).TranslateV(center.Negate())
 }
 
@@ -171,6 +201,12 @@ func (st *selectionTransform) recompute(sel ObjectSelection, mode SelectionMode)
 		st.TextBoxes = append(st.TextBoxes, tb)
 		return
 	}
+	if stickyConnections && mode != SelectionDuplicate {
+		// connected path ends follow, as committed by [Selection.doEndTransformation], around the same center
+		bounds := sel.Bounds
+		sel = scene.WithConnectedEnds(sel, stickyThreshold)
+		sel.Bounds = bounds
+	}
 
 	// fast path for identity transform
 	// TODO: not copying anything would be faster
@@ -317,7 +353,7 @@ func (s *Selection) GetAction() Action {
 	case SelectionNormal, SelectionSingleTextBox:
 		switch keyboard.Binding() {
 		case BindingEscape:
-			return app.doSwitchMode(ModeNormal, ResetAll())
+			return doEscape(EscapeSelection)
 		case BindingDuplicate:
 			// Duplicate use center of current selection as start position
 			return s.doBeginTransformation(SelectionDuplicate, s.Bounds.Center(), false)
@@ -327,6 +363,12 @@ func (s *Selection) GetAction() Action {
 			return s.doDelete()
 		case BindingRotate:
 			return s.doRotate()
+		case BindingRotateInPlace:
+			return s.doRotateInPlace()
+		case BindingSwapSelections:
+			return s.doSwapSelections()
+		case BindingRepeatTransform:
+			return s.doRepeatTransform()
 
 		case BindingLeft:
 			return s.doMoveBy(vec2(-1, 0))
@@ -355,10 +397,21 @@ func (s *Selection) GetAction() Action {
 		// TODO: Implement arrow keys nudging ?
 		switch keyboard.Binding() {
 		case BindingEscape:
-			return s.doEndTransformation(true)
-		case BindingRotate:
+			return doEscape(EscapeTransformation)
+		case BindingRotate, BindingRotateInPlace:
 			return s.doRotate()
 		}
+		if numInput.Active() {
+			// typed offset overrides the mouse
+			if offset, ok := numInput.Value(); ok {
+				s.doMoveToOffset(offset)
+				if numInput.committed() {
+					numInput.Reset()
+					return s.doEndTransformation(false)
+				}
+			}
+			return nil
+		}
 		switch {
 		case mouse.Left.Released:
 			return s.doEndTransformation(false)
@@ -442,6 +495,50 @@ func (s *Selection) doInitSelection(sel ObjectSelection) Action {
 	return app.doSwitchMode(appMode, resets)
 }
 
+// doRepeatTransform repeats the last duplicate or move on the current selection, and selects the result
+//
+// See [Scene.RepeatLastTransform]
+func (s *Selection) doRepeatTransform() Action {
+	s.traceState("before", "doRepeatTransform")
+	log.Debug("selection.doRepeatTransform", "selection.mode", s.mode)
+	app.Mode.Assert(ModeSelection)
+
+	if !scene.HasLastTransform() {
+		log.Debug("selection.doRepeatTransform", "action", "skipped", "reason", "no last transformation")
+		return nil
+	}
+	return s.doInitSelection(scene.RepeatLastTransform(s.ObjectSelection))
+}
+
+// doSelectAll initializes a new selection with every scene object
+func (s *Selection) doSelectAll() Action {
+	log.Debug("selection.doSelectAll")
+	return s.doInitSelection(scene.SelectAll())
+}
+
+// SwapSelections swaps the current selection with the secondary one, switching to [ModeSelection] or
+// [ModeNormal] if the new current selection is empty
+//
+// The secondary selection indices out of the scene bounds (e.g. after a load) are dropped, see
+// [ObjectSelection.keepValidIdxs].
+func (s *Selection) SwapSelections() {
+	s.traceState("before", "SwapSelections")
+	log.Debug("selection.SwapSelections", "secondary", s.secondary)
+	assert(app.Mode == ModeNormal || s.mode == SelectionNormal || s.mode == SelectionSingleTextBox,
+		"cannot swap selections in "+s.mode.String())
+
+	next := s.secondary.clone()
+	next.keepValidIdxs(scene.ObjectCollection)
+	s.secondary = s.ObjectSelection.clone()
+	s.doInitSelection(next) // mode switches have no follow-up action
+}
+
+// doSwapSelections swaps the current selection with the secondary one, see [Selection.SwapSelections]
+func (s *Selection) doSwapSelections() Action {
+	s.SwapSelections()
+	return nil
+}
+
 func (s *Selection) doDelete() Action {
 	s.traceState("before", "doDelete")
 	log.Debug("selection.doDelete")
@@ -499,6 +596,14 @@ func (s *Selection) doBeginTransformation(mode SelectionMode, pos rl.Vector2, mo
 	return nil
 }
 
+// ComputeDragDelta returns the translation applied to the selection for the raw mouse delta
+//
+// Grid snapping is applied first, then axis lock (holding Shift while dragging) keeps only the
+// dominant axis. The transformation preview and the committed move both use it.
+func (s Selection) ComputeDragDelta(rawDelta rl.Vector2) rl.Vector2 {
+	return s.transform.dragDelta(rawDelta)
+}
+
 func (s *Selection) doMoveBy(delta rl.Vector2) Action {
 	s.traceState("before", "doMoveBy")
 	log.Debug("selection.doMoveBy", "delta", delta, "selection.mode", s.mode)
@@ -539,12 +644,28 @@ func (s *Selection) doMoveTo(pos rl.Vector2) Action {
 		return s.doEndTransformation(false)
 	default:
 		s.transform.endPos = pos
+		s.transform.axisLock = keyboard.Shift
 		s.transform.recompute(s.ObjectSelection, s.mode)
 		s.traceState("after", "doMoveTo")
 		return nil
 	}
 }
 
+// doMoveToOffset sets the transformation translation to the given offset from its start position
+func (s *Selection) doMoveToOffset(offset rl.Vector2) Action {
+	app.Mode.Assert(ModeSelection)
+	endPos := s.transform.startPos.Add(offset)
+	if endPos == s.transform.endPos && !s.transform.axisLock {
+		return nil
+	}
+	log.Debug("selection.doMoveToOffset", "offset", offset, "selection.mode", s.mode)
+	s.transform.endPos = endPos
+	s.transform.axisLock = false
+	s.transform.recompute(s.ObjectSelection, s.mode)
+	s.traceState("after", "doMoveToOffset")
+	return nil
+}
+
 func (s *Selection) doRotate() Action {
 	s.traceState("before", "doRotate")
 	log.Debug("selection.doRotate", "selection.mode", s.mode)
@@ -573,6 +694,27 @@ func (s *Selection) doRotate() Action {
 	}
 }
 
+// doRotateInPlace rotates each selected building around its own center, see [Scene.RotateBuildingsInPlace]
+func (s *Selection) doRotateInPlace() Action {
+	log.Debug("selection.doRotateInPlace")
+	app.Mode.Assert(ModeSelection)
+	scene.RotateBuildingsInPlace(s.ObjectSelection)
+	s.recomputeBounds(scene.ObjectCollection)
+	return nil
+}
+
+// doDeselect clears the selection and switches to [ModeNormal]
+func (s *Selection) doDeselect() Action {
+	log.Debug("selection.doDeselect")
+	return app.doSwitchMode(ModeNormal, ResetAll())
+}
+
+// doCancelTransformation discards the current transformation, the scene is left untouched
+func (s *Selection) doCancelTransformation() Action {
+	log.Debug("selection.doCancelTransformation", "selection.mode", s.mode)
+	return s.doEndTransformation(true)
+}
+
 func (s *Selection) doEndTransformation(discard bool) Action {
 	s.traceState("before", "doEndTransformation")
 	log.Debug("selection.doEndTransformation", "discard", discard, "selection.mode", s.mode)
@@ -581,10 +723,24 @@ func (s *Selection) doEndTransformation(discard bool) Action {
 	if !discard && s.transform.isValid && !s.transform.isIdentity() {
 		switch s.mode {
 		case SelectionDuplicate:
-			scene.AddObjects(s.transform.ObjectCollection)
-		default:
+			if scene.AddObjects(s.transform.ObjectCollection) {
+				scene.setLastTransform(sceneOpDelta{
+					Center:    s.Bounds.Center(),
+					Translate: s.transform.translate(),
+					Rot:       s.transform.rot,
+				}, true, scene.tailSelection(s.transform.ObjectCollection))
+			}
+		case SelectionTextBoxResize:
 			scene.ModifyObjects(s.ObjectSelection, s.transform.ObjectCollection)
 			s.Bounds = s.transform.bounds
+		default:
+			// rigid transformation: only store the delta in history (see [selectionTransform.transformMatrix])
+			sel := s.ObjectSelection
+			if stickyConnections {
+				sel = scene.WithConnectedEnds(sel, stickyThreshold)
+			}
+			scene.TransformObjects(sel, s.Bounds.Center(), s.transform.translate(), s.transform.rot)
+			s.Bounds = s.transform.bounds
 		}
 	}
 
@@ -608,6 +764,8 @@ func (s *Selection) Dispatch(action Action) Action {
 		return s.doInitSingleDrag(action.Object, action.Pos)
 	case SelectionActionDelete:
 		return s.doDelete()
+	case SelectionActionSwapSelections:
+		return s.doSwapSelections()
 	case SelectionActionBeginTransformation:
 		return s.doBeginTransformation(action.Mode, action.Pos, action.MoveOnMouseDown)
 	case SelectionActionMoveTo:
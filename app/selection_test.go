@@ -0,0 +1,834 @@
+package app
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// setupDragScene loads a scene with a single building, and selects it
+func setupDragScene(t *testing.T) {
+	t.Helper()
+	scene = Scene{}
+	if err := scene.LoadFromText(strings.NewReader("#VERSION=1\nAssembler 10 20 0\n")); err != nil {
+		t.Fatal(err)
+	}
+	selection = Selection{}
+	app.Mode = ModeSelection
+	selection.doInitSelection(ObjectSelection{BuildingIdxs: []int{0}, Bounds: scene.Buildings[0].Bounds()})
+	t.Cleanup(func() {
+		scene = Scene{}
+		selection = Selection{}
+		app.Mode = ModeNormal
+	})
+}
+
+// TestDragCancel checks that cancelling a drag leaves the scene and its history untouched
+func TestDragCancel(t *testing.T) {
+	setupDragScene(t)
+	before := slices.Clone(scene.Buildings)
+	historyPos, revision := scene.historyPos, scene.revision
+
+	start := selection.Bounds.Center()
+	selection.doBeginTransformation(SelectionDrag, start, false)
+	selection.doMoveTo(start.Add(vec2(30, 0)))
+	if !slices.Equal(scene.Buildings, before) {
+		t.Fatalf("scene modified during drag: got %v, want %v", scene.Buildings, before)
+	}
+	selection.doCancelTransformation()
+
+	if scene.historyPos != historyPos || len(scene.history) != historyPos {
+		t.Errorf("history changed: historyPos=%d len=%d, want %d", scene.historyPos, len(scene.history), historyPos)
+	}
+	if scene.revision != revision {
+		t.Errorf("revision changed: got %d, want %d", scene.revision, revision)
+	}
+	if !slices.Equal(scene.Buildings, before) {
+		t.Errorf("buildings not restored: got %v, want %v", scene.Buildings, before)
+	}
+}
+
+// TestDragCommit checks that a committed drag records a single history entry
+func TestDragCommit(t *testing.T) {
+	setupDragScene(t)
+	historyPos := scene.historyPos
+
+	start := selection.Bounds.Center()
+	selection.doBeginTransformation(SelectionDrag, start, false)
+	selection.doMoveTo(start.Add(vec2(10, 0)))
+	selection.doMoveTo(start.Add(vec2(30, 0)))
+	selection.doEndTransformation(false)
+
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+	if got := scene.Buildings[0].Pos.X; got != 40 {
+		t.Errorf("building X: got %v, want 40", got)
+	}
+}
+
+// TestUndoRedoSelection checks the selection returned by undo / redo
+func TestUndoRedoSelection(t *testing.T) {
+	setupDragScene(t)
+	scene.AddObjects(ObjectCollection{Buildings: []Building{scene.Buildings[0]}})
+
+	ok, sel, _ := scene.Undo()
+	if !ok || !sel.IsEmpty() {
+		t.Errorf("undo add: got ok=%v sel=%v, want empty selection", ok, sel)
+	}
+	ok, sel, _ = scene.Redo()
+	if !ok || !slices.Equal(sel.BuildingIdxs, []int{1}) {
+		t.Errorf("redo add: got ok=%v sel=%v, want building 1 selected", ok, sel)
+	}
+	if ok, sel, _ = scene.Redo(); ok || !sel.IsEmpty() {
+		t.Errorf("redo without history: got ok=%v sel=%v, want failure", ok, sel)
+	}
+}
+
+// TestUndoGroup checks that grouped operations, nested groups included, are undone and redone at once
+func TestUndoGroup(t *testing.T) {
+	setupDragScene(t)
+	before := slices.Clone(scene.Buildings)
+	historyPos := scene.historyPos
+
+	moved := scene.Buildings[0]
+	moved.Pos.X += 100
+	scene.BeginUndoGroup()
+	scene.AddObjects(ObjectCollection{Buildings: []Building{scene.Buildings[0]}})
+	scene.BeginUndoGroup()
+	scene.ModifyObjects(ObjectSelection{BuildingIdxs: []int{0}}, ObjectCollection{Buildings: []Building{moved}})
+	scene.EndUndoGroup()
+	if scene.historyPos != historyPos {
+		t.Errorf("historyPos inside group: got %d, want %d", scene.historyPos, historyPos)
+	}
+	scene.EndUndoGroup()
+	after := slices.Clone(scene.Buildings)
+
+	if scene.historyPos != historyPos+1 || len(scene.history) != historyPos+1 {
+		t.Fatalf("history after group: historyPos=%d len=%d, want %d", scene.historyPos, len(scene.history), historyPos+1)
+	}
+	// as undoing the operations one by one: the addition is undone last
+	if ok, sel, _ := scene.Undo(); !ok || !sel.IsEmpty() {
+		t.Errorf("undo group: got ok=%v sel=%v, want empty selection", ok, sel)
+	}
+	if scene.historyPos != historyPos || !slices.Equal(scene.Buildings, before) {
+		t.Errorf("undo group: historyPos=%d buildings=%v, want %d %v", scene.historyPos, scene.Buildings, historyPos, before)
+	}
+	// the selections of every operation: the added and the moved buildings
+	if ok, sel, _ := scene.Redo(); !ok || !slices.Equal(sel.BuildingIdxs, []int{0, 1}) {
+		t.Errorf("redo group: got ok=%v sel=%v, want buildings 0 and 1 selected", ok, sel)
+	}
+	if scene.historyPos != historyPos+1 || !slices.Equal(scene.Buildings, after) {
+		t.Errorf("redo group: historyPos=%d buildings=%v, want %d %v", scene.historyPos, scene.Buildings, historyPos+1, after)
+	}
+
+	// explode: the added buildings follow the swap deletion of the original one
+	scene.BeginUndoGroup()
+	scene.AddObjects(ObjectCollection{Buildings: []Building{moved, moved}})
+	scene.DeleteObjects(ObjectSelection{BuildingIdxs: []int{0}})
+	scene.EndUndoGroup()
+	scene.Undo()
+	if ok, sel, _ := scene.Redo(); !ok || !slices.Equal(sel.BuildingIdxs, []int{0, 2}) || sel.Bounds != moved.Bounds() {
+		t.Errorf("redo explode group: got ok=%v sel=%v, want the added buildings 0 and 2 selected", ok, sel)
+	}
+}
+
+// TestHistoryEntriesTime checks that operation timestamps are kept through undo / redo
+func TestHistoryEntriesTime(t *testing.T) {
+	setupDragScene(t)
+	scene.AddObjects(ObjectCollection{Buildings: []Building{scene.Buildings[0]}})
+	entries := scene.HistoryEntries()
+	if len(entries) != 1 || entries[0].Time.IsZero() || !entries[0].Done {
+		t.Fatalf("entries after add: got %v, want a single done timestamped entry", entries)
+	}
+	scene.Undo()
+	if got := scene.HistoryEntries(); got[0].Done || !got[0].Time.Equal(entries[0].Time) {
+		t.Errorf("entry after undo: got %v, want undone with time %v", got[0], entries[0].Time)
+	}
+	scene.Redo()
+	if got := scene.HistoryEntries(); !got[0].Done || !got[0].Time.Equal(entries[0].Time) {
+		t.Errorf("entry after redo: got %v, want done with time %v", got[0], entries[0].Time)
+	}
+}
+
+// TestMaxHistory checks that the oldest operations are evicted and the retained ones can be undone
+func TestMaxHistory(t *testing.T) {
+	setupDragScene(t)
+	scene.ResetModified()
+	scene.SetMaxHistory(3)
+	for range 5 {
+		scene.AddObjects(ObjectCollection{Buildings: []Building{scene.Buildings[0]}})
+	}
+	if len(scene.history) != 3 || scene.historyPos != 3 {
+		t.Fatalf("history: len=%d historyPos=%d, want 3 3", len(scene.history), scene.historyPos)
+	}
+	for i := range 3 {
+		if ok, _, _ := scene.Undo(); !ok {
+			t.Fatalf("undo %d failed", i)
+		}
+	}
+	if ok, _, _ := scene.Undo(); ok {
+		t.Errorf("undo of an evicted operation succeeded")
+	}
+	if got := len(scene.Buildings); got != 3 {
+		t.Errorf("buildings after undo: got %d, want 3", got)
+	}
+	if !scene.IsModified() {
+		t.Errorf("IsModified: got false with the saved state evicted, want true")
+	}
+}
+
+// TestSnapPathsToGrid checks that paths ends are snapped, and that aligned paths record nothing
+func TestSnapPathsToGrid(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0.4, 0.2), End: vec2(10.6, 0)})
+	historyPos := scene.historyPos
+
+	scene.SnapPathToGrid(0)
+	if want := (Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(11, 0)}); scene.Paths[0] != want {
+		t.Errorf("snapped path: got %v, want %v", scene.Paths[0], want)
+	}
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+	if scene.SnapPathToGrid(0); scene.historyPos != historyPos+1 {
+		t.Errorf("snapping an aligned path recorded an operation")
+	}
+}
+
+// TestSelectAllOfType checks the selections of every object of a type
+func TestSelectAllOfType(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+
+	paths := scene.SelectAllOfType(TypePath)
+	if len(paths.BuildingIdxs) != 0 || len(paths.PathIdxs) != 1 || paths.PathIdxs[0] != (PathSel{Idx: 0, Start: true, End: true}) {
+		t.Errorf("select all paths: got %v", paths)
+	}
+	if want := rl.NewRectangle(0, 0, 10, 0); paths.Bounds != want {
+		t.Errorf("select all paths bounds: got %v, want %v", paths.Bounds, want)
+	}
+	if sel := scene.SelectAllOfType(TypeTextBox); !sel.IsEmpty() || sel.Bounds != (rl.Rectangle{}) {
+		t.Errorf("select all text boxes: got %v, want empty selection", sel)
+	}
+	if sel := (ObjectCollection{}).SelectAll(); !sel.IsEmpty() || sel.Bounds != (rl.Rectangle{}) {
+		t.Errorf("select all of empty collection: got %v, want empty selection", sel)
+	}
+}
+
+// TestInvertSelection checks that partially selected paths are fully selected in the complement
+func TestInvertSelection(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 10), End: vec2(10, 10)})
+
+	sel := ObjectSelection{BuildingIdxs: []int{0}, PathIdxs: []PathSel{{Idx: 0, Start: true}, {Idx: 1, Start: true, End: true}}}
+	inv := scene.InvertSelection(sel)
+	if len(inv.BuildingIdxs) != 0 || len(inv.PathIdxs) != 1 || inv.PathIdxs[0] != (PathSel{Idx: 0, Start: true, End: true}) {
+		t.Errorf("invert: got %v, want path 0 fully selected", inv)
+	}
+	if want := rl.NewRectangle(0, 0, 10, 0); inv.Bounds != want {
+		t.Errorf("invert bounds: got %v, want %v", inv.Bounds, want)
+	}
+	if all := scene.InvertSelection(ObjectSelection{}); len(all.BuildingIdxs) != 1 || len(all.PathIdxs) != 2 {
+		t.Errorf("invert empty selection: got %v, want every object", all)
+	}
+}
+
+// TestAlignBuildings checks that rotated buildings bounds are aligned, in a single history step
+func TestAlignBuildings(t *testing.T) {
+	setupDragScene(t)
+	rotated := scene.Buildings[0]
+	rotated.Pos, rotated.Rot = vec2(40, 50), 90
+	scene.AddBuilding(rotated)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+	historyPos := scene.historyPos
+	path := scene.Paths[0]
+
+	sel := scene.SelectAll()
+	for _, align := range []Alignment{AlignLeft, AlignBottom, AlignCenterX} {
+		scene.AlignBuildings(sel, align)
+		a, b := align.coord(scene.Buildings[0].Bounds()), align.coord(scene.Buildings[1].Bounds())
+		if a != b {
+			t.Errorf("%v: got %v and %v, want equal", align, a, b)
+		}
+	}
+	if scene.historyPos != historyPos+3 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+3)
+	}
+	if scene.Paths[0] != path {
+		t.Errorf("path moved: got %v, want %v", scene.Paths[0], path)
+	}
+}
+
+// TestPlacementValidityHook checks that the hook is only called on validity transitions
+func TestPlacementValidityHook(t *testing.T) {
+	setupDragScene(t)
+	app.Mode = ModeNewBuilding
+	newBuilding.Reset()
+	newBuilding.building = Building{DefIdx: scene.Buildings[0].DefIdx}
+	var calls []bool
+	OnPlacementValidityChanged(func(valid bool) { calls = append(calls, valid) })
+	t.Cleanup(func() {
+		placementValidityHooks = nil
+		newBuilding.Reset()
+	})
+
+	far, over := vec2(500, 500), scene.Buildings[0].Pos
+	for _, pos := range []rl.Vector2{far, far.Add(vec2(1, 0)), over, over, far} {
+		newBuilding.doMoveTo(pos)
+	}
+	if want := []bool{true, false, true}; !slices.Equal(calls, want) {
+		t.Errorf("hook calls: got %v, want %v", calls, want)
+	}
+
+	// right of the building, overlapping it once rotated
+	newBuilding.doMoveTo(vec2(21, 20))
+	newBuilding.doRotate()
+	if want := []bool{true, false, true, false}; !slices.Equal(calls, want) {
+		t.Errorf("hook calls after rotation: got %v, want %v", calls, want)
+	}
+}
+
+// TestPlacementDefault checks that the placement offset is rotated with the building, when previewed
+// and when added
+func TestPlacementDefault(t *testing.T) {
+	setupDragScene(t)
+	RegisterPlacementDefault("Assembler", 90, vec2(2, 1))
+	t.Cleanup(func() { delete(placementDefaults, "Assembler") })
+
+	pos := vec2(100, 20)
+	for rot, want := range map[int32]rl.Vector2{0: vec2(102, 21), 90: vec2(99, 22), 180: vec2(98, 19), 270: vec2(101, 18)} {
+		b := Building{DefIdx: buildingDefs.Index("Assembler"), Rot: rot}
+		if got := PreviewBuildingAt(b, pos).Pos; got != want {
+			t.Errorf("preview at rotation %d: got %v, want %v", rot, got, want)
+		}
+		b.Pos = pos
+		scene.AddBuilding(b)
+		if got := scene.Buildings[len(scene.Buildings)-1].Pos; got != want {
+			t.Errorf("added at rotation %d: got %v, want %v", rot, got, want)
+		}
+	}
+}
+
+// TestMirror checks the mirrored rotations, and that mirroring twice restores the selection
+func TestMirror(t *testing.T) {
+	for _, tt := range []struct {
+		flip      Axis
+		rot, want int32
+	}{
+		{AxisX, 0, 180}, {AxisX, 90, 90}, {AxisX, 180, 0}, {AxisX, 270, 270},
+		{AxisY, 0, 0}, {AxisY, 90, 270}, {AxisY, 180, 180}, {AxisY, 270, 90},
+	} {
+		if got := mirrorRot(tt.rot, tt.flip); got != tt.want {
+			t.Errorf("mirrorRot(%d, %v): got %d, want %d", tt.rot, tt.flip, got, tt.want)
+		}
+	}
+
+	setupDragScene(t)
+	scene.Buildings[0].Rot = 90
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 4)})
+	scene.AddTextBox(TextBox{Bounds: rl.NewRectangle(30, 0, 10, 6), Content: "a"})
+	before := scene.ObjectCollection.clone()
+	sel := scene.SelectAll()
+
+	scene.Mirror(sel, AxisY)
+	c := sel.Bounds.Center()
+	if got, want := scene.Paths[0], (Path{DefIdx: 0, Start: vec2(0, 2*c.Y), End: vec2(10, 2*c.Y-4)}); got != want {
+		t.Errorf("mirrored path: got %v, want %v", got, want)
+	}
+	if got := scene.Buildings[0].Rot; got != 270 {
+		t.Errorf("mirrored building rotation: got %d, want 270", got)
+	}
+	for _, flip := range []Axis{AxisY, AxisX, AxisX} {
+		scene.Mirror(sel, flip)
+	}
+	if !scene.ObjectCollection.Equal(before) {
+		t.Errorf("mirroring twice: got %v, want %v", scene.ObjectCollection, before)
+	}
+	if ok, _, _ := scene.Undo(); !ok || len(scene.history) != 6 {
+		t.Errorf("mirror is not a single operation: history length %d", len(scene.history))
+	}
+}
+
+// TestRotateSelection checks that four quarter turns restore the objects, and text boxes are kept axis aligned
+func TestRotateSelection(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 4)})
+	scene.AddTextBox(TextBox{Bounds: rl.NewRectangle(30, 0, 10, 6), Content: "a"})
+	before := scene.ObjectCollection.clone()
+	sel := scene.SelectAll()
+	historyPos := scene.historyPos
+
+	scene.RotateSelection(sel, 1)
+	if got := scene.TextBoxes[0].Bounds; got.Width != 6 || got.Height != 10 {
+		t.Errorf("rotated text box size: got %vx%v, want 6x10", got.Width, got.Height)
+	}
+	if got := scene.Buildings[0].Rot; got != 90 {
+		t.Errorf("rotated building rotation: got %d, want 90", got)
+	}
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+	for range 3 {
+		scene.RotateSelection(scene.SelectAll(), 1)
+	}
+	if !scene.ObjectCollection.EqualWithin(before, 1e-4) {
+		t.Errorf("four quarter turns: got %v, want %v", scene.ObjectCollection, before)
+	}
+	scene.RotateSelection(scene.SelectAll(), -1)
+	scene.RotateSelection(scene.SelectAll(), 1)
+	if !scene.ObjectCollection.EqualWithin(before, 1e-4) {
+		t.Errorf("quarter turn and back: got %v, want %v", scene.ObjectCollection, before)
+	}
+}
+
+// TestSnapSelectionToGrid checks that the selection is moved as a whole, once
+func TestSnapSelectionToGrid(t *testing.T) {
+	setupDragScene(t)
+	oldGrid := grid
+	grid = Grid{SnapStep: 4}
+	t.Cleanup(func() { grid = oldGrid })
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(1.5, 1), End: vec2(11, 1)})
+	sel := ObjectSelection{PathIdxs: []PathSel{{Idx: 0, Start: true, End: true}}}
+	historyPos := scene.historyPos
+
+	scene.SnapSelectionToGrid(sel)
+	if want := (Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(9.5, 0)}); scene.Paths[0] != want {
+		t.Errorf("snapped path: got %v, want %v", scene.Paths[0], want)
+	}
+	scene.SnapSelectionToGrid(sel)
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+}
+
+// TestDuplicateArray checks the copies offsets, their selection, and that they are a single operation
+func TestDuplicateArray(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+	sel := ObjectSelection{BuildingIdxs: []int{0}, PathIdxs: []PathSel{{Idx: 0, Start: true, End: true}}}
+	historyPos := scene.historyPos
+
+	if got := scene.DuplicateArray(sel, 0, vec2(20, 0)); !got.IsEmpty() || scene.historyPos != historyPos {
+		t.Errorf("count 0: got %v, want a no-op", got)
+	}
+	got := scene.DuplicateArray(sel, 3, vec2(20, 0))
+	if scene.historyPos != historyPos+1 || len(scene.Buildings) != 4 || len(scene.Paths) != 4 {
+		t.Fatalf("duplicate 3: historyPos=%d buildings=%d paths=%d", scene.historyPos, len(scene.Buildings), len(scene.Paths))
+	}
+	if !slices.Equal(got.BuildingIdxs, []int{1, 2, 3}) || len(got.PathIdxs) != 3 || got.PathIdxs[2] != (PathSel{Idx: 3, Start: true, End: true}) {
+		t.Errorf("duplicate 3 selection: got %v", got)
+	}
+	if want := vec2(60, 0); scene.Paths[3].Start != want {
+		t.Errorf("last copy path start: got %v, want %v", scene.Paths[3].Start, want)
+	}
+}
+
+// TestRepeatDragDuplicate checks that repeating a drag-duplicate continues the array from the copy
+func TestRepeatDragDuplicate(t *testing.T) {
+	setupDragScene(t)
+	orig := scene.Buildings[0].Pos
+
+	start := selection.Bounds.Center()
+	selection.doBeginTransformation(SelectionDuplicate, start, false)
+	selection.doMoveTo(start.Add(vec2(30, 0)))
+	selection.doEndTransformation(false)
+	selection.doCancelTransformation() // leaves the duplicate mode, the originals stay selected
+	if len(scene.Buildings) != 2 || !slices.Equal(selection.BuildingIdxs, []int{0}) {
+		t.Fatalf("drag-duplicate: buildings=%d selection=%v", len(scene.Buildings), selection.BuildingIdxs)
+	}
+
+	selection.doRepeatTransform()
+	if len(scene.Buildings) != 3 {
+		t.Fatalf("repeat: got %d buildings, want 3", len(scene.Buildings))
+	}
+	if want := orig.Add(vec2(60, 0)); scene.Buildings[2].Pos != want {
+		t.Errorf("second copy position: got %v, want %v", scene.Buildings[2].Pos, want)
+	}
+	if !slices.Equal(selection.BuildingIdxs, []int{2}) {
+		t.Errorf("repeat selection: got %v, want [2]", selection.BuildingIdxs)
+	}
+	selection.doRepeatTransform()
+	if want := orig.Add(vec2(90, 0)); len(scene.Buildings) != 4 || scene.Buildings[3].Pos != want {
+		t.Errorf("third copy: buildings=%d, want position %v", len(scene.Buildings), want)
+	}
+}
+
+// TestSecondarySelectionRemap checks that the secondary selection follows its objects across swap
+// deletes and their undo
+func TestSecondarySelectionRemap(t *testing.T) {
+	setupDragScene(t)
+	for _, x := range []float32{30, 50, 70} {
+		scene.AddBuilding(Building{DefIdx: scene.Buildings[0].DefIdx, Pos: vec2(x, 20)})
+	}
+	selection.secondary = ObjectSelection{BuildingIdxs: []int{1, 3}}
+	last := scene.Buildings[3]
+
+	// building 3 is swapped into the slot of building 0
+	scene.DeleteObjects(ObjectSelection{BuildingIdxs: []int{0}})
+	if !slices.Equal(selection.secondary.BuildingIdxs, []int{0, 1}) || scene.Buildings[0] != last {
+		t.Errorf("after delete: secondary %v, want [0 1]", selection.secondary.BuildingIdxs)
+	}
+	scene.Undo()
+	if !slices.Equal(selection.secondary.BuildingIdxs, []int{1, 3}) {
+		t.Errorf("after undo: secondary %v, want [1 3]", selection.secondary.BuildingIdxs)
+	}
+	scene.DeleteObjects(ObjectSelection{BuildingIdxs: []int{1}})
+	if !slices.Equal(selection.secondary.BuildingIdxs, []int{1}) || scene.Buildings[1] != last {
+		t.Errorf("after deleting a secondary building: secondary %v, want [1]", selection.secondary.BuildingIdxs)
+	}
+	app.Mode = ModeNormal
+	selection.doSwapSelections()
+	if !slices.Equal(selection.BuildingIdxs, []int{1}) || selection.Bounds != last.Bounds() {
+		t.Errorf("swapped selection: %v", selection.ObjectSelection)
+	}
+}
+
+// TestSwapSelections checks that swapping twice restores the selection, with the matching app mode
+func TestSwapSelections(t *testing.T) {
+	setupDragScene(t)
+	selection.SwapSelections()
+	if app.Mode != ModeNormal || !selection.IsEmpty() || !slices.Equal(selection.secondary.BuildingIdxs, []int{0}) {
+		t.Errorf("first swap: got mode %v with %v selected and secondary %v, want normal mode, nothing selected and secondary [0]",
+			app.Mode, selection.ObjectSelection, selection.secondary)
+	}
+	selection.SwapSelections()
+	if app.Mode != ModeSelection || !slices.Equal(selection.BuildingIdxs, []int{0}) || !selection.secondary.IsEmpty() {
+		t.Errorf("second swap: got mode %v with %v selected and secondary %v, want building 0 selected and no secondary",
+			app.Mode, selection.ObjectSelection, selection.secondary)
+	}
+}
+
+// TestWrapSelectionInTextBox checks that the added text box is selected, and that nothing is selected
+// when it is rejected
+func TestWrapSelectionInTextBox(t *testing.T) {
+	setupDragScene(t)
+	scene.AddTextBox(TextBox{Bounds: rl.NewRectangle(100, 100, 10, 10)})
+	sel := selection.ObjectSelection.clone()
+
+	invalid := sel.clone()
+	invalid.Bounds.X = float32(math.NaN())
+	scene.WrapSelectionInTextBox(invalid, "title", 5)
+	if len(scene.TextBoxes) != 1 || !slices.Equal(selection.BuildingIdxs, []int{0}) || len(selection.TextBoxIdxs) != 0 {
+		t.Errorf("rejected text box: got %d text boxes with %v selected, want 1 and the selection kept",
+			len(scene.TextBoxes), selection.ObjectSelection)
+	}
+	scene.WrapSelectionInTextBox(sel, "title", 5)
+	if len(scene.TextBoxes) != 2 || !slices.Equal(selection.TextBoxIdxs, []int{1}) || scene.TextBoxes[1].Bounds != sel.BoundsWithMargin(5) {
+		t.Errorf("added text box: got %v with %v selected, want text box 1 selected", scene.TextBoxes, selection.ObjectSelection)
+	}
+}
+
+// TestRemapDefIndices checks that the objects held outside of the scene are remapped with it
+func TestRemapDefIndices(t *testing.T) {
+	setupDragScene(t)
+	defs := buildingDefs
+	t.Cleanup(func() {
+		buildingDefs = defs
+		stamp = Stamp{}
+		reference = ObjectCollection{}
+	})
+	assembler := Building{DefIdx: defs.Index("Assembler")}
+	stamp.doStart(ObjectCollection{Buildings: []Building{assembler}})
+	reference = ObjectCollection{Buildings: []Building{assembler}}
+	selection.doBeginTransformation(SelectionDuplicate, selection.Bounds.Center(), false)
+
+	reversed := slices.Clone(defs)
+	slices.Reverse(reversed)
+	if err := scene.RemapDefIndices(defs, reversed); err != nil {
+		t.Fatal(err)
+	}
+	buildingDefs = reversed
+	for name, b := range map[string]Building{
+		"scene": scene.Buildings[0], "stamp": stamp.objects.Buildings[0], "reference": reference.Buildings[0],
+		"transform": selection.transform.Buildings[0],
+	} {
+		if class := b.Def().Class; class != "Assembler" {
+			t.Errorf("%s building class: got %s, want Assembler", name, class)
+		}
+	}
+}
+
+// TestStatusbarSelectionMetrics checks that the status bar selection metrics follow the scene and
+// the selection changes
+func TestStatusbarSelectionMetrics(t *testing.T) {
+	setupDragScene(t)
+	var sb guiStatusbar
+	if m := sb.selectionMetrics(); m.Buildings != 1 || m.Width != 10 {
+		t.Errorf("initial metrics %+v", m)
+	}
+	scene.AddBuilding(Building{DefIdx: scene.Buildings[0].DefIdx, Pos: vec2(40, 20)})
+	selection.BuildingIdxs = append(selection.BuildingIdxs, 1)
+	if m := sb.selectionMetrics(); m.Buildings != 2 || m.Width != 40 {
+		t.Errorf("metrics after selection change %+v", m)
+	}
+	scene.TransformObjects(ObjectSelection{BuildingIdxs: []int{1}}, vec2(40, 20), vec2(10, 0), 0)
+	if m := sb.selectionMetrics(); m.Width != 50 {
+		t.Errorf("metrics after scene change %+v", m)
+	}
+}
+
+// TestHistoryOpBounds checks the bounds of the last done and undone operations
+func TestHistoryOpBounds(t *testing.T) {
+	setupDragScene(t)
+	bounds := scene.Buildings[0].Bounds()
+	sel := ObjectSelection{BuildingIdxs: []int{0}, Bounds: bounds}
+	scene.TransformObjects(sel, bounds.Center(), vec2(100, 0), 0)
+	want := rl.NewRectangle(bounds.X, bounds.Y, bounds.Width+100, bounds.Height)
+
+	if got, ok := scene.HistoryOpBounds(scene.historyPos - 1); !ok || got != want {
+		t.Errorf("done move bounds: got %v %v, want %v", got, ok, want)
+	}
+	scene.Undo()
+	if got, ok := scene.HistoryOpBounds(scene.historyPos); !ok || got != want {
+		t.Errorf("undone move bounds: got %v %v, want %v", got, ok, want)
+	}
+	if _, ok := scene.HistoryOpBounds(scene.historyPos + 1); ok {
+		t.Errorf("out of range operation bounds: got ok")
+	}
+}
+
+// TestSelectionEncode checks that selections round-trip through their text encoding
+func TestSelectionEncode(t *testing.T) {
+	sels := []ObjectSelection{
+		{},
+		{BuildingIdxs: []int{0, 3}},
+		{
+			BuildingIdxs: []int{1},
+			PathIdxs:     []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 2, Start: true}, {Idx: 5, End: true}, {Idx: 7}},
+			TextBoxIdxs:  []int{4, 12},
+		},
+		{PathIdxs: []PathSel{{Idx: 10, End: true}}},
+	}
+	for _, sel := range sels {
+		str := sel.Encode()
+		got, err := DecodeSelection(str)
+		if err != nil {
+			t.Fatalf("%q: %v", str, err)
+		}
+		if !slices.Equal(got.BuildingIdxs, sel.BuildingIdxs) || !slices.Equal(got.PathIdxs, sel.PathIdxs) ||
+			!slices.Equal(got.TextBoxIdxs, sel.TextBoxIdxs) {
+			t.Errorf("%q: decoded %v, want %v", str, got, sel)
+		}
+	}
+	if got := sels[2].Encode(); got != "b:1;p:0,2s,5e,7n;t:4,12" {
+		t.Errorf("encoded %q", got)
+	}
+
+	for _, str := range []string{"x:1", "b:", "b:1;b:2", "b:-1", "p:3x", "p:s", "b:1,,2", "t"} {
+		if _, err := DecodeSelection(str); err == nil {
+			t.Errorf("%q: expected an error", str)
+		}
+	}
+}
+
+// TestRotateBuildingsInPlace checks that each building rotates around its own center in one operation
+func TestRotateBuildingsInPlace(t *testing.T) {
+	setupDragScene(t)
+	scene.AddBuilding(Building{DefIdx: buildingDefs.Index("Assembler"), Pos: vec2(100, 20)})
+	sel := scene.SelectAll()
+	before := slices.Clone(scene.Buildings)
+
+	scene.RotateBuildingsInPlace(sel)
+	for i, b := range scene.Buildings {
+		if b.Rot != (before[i].Rot+90)%360 {
+			t.Errorf("building %d: rot %d, want %d", i, b.Rot, (before[i].Rot+90)%360)
+		}
+		got, want := b.Bounds().Center(), before[i].Bounds().Center()
+		if math32.Abs(got.X-want.X) > 0.5 || math32.Abs(got.Y-want.Y) > 0.5 {
+			t.Errorf("building %d: center %v, want %v", i, got, want)
+		}
+		if bounds := b.Bounds(); bounds.X != math32.Round(bounds.X) || bounds.Y != math32.Round(bounds.Y) {
+			t.Errorf("building %d: bounds %v not aligned", i, bounds)
+		}
+	}
+	if ok, _, _ := scene.Undo(); !ok || !slices.Equal(scene.Buildings, before) {
+		t.Errorf("undo: buildings %v, want %v", scene.Buildings, before)
+	}
+}
+
+// TestReversePaths checks that reversing paths swaps their ends and the ends selection
+func TestReversePaths(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 5), End: vec2(10, 5)})
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 9), End: vec2(10, 9)})
+	before := slices.Clone(scene.Paths)
+	sel := ObjectSelection{PathIdxs: []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 1, Start: true}}}
+
+	got := scene.ReversePaths(sel)
+	for i, want := range []Path{
+		{DefIdx: 0, Start: vec2(10, 0), End: vec2(0, 0)},
+		{DefIdx: 0, Start: vec2(10, 5), End: vec2(0, 5)},
+		before[2],
+	} {
+		if scene.Paths[i] != want {
+			t.Errorf("path %d: %v, want %v", i, scene.Paths[i], want)
+		}
+	}
+	if want := []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 1, End: true}}; !slices.Equal(got.PathIdxs, want) {
+		t.Errorf("selection %v, want %v", got.PathIdxs, want)
+	}
+
+	scene.ReversePaths(got)
+	if !slices.Equal(scene.Paths, before) {
+		t.Errorf("reversed twice: paths %v, want %v", scene.Paths, before)
+	}
+}
+
+// TestStickyConnections checks that only the connected ends of paths follow a dragged building
+func TestStickyConnections(t *testing.T) {
+	setupDragScene(t)
+	t.Cleanup(func() { stickyConnections = false })
+	bounds := scene.Buildings[0].Bounds()
+	right := vec2(bounds.X+bounds.Width, bounds.Y+1)
+	scene.AddObjects(ObjectCollection{Paths: []Path{
+		{Start: right, End: right.Add(vec2(20, 0))},          // connected start
+		{Start: vec2(0, 100), End: vec2(bounds.X, bounds.Y)}, // connected end
+		{Start: vec2(0, 100), End: vec2(0, 120)},             // not connected
+	}})
+	before := slices.Clone(scene.Paths)
+	historyPos := scene.historyPos
+
+	stickyConnections = true
+	start := selection.Bounds.Center()
+	selection.doBeginTransformation(SelectionDrag, start, false)
+	selection.doMoveTo(start.Add(vec2(30, 0)))
+	preview := slices.Clone(selection.transform.Paths)
+	selection.doEndTransformation(false)
+
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+	want := []Path{
+		{Start: before[0].Start.Add(vec2(30, 0)), End: before[0].End},
+		{Start: before[1].Start, End: before[1].End.Add(vec2(30, 0))},
+		before[2],
+	}
+	if !slices.Equal(scene.Paths, want) {
+		t.Errorf("paths: got %v, want %v", scene.Paths, want)
+	}
+	if !slices.Equal(preview, want[:2]) {
+		t.Errorf("previewed paths: got %v, want %v", preview, want[:2])
+	}
+	if !slices.Equal(selection.PathIdxs, nil) {
+		t.Errorf("selected paths: got %v, want none", selection.PathIdxs)
+	}
+}
+
+// TestMergeCollinearPaths checks that collinear touching or overlapping paths of the same definition
+// are merged, as a single history step
+func TestMergeCollinearPaths(t *testing.T) {
+	setupDragScene(t)
+	scene.AddObjects(ObjectCollection{Paths: []Path{
+		{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)},
+		{DefIdx: 0, Start: vec2(10, 0), End: vec2(20, 0)}, // touching
+		{DefIdx: 0, Start: vec2(15, 0), End: vec2(30, 0)}, // overlapping
+		{DefIdx: 0, Start: vec2(0, 5), End: vec2(10, 5)},  // parallel
+		{DefIdx: 0, Start: vec2(40, 0), End: vec2(50, 0)}, // disjoint
+		{DefIdx: 1, Start: vec2(30, 0), End: vec2(40, 0)}, // other definition
+		{DefIdx: 0, Start: vec2(0, 10), End: vec2(5, 15)},
+		{DefIdx: 0, Start: vec2(10, 20), End: vec2(5, 15)}, // reversed diagonal
+	}})
+	before := slices.Clone(scene.Paths)
+	historyPos := scene.historyPos
+
+	got, ok := scene.MergeCollinearPaths(scene.SelectAll())
+	if !ok {
+		t.Fatal("no path merged")
+	}
+	// deleted paths are replaced by the last ones
+	want := []Path{
+		{DefIdx: 0, Start: vec2(0, 0), End: vec2(30, 0)},
+		before[5],
+		{DefIdx: 0, Start: vec2(0, 10), End: vec2(10, 20)},
+		before[3], before[4],
+	}
+	if !slices.Equal(scene.Paths, want) {
+		t.Errorf("paths %v, want %v", scene.Paths, want)
+	}
+	if want := []PathSel{{Idx: 0, Start: true, End: true}, {Idx: 2, Start: true, End: true}}; !slices.Equal(got.PathIdxs, want) {
+		t.Errorf("selection %v, want %v", got.PathIdxs, want)
+	}
+	if scene.historyPos != historyPos+1 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+1)
+	}
+	if _, ok := scene.MergeCollinearPaths(scene.SelectAll()); ok {
+		t.Error("merged paths again")
+	}
+	scene.Undo()
+	if !slices.Equal(scene.Paths, before) {
+		t.Errorf("undo: paths %v, want %v", scene.Paths, before)
+	}
+}
+
+// TestSetPosition checks that typed positions are applied as a single operation, and invalid ones
+// rejected
+func TestSetPosition(t *testing.T) {
+	setupDragScene(t)
+	scene.AddPath(Path{DefIdx: 0, Start: vec2(0, 0), End: vec2(10, 0)})
+	building, path := Object{Type: TypeBuilding, Idx: 0}, Object{Type: TypePath, Idx: 0}
+	if _, values, ok := scene.PositionFields(building); !ok || !slices.Equal(values, []float32{10, 20, 0}) {
+		t.Errorf("building fields %v %v", values, ok)
+	}
+	historyPos := scene.historyPos
+
+	if !scene.SetPosition(building, []float32{1.5, -2, -90}) {
+		t.Fatal("building position rejected")
+	}
+	if b := scene.Buildings[0]; b.Pos != vec2(1.5, -2) || b.Rot != 270 {
+		t.Errorf("building %v", b)
+	}
+	if !scene.SetPosition(path, []float32{1, 2, 3, 4}) {
+		t.Fatal("path position rejected")
+	}
+	if p := scene.Paths[0]; p.Start != vec2(1, 2) || p.End != vec2(3, 4) {
+		t.Errorf("path %v", p)
+	}
+	if scene.historyPos != historyPos+2 {
+		t.Errorf("historyPos: got %d, want %d", scene.historyPos, historyPos+2)
+	}
+
+	for _, values := range [][]float32{{1, 2, 45}, {1, 2, 90.5}, {1, 2}, {float32(math.NaN()), 2, 0}} {
+		if scene.SetPosition(building, values) {
+			t.Errorf("building position %v accepted", values)
+		}
+	}
+	if scene.SetPosition(Object{Type: TypeTextBox}, nil) {
+		t.Error("text box position accepted")
+	}
+	if scene.historyPos != historyPos+2 {
+		t.Errorf("historyPos after invalid positions: got %d, want %d", scene.historyPos, historyPos+2)
+	}
+}
+
+// TestSelectorDirection checks that left-to-right drags are window selections and right-to-left
+// drags are crossing selections
+func TestSelectorDirection(t *testing.T) {
+	setupDragScene(t) // building bounds: 5 12 10 15
+	app.Mode = ModeNormal
+	t.Cleanup(func() { selector = Selector{} })
+
+	for _, tc := range []struct {
+		start, end rl.Vector2
+		want       []int
+	}{
+		{vec2(0, 0), vec2(12, 30), nil},      // window, partially enclosed
+		{vec2(12, 30), vec2(0, 0), []int{0}}, // crossing, touched
+		{vec2(0, 0), vec2(20, 30), []int{0}}, // window, fully enclosed
+		{vec2(20, 30), vec2(16, 0), nil},     // crossing, not touched
+		{vec2(0, 30), vec2(20, 0), []int{0}}, // window, dragged upward
+		{vec2(12, 0), vec2(0, 30), []int{0}}, // crossing, dragged downward
+	} {
+		selector = Selector{selecting: true, start: tc.start, end: tc.start}
+		selector.doMoveTo(tc.end)
+		if !slices.Equal(selector.BuildingIdxs, tc.want) {
+			t.Errorf("drag from %v to %v: selected %v, want %v", tc.start, tc.end, selector.BuildingIdxs, tc.want)
+		}
+	}
+}
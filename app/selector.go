@@ -12,6 +12,11 @@ import (
 
 var selector Selector
 
+// Selector selects the objects in the rectangle dragged from an empty scene spot
+//
+// Dragging left-to-right is a window selection: only the objects fully enclosed are selected.
+// Dragging right-to-left is a crossing selection: any object touched is selected. Path ends inside
+// the rectangle are selected individually either way, see [Scene.ObjectsInRect].
 type Selector struct {
 	// True when drawing the selector rectangle
 	selecting bool
@@ -21,6 +26,9 @@ type Selector struct {
 	ObjectSelection
 }
 
+// crossing returns true if the selector rectangle was dragged right-to-left (crossing selection)
+func (s Selector) crossing() bool { return s.end.X < s.start.X }
+
 func (s Selector) traceState(key, val string) {
 	if log.WillTrace() {
 		if key != "" && val != "" {
@@ -60,6 +68,9 @@ func (s *Selector) GetAction() Action {
 			return selection.doInitSingleDrag(scene.Hovered, mouse.Pos)
 		}
 	}
+	if !s.selecting && keyboard.Binding() == BindingSwapSelections {
+		return selection.doSwapSelections()
+	}
 	if s.selecting {
 		if mouse.Left.Released {
 			return s.doSelect()
@@ -88,8 +99,7 @@ func (s *Selector) doMoveTo(pos rl.Vector2) Action {
 	assert(s.selecting, "Selector.doMoveTo: selector not active")
 	s.end = pos
 	rect := rl.NewRectangleCorners(s.start, s.end)
-	s.ObjectSelection.reset()
-	scene.SelectFromRect(&s.ObjectSelection, rect)
+	s.ObjectSelection = scene.ObjectsInRect(rect, !s.crossing())
 	s.traceState("after", "doMoveTo")
 	return nil
 }
@@ -120,12 +130,34 @@ func (s *Selector) Dispatch(action Action) Action {
 	}
 }
 
-// Draw selector rectangle
+// Draw selector rectangle: solid blue for window selections, dashed green for crossing selections
 func (s Selector) Draw() {
 	if s.selecting {
 		rect := rl.NewRectangleCorners(s.start, s.end)
-		rl.DrawRectangleLinesEx(rect, 3/camera.Zoom(), colors.WithAlpha(colors.Blue500, 0.5))
+		if s.crossing() {
+			rl.DrawRectangleRec(rect, colors.WithAlpha(colors.Green500, 0.1))
+			drawDashedRectangle(rect, 3/camera.Zoom(), 12/camera.Zoom(), colors.WithAlpha(colors.Green500, 0.7))
+		} else {
+			rl.DrawRectangleRec(rect, colors.WithAlpha(colors.Blue500, 0.1))
+			rl.DrawRectangleLinesEx(rect, 3/camera.Zoom(), colors.WithAlpha(colors.Blue500, 0.5))
+		}
 
 		// TODO: draw objects in selector rectangle
 	}
 }
+
+// drawDashedRectangle draws the outline of rect with dashes (and gaps) of length dash
+func drawDashedRectangle(rect rl.Rectangle, thick, dash float32, color rl.Color) {
+	corners := [5]rl.Vector2{rect.TopLeft(), rect.TopRight(), rect.BottomRight(), rect.BottomLeft(), rect.TopLeft()}
+	for i := range 4 {
+		a, b := corners[i], corners[i+1]
+		length := b.Subtract(a).Length()
+		if length == 0 {
+			continue
+		}
+		dir := b.Subtract(a).Scale(1 / length)
+		for d := float32(0); d < length; d += 2 * dash {
+			rl.DrawLineEx(a.Add(dir.Scale(d)), a.Add(dir.Scale(min(d+dash, length))), thick, color)
+		}
+	}
+}
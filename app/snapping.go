@@ -0,0 +1,117 @@
+// snapping - Snap candidates for objects placement (grid, buildings, paths)
+
+package app
+
+import (
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// snap radius used when placing new objects (screen pixels)
+const placementSnapRadius = 10
+
+// SnapKind enumerates the kinds of [SnapPoint]
+type SnapKind int
+
+const (
+	// Nearest grid point
+	SnapGrid SnapKind = iota
+	// Center of a building
+	SnapBuildingCenter
+	// Middle of a building bounds edge
+	SnapBuildingEdge
+	// Start or end of a path
+	SnapPathEnd
+	// Middle of a path
+	SnapPathMiddle
+)
+
+func (k SnapKind) String() string {
+	switch k {
+	case SnapGrid:
+		return "SnapGrid"
+	case SnapBuildingCenter:
+		return "SnapBuildingCenter"
+	case SnapBuildingEdge:
+		return "SnapBuildingEdge"
+	case SnapPathEnd:
+		return "SnapPathEnd"
+	case SnapPathMiddle:
+		return "SnapPathMiddle"
+	default:
+		return "Invalid"
+	}
+}
+
+// SnapPoint is a position a placed object can snap to, see [Scene.SnapCandidates]
+type SnapPoint struct {
+	Kind SnapKind
+	// Pos is the snap position (world coordinates)
+	Pos rl.Vector2
+	// Dist is the distance from the queried position
+	Dist float32
+}
+
+// SnapCandidates returns the snap points within radius of pos, sorted by increasing distance.
+//
+// Candidates are the nearest grid point, the buildings center and middle of their bounds edges, and
+// the paths ends and middle. Hidden objects and objects of hidden layers are ignored.
+func (s Scene) SnapCandidates(pos rl.Vector2, radius float32) []SnapPoint {
+	var points []SnapPoint
+	add := func(kind SnapKind, p rl.Vector2) {
+		if d := p.Distance(pos); d <= radius {
+			points = append(points, SnapPoint{Kind: kind, Pos: p, Dist: d})
+		}
+	}
+
+	add(SnapGrid, grid.Snap(pos))
+	if s.IsLayerVisible(LayerBuildings) {
+		for _, b := range s.Buildings {
+			bounds := b.Bounds()
+			if b.Hidden || !GrowRect(bounds, radius).CheckCollisionPoint(pos) {
+				continue
+			}
+			add(SnapBuildingCenter, bounds.Center())
+			tl, br := bounds.TopLeft(), bounds.BottomRight()
+			mid := bounds.Center()
+			add(SnapBuildingEdge, vec2(mid.X, tl.Y))
+			add(SnapBuildingEdge, vec2(mid.X, br.Y))
+			add(SnapBuildingEdge, vec2(tl.X, mid.Y))
+			add(SnapBuildingEdge, vec2(br.X, mid.Y))
+		}
+	}
+	if s.IsLayerVisible(LayerPaths) {
+		for _, p := range s.Paths {
+			if p.Hidden {
+				continue
+			}
+			add(SnapPathEnd, p.Start)
+			add(SnapPathEnd, p.End)
+			add(SnapPathMiddle, p.Start.Add(p.End).Scale(0.5))
+		}
+	}
+
+	slices.SortStableFunc(points, func(a, b SnapPoint) int {
+		switch {
+		case a.Dist < b.Dist:
+			return -1
+		case a.Dist > b.Dist:
+			return 1
+		default:
+			return 0
+		}
+	})
+	return points
+}
+
+// PlacementSnap returns the position a new object placed at pos snaps to: the nearest object snap
+// point if any, the nearest grid point otherwise.
+func (s Scene) PlacementSnap(pos rl.Vector2) rl.Vector2 {
+	for _, p := range s.SnapCandidates(pos, placementSnapRadius/camera.Zoom()) {
+		if p.Kind != SnapGrid {
+			return p.Pos
+		}
+	}
+	return grid.Snap(pos)
+}
@@ -0,0 +1,169 @@
+// spatial - Uniform grid spatial index of the scene objects (hit testing)
+
+package app
+
+import (
+	"slices"
+
+	"github.com/bonoboris/satisfied/log"
+	"github.com/bonoboris/satisfied/math32"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// spatialCellSize is the width and height of the spatial index cells (world units)
+	spatialCellSize = 64
+	// spatialMaxCells is the maximum number of cells an object is indexed in, bigger objects are
+	// always tested (eg. long diagonal paths, big text boxes)
+	spatialMaxCells = 64
+)
+
+// spatialCell holds the indices of the objects whose bounds overlap a cell, in increasing order
+type spatialCell struct {
+	paths     []int
+	buildings []int
+	textBoxes []int
+}
+
+// spatialIndex is a uniform grid of the scene objects bounds, see [Scene.updateSpatialIndex]
+type spatialIndex struct {
+	cells map[[2]int32]*spatialCell
+	// objects overlapping too many cells to be indexed
+	oversized spatialCell
+}
+
+// spatialCellOf returns the coordinates of the cell containing pos
+func spatialCellOf(pos rl.Vector2) [2]int32 {
+	return [2]int32{int32(math32.Floor(pos.X / spatialCellSize)), int32(math32.Floor(pos.Y / spatialCellSize))}
+}
+
+// newSpatialIndex builds the spatial index of the scene objects, in O(n)
+func newSpatialIndex(s Scene) spatialIndex {
+	idx := spatialIndex{cells: map[[2]int32]*spatialCell{}}
+	insert := func(bounds rl.Rectangle, list func(c *spatialCell) *[]int, i int) {
+		tl, br := spatialCellOf(bounds.TopLeft()), spatialCellOf(bounds.BottomRight())
+		if (int(br[0]-tl[0])+1)*(int(br[1]-tl[1])+1) > spatialMaxCells {
+			l := list(&idx.oversized)
+			*l = append(*l, i)
+			return
+		}
+		for x := tl[0]; x <= br[0]; x++ {
+			for y := tl[1]; y <= br[1]; y++ {
+				c := idx.cells[[2]int32{x, y}]
+				if c == nil {
+					c = &spatialCell{}
+					idx.cells[[2]int32{x, y}] = c
+				}
+				l := list(c)
+				*l = append(*l, i)
+			}
+		}
+	}
+
+	for i := range s.Paths {
+		bounds, _ := s.ObjectBounds(Object{Type: TypePath, Idx: i})
+		insert(bounds, func(c *spatialCell) *[]int { return &c.paths }, i)
+	}
+	for i, b := range s.Buildings {
+		insert(b.Bounds(), func(c *spatialCell) *[]int { return &c.buildings }, i)
+	}
+	for i, tb := range s.TextBoxes {
+		insert(tb.Bounds, func(c *spatialCell) *[]int { return &c.textBoxes }, i)
+	}
+	return idx
+}
+
+// lastHit returns the highest index of cell and oversized lists for which hit returns true, or -1
+func lastHit(cell, oversized []int, hit func(i int) bool) int {
+	best := -1
+	for _, list := range [2][]int{cell, oversized} {
+		for k := len(list) - 1; k >= 0 && list[k] > best; k-- {
+			if hit(list[k]) {
+				best = list[k]
+				break
+			}
+		}
+	}
+	return best
+}
+
+// objectAt returns the non hidden object of s at pos, with [Scene.GetObjectAt] priorities among
+// non selected objects, or a zero-valued [Object].
+func (idx spatialIndex) objectAt(s Scene, pos rl.Vector2) Object {
+	cell := idx.cells[spatialCellOf(pos)]
+	if cell == nil {
+		cell = &spatialCell{}
+	}
+
+	if s.IsLayerVisible(LayerPaths) {
+		i := lastHit(cell.paths, idx.oversized.paths, func(i int) bool {
+			p := s.Paths[i]
+			return !p.Hidden && (p.CheckStartCollisionPoint(pos) || p.CheckEndCollisionPoint(pos) || p.CheckCollisionPoint(pos))
+		})
+		if i >= 0 {
+			switch p := s.Paths[i]; {
+			case p.CheckStartCollisionPoint(pos):
+				return Object{Type: TypePathStart, Idx: i}
+			case p.CheckEndCollisionPoint(pos):
+				return Object{Type: TypePathEnd, Idx: i}
+			default:
+				return Object{Type: TypePath, Idx: i}
+			}
+		}
+	}
+	if s.IsLayerVisible(LayerBuildings) {
+		i := lastHit(cell.buildings, idx.oversized.buildings, func(i int) bool {
+			return !s.Buildings[i].Hidden && s.Buildings[i].Bounds().CheckCollisionPoint(pos)
+		})
+		if i >= 0 {
+			return Object{Type: TypeBuilding, Idx: i}
+		}
+	}
+	if s.IsLayerVisible(LayerTextBoxes) {
+		i := lastHit(cell.textBoxes, idx.oversized.textBoxes, func(i int) bool {
+			return !s.TextBoxes[i].Hidden && s.TextBoxes[i].Bounds.CheckCollisionPoint(pos)
+		})
+		if i >= 0 {
+			return Object{Type: TypeTextBox, Idx: i}
+		}
+	}
+	return Object{}
+}
+
+// buildingsNear returns the indices of the buildings whose bounds may overlap r (with duplicates), and
+// false if r covers too many cells for the index to help.
+func (idx spatialIndex) buildingsNear(r rl.Rectangle) ([]int, bool) {
+	return idx.near(r, func(c *spatialCell) []int { return c.buildings })
+}
+
+// pathsNear returns the indices of the paths whose bounds may overlap r (with duplicates), and
+// false if r covers too many cells for the index to help.
+func (idx spatialIndex) pathsNear(r rl.Rectangle) ([]int, bool) {
+	return idx.near(r, func(c *spatialCell) []int { return c.paths })
+}
+
+// near returns the indices of the list of the cells overlapping r and of the oversized objects
+func (idx spatialIndex) near(r rl.Rectangle, list func(c *spatialCell) []int) ([]int, bool) {
+	tl, br := spatialCellOf(r.TopLeft()), spatialCellOf(r.BottomRight())
+	if (int(br[0]-tl[0])+1)*(int(br[1]-tl[1])+1) > spatialMaxCells {
+		return nil, false
+	}
+	near := slices.Clone(list(&idx.oversized))
+	for x := tl[0]; x <= br[0]; x++ {
+		for y := tl[1]; y <= br[1]; y++ {
+			if c := idx.cells[[2]int32{x, y}]; c != nil {
+				near = append(near, list(c)...)
+			}
+		}
+	}
+	return near, true
+}
+
+// updateSpatialIndex rebuilds the spatial index if needed
+func (s *Scene) updateSpatialIndex() {
+	if !s.indexUpToDate {
+		s.index = newSpatialIndex(*s)
+		s.indexUpToDate = true
+		log.Trace("scene.updateSpatialIndex", "cells", len(s.index.cells))
+	}
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// randomScene returns a scene with n random objects of each type, within [0, size) coordinates
+func randomScene(rnd *rand.Rand, n int, size float32) Scene {
+	pos := func() rl.Vector2 { return vec2(rnd.Float32()*size, rnd.Float32()*size) }
+	var s Scene
+	for range n {
+		s.Buildings = append(s.Buildings, Building{
+			DefIdx: rnd.Intn(len(buildingDefs)),
+			Pos:    pos(),
+			Rot:    int32(rnd.Intn(4) * 90),
+			Hidden: rnd.Intn(10) == 0,
+		})
+		start := pos()
+		end := start.Add(vec2(rnd.Float32()*100-50, rnd.Float32()*100-50))
+		if rnd.Intn(10) == 0 {
+			end = pos() // long, possibly diagonal path
+		}
+		s.Paths = append(s.Paths, Path{DefIdx: rnd.Intn(len(pathDefs)), Start: start, End: end, Hidden: rnd.Intn(10) == 0})
+		tb := TextBox{Hidden: rnd.Intn(10) == 0}
+		tb.Bounds.X, tb.Bounds.Y = rnd.Float32()*size, rnd.Float32()*size
+		tb.Bounds.Width, tb.Bounds.Height = rnd.Float32()*size/4, rnd.Float32()*size/4
+		s.TextBoxes = append(s.TextBoxes, tb)
+	}
+	return s
+}
+
+// TestSpatialIndex checks that indexed lookups return the same object as the linear scan
+func TestSpatialIndex(t *testing.T) {
+	selection = Selection{}
+	rnd := rand.New(rand.NewSource(1))
+	hits := 0
+	for range 5 {
+		const size = 1000
+		s := randomScene(rnd, 200, size)
+		s.updateSpatialIndex()
+		for range 2000 {
+			pos := vec2(rnd.Float32()*size*1.1-size*0.05, rnd.Float32()*size*1.1-size*0.05)
+			if got, want := s.GetObjectAt(pos), s.getObjectAtLinear(pos); got != want {
+				t.Fatalf("at %v: indexed lookup returned %v, linear scan %v", pos, got, want)
+			} else if !got.IsEmpty() {
+				hits++
+			}
+		}
+	}
+	if hits == 0 {
+		t.Error("no object found at any position")
+	}
+}
+
+// TestOverlappingSet checks that the incrementally updated overlapping set matches a full recompute
+func TestOverlappingSet(t *testing.T) {
+	selection = Selection{}
+	rnd := rand.New(rand.NewSource(1))
+	const size = 1000
+	s := randomScene(rnd, 200, size)
+	for i := range 300 {
+		switch n := len(s.Buildings); {
+		case n > 0 && rnd.Intn(2) == 0:
+			idx := rnd.Intn(n)
+			b := s.Buildings[idx]
+			b.Pos = b.Pos.Add(vec2(rnd.Float32()*40-20, rnd.Float32()*40-20))
+			s.ModifyObjects(ObjectSelection{BuildingIdxs: []int{idx}}, ObjectCollection{Buildings: []Building{b}})
+		case n > 1 && rnd.Intn(4) == 0:
+			idxs := rnd.Perm(n)[:1+rnd.Intn(2)]
+			slices.Sort(idxs)
+			s.DeleteObjects(ObjectSelection{BuildingIdxs: idxs})
+		case rnd.Intn(4) == 0:
+			s.Undo()
+		case rnd.Intn(4) == 0:
+			s.Redo()
+		default:
+			s.AddBuilding(Building{DefIdx: rnd.Intn(len(buildingDefs)), Pos: vec2(rnd.Float32()*size, rnd.Float32()*size)})
+		}
+
+		// let some operations accumulate between updates
+		if rnd.Intn(3) == 0 {
+			continue
+		}
+		want := map[int]bool{}
+		for _, pair := range overlappingPairs(s.buildingsBounds()) {
+			want[pair[0]], want[pair[1]] = true, true
+		}
+		if got := s.OverlappingSet(); !maps.Equal(got, want) {
+			t.Fatalf("op %d: overlapping set %v, want %v", i, got, want)
+		}
+	}
+}
+
+// TestFindPathCrossings checks that indexed crossings lookups match the test of every pair of paths
+func TestFindPathCrossings(t *testing.T) {
+	selection = Selection{}
+	rnd := rand.New(rand.NewSource(1))
+	s := randomScene(rnd, 300, 1000)
+	var want []PathCrossing
+	for i, p := range s.Paths {
+		for j, q := range s.Paths[i+1:] {
+			if p.Hidden || q.Hidden {
+				continue
+			}
+			if pos, ok := SegmentIntersection(p.Start, p.End, q.Start, q.End); ok {
+				want = append(want, PathCrossing{I: i, J: i + 1 + j, Pos: pos})
+			}
+		}
+	}
+	if got := s.FindPathCrossings(); !slices.Equal(got, want) || len(got) == 0 {
+		t.Errorf("crossings: got %v, want %v", got, want)
+	}
+}
+
+// TestPathsConnectedTo checks that indexed connected paths lookups match the linear scan
+func TestPathsConnectedTo(t *testing.T) {
+	selection = Selection{}
+	rnd := rand.New(rand.NewSource(1))
+	s := randomScene(rnd, 200, 1000)
+	const threshold = 2
+	found := 0
+	for bIdx, b := range s.Buildings {
+		bounds := GrowRect(b.Bounds(), threshold)
+		want := []int{}
+		for i, p := range s.Paths {
+			if bounds.CheckCollisionPoint(p.Start) || bounds.CheckCollisionPoint(p.End) {
+				want = append(want, i)
+			}
+		}
+		got := s.PathsConnectedTo(bIdx, threshold)
+		if !slices.Equal(got, want) || got == nil {
+			t.Fatalf("building %d: connected paths %v, want %v", bIdx, got, want)
+		}
+		found += len(got)
+	}
+	if found == 0 {
+		t.Error("no connected path found")
+	}
+	if got := s.PathsConnectedTo(-1, threshold); got == nil || len(got) != 0 {
+		t.Errorf("invalid building: connected paths %v, want empty", got)
+	}
+}
+
+// TestAutoRoutePath checks that routes go around buildings, orthogonally
+func TestAutoRoutePath(t *testing.T) {
+	selection = Selection{}
+	var s Scene
+	// Assembler is 10x15, centered at (50, 0)
+	s.AddBuilding(Building{DefIdx: buildingDefs.Index("Assembler"), Pos: vec2(50, 0)})
+	obstacle := s.Buildings[0].Bounds()
+	const clearance = 1
+	grown := GrowRect(obstacle, clearance)
+
+	for _, end := range []rl.Vector2{vec2(100, 0), vec2(100, 0.5)} {
+		points, ok := s.AutoRoutePath(vec2(0, 0), end, clearance)
+		if !ok {
+			t.Fatalf("to %v: no route", end)
+		}
+		if !points[0].Equals(vec2(0, 0)) || !points[len(points)-1].Equals(end) {
+			t.Errorf("to %v: route %v does not join the ends", end, points)
+		}
+		length := float32(0)
+		for i := 1; i < len(points); i++ {
+			a, b := points[i-1], points[i]
+			if a.X != b.X && a.Y != b.Y {
+				t.Errorf("to %v: segment %v %v is not orthogonal", end, a, b)
+			}
+			if segmentCrossesRect(a, b, grown) {
+				t.Errorf("to %v: segment %v %v crosses the building %v", end, a, b, grown)
+			}
+			length += a.Distance(b)
+		}
+		// the shortest routes follow the bottom edge of the grown building (Y = 8)
+		if length < 115 || length > 116 {
+			t.Errorf("to %v: route length %v", end, length)
+		}
+		if len(points) > 7 {
+			t.Errorf("to %v: route %v has too many turns", end, points)
+		}
+		sel, ok := s.AddRoute(0, points)
+		if !ok || len(sel.PathIdxs) != len(points)-1 {
+			t.Errorf("to %v: added route %v", end, sel)
+		}
+	}
+
+	// start inside overlapping buildings
+	s.AddBuilding(Building{DefIdx: buildingDefs.Index("Assembler"), Pos: vec2(50, 0)})
+	if _, ok := s.AutoRoutePath(vec2(50, 0.5), vec2(100, 0), clearance); !ok {
+		t.Error("no route out of a building")
+	}
+	if !SetAutoRouteStep(1) || SetAutoRouteStep(0) {
+		t.Error("invalid step accepted")
+	}
+}
@@ -0,0 +1,118 @@
+// stamp - Repeated placement of a blueprint following the cursor
+
+package app
+
+import (
+	"github.com/bonoboris/satisfied/log"
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+var stamp = Stamp{}
+
+// Stamp places copies of a blueprint, previewed at the cursor
+//
+// While active it captures the scene inputs: the blueprint bounding box top-left follows the snapped
+// cursor (see [Grid.Snap]), each click adds a copy as a single operation, Rotate rotates the
+// blueprint by a quarter turn. Escape deactivates it.
+type Stamp struct {
+	// whether the stamp is active
+	active bool
+	// stamped objects, normalized (see [ObjectCollection.normalize])
+	objects ObjectCollection
+	// position of the objects bounding box top-left (world coordinates)
+	pos rl.Vector2
+}
+
+// Active returns true if the stamp captures the inputs
+func (st Stamp) Active() bool { return st.active }
+
+// Update processes inputs while the stamp is active
+func (st *Stamp) Update() {
+	if !st.active {
+		return
+	}
+	switch keyboard.Binding() {
+	case BindingEscape:
+		st.doClear()
+		return
+	case BindingRotate, BindingRotateInPlace:
+		st.doRotate()
+	}
+	if !mouse.InScene {
+		return
+	}
+	st.doMoveTo(mouse.SnappedPos)
+	if mouse.Left.Pressed {
+		st.doStamp()
+	}
+}
+
+// doStart activates the stamp with the given objects, normalized
+func (st *Stamp) doStart(col ObjectCollection) {
+	if col.IsEmpty() {
+		log.Warn("cannot start stamp", "reason", "no objects")
+		return
+	}
+	col = col.clone()
+	col.normalize()
+	log.Debug("stamp.doStart", "buildings", len(col.Buildings), "paths", len(col.Paths), "textBoxes", len(col.TextBoxes))
+	*st = Stamp{active: true, objects: col, pos: mouse.SnappedPos}
+}
+
+// doClear deactivates the stamp
+func (st *Stamp) doClear() {
+	log.Debug("stamp.doClear")
+	*st = Stamp{}
+}
+
+// doMoveTo moves the stamp bounding box top-left to pos
+func (st *Stamp) doMoveTo(pos rl.Vector2) {
+	if st.active {
+		st.pos = pos
+	}
+}
+
+// doRotate rotates the stamped objects by a quarter turn clockwise (see [ObjectCollection.rotate]),
+// keeping their bounding box top-left at the stamp position
+func (st *Stamp) doRotate() {
+	if !st.active {
+		return
+	}
+	st.objects.rotate(1)
+	st.objects.normalize()
+	log.Debug("stamp.doRotate")
+}
+
+// doStamp adds a copy of the objects at the stamp position, as a single operation
+func (st *Stamp) doStamp() {
+	if !st.active {
+		return
+	}
+	if _, err := scene.insertBlueprint(st.objects, st.pos); err != nil {
+		log.Warn("cannot stamp", "reason", err.Error())
+	}
+}
+
+// Draw draws the stamp preview at its position
+func (st Stamp) Draw() {
+	if !st.active {
+		return
+	}
+	col := st.objects.clone()
+	sceneOpDelta{Translate: st.pos}.applyCollection(&col)
+	col.draw(DrawShadow)
+}
+
+// doStampBlueprint prompts for a blueprint file and starts stamping it
+func (a *App) doStampBlueprint() Action {
+	if !a.isNormal() {
+		return nil
+	}
+	log.Info("stamp blueprint")
+	col, ok := openBlueprint("Stamp blueprint")
+	if !ok {
+		return nil
+	}
+	stamp.doStart(col)
+	return a.doSwitchMode(ModeNormal, ResetAll())
+}
@@ -0,0 +1,54 @@
+package app
+
+import "testing"
+
+// TestStamp checks that each stamp adds a single operation, at the stamp position and rotation
+func TestStamp(t *testing.T) {
+	scene = Scene{}
+	t.Cleanup(func() { scene = Scene{}; stamp = Stamp{} })
+	stamp.doStart(ObjectCollection{
+		Buildings: []Building{{DefIdx: 0, Pos: vec2(10, 10)}},
+		Paths:     []Path{{DefIdx: 0, Start: vec2(10, 5), End: vec2(20, 5)}},
+	})
+	if !stamp.Active() {
+		t.Fatal("stamp not active")
+	}
+
+	stamp.doMoveTo(vec2(100, 100))
+	stamp.doStamp()
+	stamp.doMoveTo(vec2(200, 100))
+	stamp.doStamp()
+	if scene.historyPos != 2 || len(scene.Buildings) != 2 || len(scene.Paths) != 2 {
+		t.Fatalf("historyPos %d, objects %v", scene.historyPos, scene.ObjectCollection)
+	}
+	b := scene.Buildings[0].Bounds()
+	if bounds := (ObjectCollection{Buildings: scene.Buildings[:1], Paths: scene.Paths[:1]}).SelectAll().Bounds; bounds.X != 100 || bounds.Y != 100 {
+		t.Errorf("first stamp bounds %v, want top-left at (100, 100)", bounds)
+	}
+	if got := scene.Buildings[1].Bounds(); got.X != b.X+100 || got.Y != b.Y {
+		t.Errorf("second stamp building bounds %v, want %v moved by (100, 0)", got, b)
+	}
+
+	stamp.doRotate()
+	stamp.doMoveTo(vec2(0, 0))
+	stamp.doStamp()
+	rotated := scene.Buildings[2]
+	if rotated.Rot != 90 {
+		t.Errorf("rotated stamp building rotation %d, want 90", rotated.Rot)
+	}
+	if p := scene.Paths[2]; p.Start.X != p.End.X {
+		t.Errorf("rotated stamp path %v, want vertical", p)
+	}
+	if bounds := (ObjectCollection{Buildings: scene.Buildings[2:], Paths: scene.Paths[2:]}).SelectAll().Bounds; bounds.X != 0 || bounds.Y != 0 {
+		t.Errorf("rotated stamp bounds %v, want top-left at origin", bounds)
+	}
+
+	ok, _, _ := scene.Undo()
+	if !ok || len(scene.Buildings) != 2 || len(scene.Paths) != 2 {
+		t.Errorf("undo last stamp: objects %v", scene.ObjectCollection)
+	}
+	stamp.doClear()
+	if stamp.Active() {
+		t.Error("cleared stamp active")
+	}
+}
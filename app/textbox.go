@@ -19,6 +19,10 @@ const (
 type TextBox struct {
 	Bounds  rl.Rectangle
 	Content string
+	// Background text boxes are drawn behind the other objects (eg. region labels)
+	Background bool
+	// Hidden text boxes are not drawn and cannot be hovered or selected (see [Scene.Hide])
+	Hidden bool
 }
 
 func (tb TextBox) HandleRect() rl.Rectangle {
@@ -29,7 +33,7 @@ func (tb TextBox) HandleRect() rl.Rectangle {
 
 // Draw draws the textbox (must be called outside of Camera2D mode)
 func (tb *TextBox) Draw(state DrawState, drawHandle bool) {
-	if state == DrawSkip {
+	if state == DrawSkip || tb.Hidden {
 		return
 	}
 	if !dims.World.CheckCollisionRec(tb.Bounds) {
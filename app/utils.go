@@ -7,6 +7,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/bonoboris/satisfied/math32"
 	rl "github.com/gen2brain/raylib-go/raylib"
 )
 
@@ -182,3 +183,114 @@ func ParseFloat32(s string) (float32, error) {
 	}
 	return float32(f), nil
 }
+
+// SegmentIntersection returns the intersection point of the segments [a1, a2] and [b1, b2] and
+// whether they cross.
+//
+// Only proper crossings are reported: parallel or collinear segments, and segments touching by an
+// end (shared end or T-junction) are not considered crossing.
+func SegmentIntersection(a1, a2, b1, b2 rl.Vector2) (rl.Vector2, bool) {
+	r := a2.Subtract(a1)
+	s := b2.Subtract(b1)
+	denom := r.CrossProduct(s)
+	if denom == 0 {
+		return rl.Vector2{}, false
+	}
+	ab := b1.Subtract(a1)
+	t := ab.CrossProduct(s) / denom
+	u := ab.CrossProduct(r) / denom
+	if t <= 0 || t >= 1 || u <= 0 || u >= 1 {
+		return rl.Vector2{}, false
+	}
+	return a1.Add(r.Scale(t)), true
+}
+
+// ReflectPoint returns the reflection of p across the line passing through a and b (a != b).
+func ReflectPoint(p, a, b rl.Vector2) rl.Vector2 {
+	dir := b.Subtract(a).Normalize()
+	ap := p.Subtract(a)
+	proj := a.Add(dir.Scale(ap.DotProduct(dir)))
+	return proj.Scale(2).Subtract(p)
+}
+
+// AllFinite returns true if none of the given values is NaN or infinite.
+func AllFinite(vs ...float32) bool {
+	for _, v := range vs {
+		if math32.IsNaN(v) || math32.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// RectGap returns the distance between the rectangles a and b, 0 if touching.
+//
+// Overlapping rectangles return the negated overlap depth (the smallest overlap along X or Y).
+func RectGap(a, b rl.Rectangle) float32 {
+	// signed gaps along each axis, negative if the projections overlap
+	dx := max(b.X-(a.X+a.Width), a.X-(b.X+b.Width))
+	dy := max(b.Y-(a.Y+a.Height), a.Y-(b.Y+b.Height))
+	switch {
+	case dx < 0 && dy < 0:
+		return max(dx, dy)
+	case dx > 0 && dy > 0:
+		return math32.Sqrt(dx*dx + dy*dy)
+	default:
+		return max(dx, dy, 0)
+	}
+}
+
+// PointSegmentDistance returns the distance between p and the segment [a, b]
+func PointSegmentDistance(p, a, b rl.Vector2) float32 {
+	ab := b.Subtract(a)
+	lengthSqr := ab.LengthSqr()
+	if lengthSqr == 0 {
+		return p.Distance(a)
+	}
+	t := min(max(p.Subtract(a).DotProduct(ab)/lengthSqr, 0), 1)
+	return p.Distance(a.Add(ab.Scale(t)))
+}
+
+// PointInPolygon returns true if p is inside the polygon of the given vertices (even-odd rule)
+func PointInPolygon(p rl.Vector2, vertices []rl.Vector2) bool {
+	inside := false
+	for i, j := 0, len(vertices)-1; i < len(vertices); j, i = i, i+1 {
+		a, b := vertices[i], vertices[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// SegmentIntersectsRect returns true if the segment [a, b] intersects the rectangle r (edges
+// included), found by clipping the segment to r (Liang-Barsky).
+func SegmentIntersectsRect(a, b rl.Vector2, r rl.Rectangle) bool {
+	d := b.Subtract(a)
+	t0, t1 := float32(0), float32(1)
+	// clips [t0, t1] to p*t <= q for each rectangle side
+	clip := func(p, q float32) bool {
+		switch {
+		case p == 0:
+			return q >= 0
+		case p < 0:
+			t0 = max(t0, q/p)
+		default:
+			t1 = min(t1, q/p)
+		}
+		return t0 <= t1
+	}
+	return clip(-d.X, a.X-r.X) && clip(d.X, r.X+r.Width-a.X) &&
+		clip(-d.Y, a.Y-r.Y) && clip(d.Y, r.Y+r.Height-a.Y)
+}
+
+// RectContainsRect returns true if inner is inside outer (edges included)
+func RectContainsRect(outer, inner rl.Rectangle) bool {
+	return inner.X >= outer.X && inner.Y >= outer.Y &&
+		inner.X+inner.Width <= outer.X+outer.Width && inner.Y+inner.Height <= outer.Y+outer.Height
+}
+
+// GrowRect returns r grown by pad on every side
+func GrowRect(r rl.Rectangle, pad float32) rl.Rectangle {
+	return rl.NewRectangle(r.X-pad, r.Y-pad, r.Width+2*pad, r.Height+2*pad)
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"slices"
+	"testing"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// TestSwapDeleteInsertMany checks that [SwapInsertMany] exactly restores what [SwapDeleteMany] deleted
+func TestSwapDeleteInsertMany(t *testing.T) {
+	orig := Range(0, 20)
+	for _, idxs := range [][]int{{}, {0}, {19}, {0, 19}, {3, 4, 5}, {1, 7, 18, 19}, Range(0, 20), Range(10, 20)} {
+		s := SwapDeleteMany(slices.Clone(orig), idxs)
+		if len(s) != len(orig)-len(idxs) {
+			t.Fatalf("delete %v: got length %d, want %d", idxs, len(s), len(orig)-len(idxs))
+		}
+		for _, v := range s {
+			if SortedIntsIndex(idxs, v) >= 0 {
+				t.Fatalf("delete %v: %d not deleted: %v", idxs, v, s)
+			}
+		}
+		if s = SwapInsertMany(s, idxs, idxs); !slices.Equal(s, orig) {
+			t.Errorf("insert %v: got %v, want %v", idxs, s, orig)
+		}
+	}
+}
+
+// BenchmarkSwapDeleteMany deletes then restores half of a large slice, it must be O(n)
+func BenchmarkSwapDeleteMany(b *testing.B) {
+	const n = 10000
+	s := Range(0, n)
+	idxs := make([]int, 0, n/2)
+	for i := 0; i < n; i += 2 {
+		idxs = append(idxs, i)
+	}
+	b.ResetTimer()
+	for range b.N {
+		s = SwapDeleteMany(s, idxs)
+		s = SwapInsertMany(s, idxs, idxs)
+	}
+}
+
+// BenchmarkDeleteObjects deletes then undoes the deletion of half of the buildings of a large scene
+func BenchmarkDeleteObjects(b *testing.B) {
+	const n = 10000
+	var s Scene
+	for i := range n {
+		s.Buildings = append(s.Buildings, Building{Pos: vec2(float32(i), 0)})
+	}
+	sel := ObjectSelection{}
+	for i := 0; i < n; i += 2 {
+		sel.BuildingIdxs = append(sel.BuildingIdxs, i)
+	}
+	selection = Selection{}
+	b.ResetTimer()
+	for range b.N {
+		s.DeleteObjects(sel)
+		s.Undo()
+	}
+}
+
+// TestSegmentIntersectsRect checks segment / rectangle intersections, edges included
+func TestSegmentIntersectsRect(t *testing.T) {
+	r := rl.NewRectangle(0, 0, 10, 10)
+	for _, tc := range []struct {
+		a, b rl.Vector2
+		want bool
+	}{
+		{vec2(2, 2), vec2(3, 3), true},     // inside
+		{vec2(-5, 5), vec2(15, 5), true},   // through
+		{vec2(-5, -5), vec2(15, 15), true}, // diagonal through
+		{vec2(-5, 0), vec2(-1, 0), false},  // aside
+		{vec2(-5, 15), vec2(5, 5), true},   // touching a corner
+		{vec2(-2, 13), vec2(2, 17), false}, // passing by a corner
+		{vec2(10, -5), vec2(10, -1), false},
+		{vec2(10, -5), vec2(10, 0), true}, // ending on a corner
+		{vec2(5, 5), vec2(5, 5), true},    // degenerate, inside
+		{vec2(20, 5), vec2(20, 5), false}, // degenerate, outside
+	} {
+		if got := SegmentIntersectsRect(tc.a, tc.b, r); got != tc.want {
+			t.Errorf("SegmentIntersectsRect(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
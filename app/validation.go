@@ -0,0 +1,254 @@
+// validation - Scene semantic validation (overlaps, short paths, dangling path ends, regions)
+
+package app
+
+import (
+	"fmt"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+const (
+	// Paths shorter than this are reported as [IssueShortPath] (world units)
+	minPathLength = 0.5
+	// Maximum distance for a path end to be considered connected (world units)
+	connectEpsilon = 0.5
+)
+
+// SceneIssueKind enumerates the kinds of issues reported by [Scene.Validate]
+type SceneIssueKind int
+
+const (
+	// Two buildings overlap
+	IssueOverlap SceneIssueKind = iota
+	// A path is (almost) zero-length
+	IssueShortPath
+	// A path end is not connected to any building or other path
+	IssueDanglingEnd
+	// A building center is outside every region (see [Scene.Regions])
+	IssueOutsideRegion
+)
+
+func (k SceneIssueKind) String() string {
+	switch k {
+	case IssueOverlap:
+		return "IssueOverlap"
+	case IssueShortPath:
+		return "IssueShortPath"
+	case IssueDanglingEnd:
+		return "IssueDanglingEnd"
+	case IssueOutsideRegion:
+		return "IssueOutsideRegion"
+	default:
+		return "Invalid"
+	}
+}
+
+// SceneIssue represents a semantic issue found by [Scene.Validate]
+type SceneIssue struct {
+	Kind SceneIssueKind
+	// Object is the object the issue is about
+	Object Object
+	// Other is the other object involved in the issue ([IssueOverlap] only)
+	Other Object
+	// Msg is a human readable description of the issue
+	Msg string
+}
+
+func (i SceneIssue) String() string { return i.Msg }
+
+// Validate runs semantic checks on the scene and returns the issues found, it never modifies the scene.
+//
+// Issues are reported in this order:
+//   - overlapping buildings pairs ([IssueOverlap])
+//   - (almost) zero-length paths ([IssueShortPath])
+//   - path ends not connected to a building or another path ([IssueDanglingEnd])
+//   - buildings outside every region, if any region is defined ([IssueOutsideRegion])
+//
+// Hidden objects (see [Scene.Hide]) are not reported, as they cannot be selected: overlaps are only
+// reported between visible buildings. They still count as connections of the path ends.
+//
+// Dangling ends are found with the spatial index, see [Scene.updateSpatialIndex].
+func (s *Scene) Validate() []SceneIssue {
+	var issues []SceneIssue
+	issues = append(issues, s.overlapIssues()...)
+
+	for i, p := range s.Paths {
+		if !p.Hidden && p.Start.Distance(p.End) < minPathLength {
+			issues = append(issues, SceneIssue{
+				Kind:   IssueShortPath,
+				Object: Object{Type: TypePath, Idx: i},
+				Msg:    fmt.Sprintf("path %d is too short (%v)", i, p),
+			})
+		}
+	}
+
+	for i, p := range s.Paths {
+		if p.Hidden {
+			continue
+		}
+		if !s.isPathEndConnected(i, p.Start) {
+			issues = append(issues, SceneIssue{
+				Kind:   IssueDanglingEnd,
+				Object: Object{Type: TypePathStart, Idx: i},
+				Msg:    fmt.Sprintf("path %d start is not connected (%v)", i, p),
+			})
+		}
+		if !s.isPathEndConnected(i, p.End) {
+			issues = append(issues, SceneIssue{
+				Kind:   IssueDanglingEnd,
+				Object: Object{Type: TypePathEnd, Idx: i},
+				Msg:    fmt.Sprintf("path %d end is not connected (%v)", i, p),
+			})
+		}
+	}
+
+	for _, i := range s.BuildingsOutsideRegions() {
+		if s.Buildings[i].Hidden {
+			continue
+		}
+		issues = append(issues, SceneIssue{
+			Kind:   IssueOutsideRegion,
+			Object: Object{Type: TypeBuilding, Idx: i},
+			Msg:    fmt.Sprintf("building %d is outside every region (%v)", i, s.Buildings[i]),
+		})
+	}
+	return issues
+}
+
+// overlapIssues returns an [IssueOverlap] for each pair of overlapping visible buildings
+func (s Scene) overlapIssues() []SceneIssue {
+	var issues []SceneIssue
+	for _, pair := range overlappingPairs(s.buildingsBounds()) {
+		a, b := pair[0], pair[1]
+		if s.Buildings[a].Hidden || s.Buildings[b].Hidden {
+			continue
+		}
+		issues = append(issues, SceneIssue{
+			Kind:   IssueOverlap,
+			Object: Object{Type: TypeBuilding, Idx: a},
+			Other:  Object{Type: TypeBuilding, Idx: b},
+			Msg:    fmt.Sprintf("buildings %d and %d overlap (%v, %v)", a, b, s.Buildings[a], s.Buildings[b]),
+		})
+	}
+	return issues
+}
+
+// buildingsBounds returns the bounds of every building
+func (s Scene) buildingsBounds() []rl.Rectangle {
+	bounds := make([]rl.Rectangle, len(s.Buildings))
+	for i, b := range s.Buildings {
+		bounds[i] = b.Bounds()
+	}
+	return bounds
+}
+
+// overlappingPairs returns the pairs of indices (smallest first) of the colliding bounds, sorted
+//
+// Bounds are swept along the X axis so only bounds with overlapping X ranges are tested.
+func overlappingPairs(bounds []rl.Rectangle) [][2]int {
+	order := Range(0, len(bounds))
+	slices.SortFunc(order, func(a, b int) int {
+		switch {
+		case bounds[a].X < bounds[b].X:
+			return -1
+		case bounds[a].X > bounds[b].X:
+			return 1
+		default:
+			return a - b
+		}
+	})
+
+	var pairs [][2]int
+	for k, i := range order {
+		for _, j := range order[k+1:] {
+			if bounds[j].X >= bounds[i].X+bounds[i].Width {
+				break
+			}
+			if bounds[i].CheckCollisionRec(bounds[j]) {
+				pairs = append(pairs, [2]int{min(i, j), max(i, j)})
+			}
+		}
+	}
+	slices.SortFunc(pairs, func(a, b [2]int) int {
+		if a[0] != b[0] {
+			return a[0] - b[0]
+		}
+		return a[1] - b[1]
+	})
+	return pairs
+}
+
+// isPathEndConnected returns true if the given end of path idx touches a building or another path
+//
+// Only the objects near pos in the spatial index are tested.
+func (s *Scene) isPathEndConnected(idx int, pos rl.Vector2) bool {
+	s.updateSpatialIndex()
+	r := GrowRect(rl.NewRectangleV(pos, rl.Vector2{}), connectEpsilon)
+	buildings, ok := s.index.buildingsNear(r)
+	if !ok {
+		buildings = Range(0, len(s.Buildings))
+	}
+	for _, i := range buildings {
+		bounds := s.Buildings[i].Bounds()
+		bounds = rl.NewRectangleV(bounds.TopLeft().SubtractValue(connectEpsilon), bounds.Size().AddValue(2*connectEpsilon))
+		if bounds.CheckCollisionPoint(pos) {
+			return true
+		}
+	}
+	paths, ok := s.index.pathsNear(r)
+	if !ok {
+		paths = Range(0, len(s.Paths))
+	}
+	for _, i := range paths {
+		if i == idx {
+			continue
+		}
+		if p := s.Paths[i]; pos.Distance(p.Start) <= connectEpsilon || pos.Distance(p.End) <= connectEpsilon || p.CheckCollisionPoint(pos) {
+			return true
+		}
+	}
+	return false
+}
+
+// SelectIssues returns the selection of the objects involved in the given issues (see
+// [Scene.Validate]), with its bounds.
+//
+// Only the issues of the given kinds are considered, or every issue if none is given. Short paths
+// are fully selected, only the dangling end is selected for [IssueDanglingEnd].
+func (s Scene) SelectIssues(issues []SceneIssue, kinds ...SceneIssueKind) ObjectSelection {
+	buildings := map[int]bool{}
+	paths := map[int]PathSel{}
+	for _, issue := range issues {
+		if len(kinds) > 0 && !slices.Contains(kinds, issue.Kind) {
+			continue
+		}
+		for _, obj := range [2]Object{issue.Object, issue.Other} {
+			switch obj.Type {
+			case TypeBuilding:
+				buildings[obj.Idx] = true
+			case TypePath, TypePathStart, TypePathEnd:
+				ps := paths[obj.Idx]
+				ps.Idx = obj.Idx
+				ps.Start = ps.Start || obj.Type != TypePathEnd
+				ps.End = ps.End || obj.Type != TypePathStart
+				paths[obj.Idx] = ps
+			}
+		}
+	}
+
+	var sel ObjectSelection
+	for idx := range buildings {
+		sel.BuildingIdxs = append(sel.BuildingIdxs, idx)
+	}
+	slices.Sort(sel.BuildingIdxs)
+	for _, ps := range paths {
+		sel.PathIdxs = append(sel.PathIdxs, ps)
+	}
+	slices.SortFunc(sel.PathIdxs, func(a, b PathSel) int { return a.Idx - b.Idx })
+	if !sel.IsEmpty() {
+		sel.recomputeBounds(s.ObjectCollection)
+	}
+	return sel
+}
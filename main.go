@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime/pprof"
+	"time"
 
 	"github.com/bonoboris/satisfied/app"
 	"github.com/bonoboris/satisfied/log"
@@ -26,6 +27,7 @@ var (
 	vverbose   *bool
 	quiet      *bool
 	fps        *int
+	autosave   *time.Duration
 	cpuprofile *string
 	memprofile *string
 )
@@ -44,6 +46,7 @@ func init() {
 	verbose = fs.Bool("v", false, "DEBUG verbosity")
 	vverbose = fs.Bool("vv", false, "TRACE verbosity")
 	fps = fs.Int("fps", app.DefaultTargetFPS, "Target / Max FPS, (use a low value when using -vv to reduce the ammount of logs)")
+	autosave = fs.Duration("autosave", 0, "Autosave interval to the recovery file (0 for the default, negative to disable)")
 
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
@@ -76,6 +79,7 @@ func parseArgs() (slog.Level, *app.AppOptions) {
 		opts.File = app.NormalizePath(fs.Arg(0))
 	}
 	opts.Fps = *fps
+	opts.AutosaveInterval = *autosave
 	return logLevel, opts
 }
 
@@ -87,6 +87,16 @@ func Round(x float32) float32 { return float32(math.Round(float64(x))) }
 //	Pow(x, y) = NaN for finite x < 0 and finite non-integer y
 func Pow(x, y float32) float32 { return float32(math.Pow(float64(x), float64(y))) }
 
+// Sqrt returns the square root of x.
+//
+// Special cases are:
+//
+//	Sqrt(+Inf) = +Inf
+//	Sqrt(±0) = ±0
+//	Sqrt(x < 0) = NaN
+//	Sqrt(NaN) = NaN
+func Sqrt(x float32) float32 { return float32(math.Sqrt(float64(x))) }
+
 // Abs returns the absolute value of x.
 //
 // Special cases are:
@@ -95,6 +105,9 @@ func Pow(x, y float32) float32 { return float32(math.Pow(float64(x), float64(y))
 //	Abs(NaN) = NaN
 func Abs(x float32) float32 { return float32(math.Abs(float64(x))) }
 
+// Copysign returns a value with the magnitude of f and the sign of sign.
+func Copysign(f, sign float32) float32 { return float32(math.Copysign(float64(f), float64(sign))) }
+
 // Cos returns the cosine of the radian argument x.
 //
 // Special cases are:
@@ -137,6 +150,12 @@ func Acos(x float32) float32 { return float32(math.Acos(float64(x))) }
 //	Asin(x) = NaN if x < -1 or x > 1
 func Asin(x float32) float32 { return float32(math.Asin(float64(x))) }
 
+// Atan2 returns the arc tangent of y/x, using the signs of the two to determine the quadrant of
+// the return value.
+//
+// See [math.Atan2] for special cases.
+func Atan2(y, x float32) float32 { return float32(math.Atan2(float64(y), float64(x))) }
+
 // Mod returns the floating-point remainder of x/y.
 // The magnitude of the result is less than y and its
 // sign agrees with that of x.
@@ -149,3 +168,12 @@ func Asin(x float32) float32 { return float32(math.Asin(float64(x))) }
 //	Mod(x, ±Inf) = x
 //	Mod(x, NaN) = NaN
 func Mod(x, y float32) float32 { return float32(math.Mod(float64(x), float64(y))) }
+
+// IsNaN reports whether x is an IEEE 754 “not-a-number” value.
+func IsNaN(x float32) bool { return x != x }
+
+// IsInf reports whether x is an infinity, according to sign.
+// If sign > 0, IsInf reports whether x is positive infinity.
+// If sign < 0, IsInf reports whether x is negative infinity.
+// If sign == 0, IsInf reports whether x is either infinity.
+func IsInf(x float32, sign int) bool { return math.IsInf(float64(x), sign) }